@@ -12,7 +12,9 @@ import (
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"tschwaa.com/api/requests"
 	"tschwaa.com/api/server"
+	"tschwaa.com/api/services"
 	"tschwaa.com/api/storage"
 )
 
@@ -44,14 +46,34 @@ func start() int {
 		_ = log.Sync()
 	}()
 
+	keys, err := services.LoadKeySetFromEnv()
+	if err != nil {
+		log.Info("Error loading the JWT keys", zap.Error(err))
+		return 1
+	}
+	services.UseKeySet(keys)
+
+	if err := services.CheckSecrets(); err != nil {
+		log.Info("Error loading the secrets", zap.Error(err))
+		return 1
+	}
+
+	mailer, err := requests.NewMailerFromEnv()
+	if err != nil {
+		log.Info("Error setting up the mailer", zap.Error(err))
+		return 1
+	}
+
 	host := getStringOrDefault("HOST", "0.0.0.0")
 	port := getIntOrDefault("PORT", 8080)
 
 	s := server.New(server.Options{
-		Database: createDatabase(log),
-		Host:     host,
-		Port:     port,
-		Log:      log,
+		AccountDeletionInterval: getDurationOrDefault("ACCOUNT_DELETION_INTERVAL", time.Hour),
+		Database:                createDatabase(log),
+		Host:                    host,
+		Port:                    port,
+		Log:                     log,
+		Mailer:                  mailer,
 	})
 
 	var eg errgroup.Group
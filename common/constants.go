@@ -5,3 +5,91 @@ const (
 	SESSION_PLACE_GIVEN_VENUE = "given_venue"
 	SESSION_PLACE_MEMBER_HOME = "member_home"
 )
+
+const (
+	MEMBERSHIP_ROLE_ADMIN   = "admin"
+	MEMBERSHIP_ROLE_OFFICER = "officer"
+	MEMBERSHIP_ROLE_MEMBER  = "member"
+)
+
+const (
+	OTP_CHANNEL_WHATSAPP = "whatsapp"
+	OTP_CHANNEL_SMS      = "sms"
+	OTP_CHANNEL_EMAIL    = "email"
+	OTP_CHANNEL_LOG      = "log"
+)
+
+const (
+	OTP_PURPOSE_SIGN_IN        = "sign_in"
+	OTP_PURPOSE_PASSWORD_RESET = "password_reset"
+	OTP_PURPOSE_PHONE_CHANGE   = "phone_change"
+	OTP_PURPOSE_PHONE_RECOVERY = "phone_recovery"
+)
+
+const (
+	CLIENT_TYPE_WEB    = "web"
+	CLIENT_TYPE_MOBILE = "mobile"
+)
+
+const (
+	PHONE_CHANGE_STATUS_PENDING   = "pending"
+	PHONE_CHANGE_STATUS_COMPLETED = "completed"
+	PHONE_CHANGE_STATUS_CANCELLED = "cancelled"
+)
+
+const (
+	API_KEY_PERMISSION_READ_ORGANIZATION  = "read:organization"
+	API_KEY_PERMISSION_READ_MEMBERS       = "read:members"
+	API_KEY_PERMISSION_READ_SESSIONS      = "read:sessions"
+	API_KEY_PERMISSION_READ_CONTRIBUTIONS = "read:contributions"
+)
+
+const (
+	WEBAUTHN_CEREMONY_REGISTRATION = "registration"
+	WEBAUTHN_CEREMONY_LOGIN        = "login"
+)
+
+const (
+	CONTRIBUTION_FREQUENCY_ONCE     = "once"
+	CONTRIBUTION_FREQUENCY_WEEKLY   = "weekly"
+	CONTRIBUTION_FREQUENCY_BIWEEKLY = "biweekly"
+	CONTRIBUTION_FREQUENCY_MONTHLY  = "monthly"
+)
+
+const (
+	ROTATION_METHOD_FIXED  = "fixed"
+	ROTATION_METHOD_DRAW   = "draw"
+	ROTATION_METHOD_MANUAL = "manual"
+)
+
+const (
+	SWAP_STATUS_PENDING  = "pending"
+	SWAP_STATUS_APPROVED = "approved"
+	SWAP_STATUS_REJECTED = "rejected"
+)
+
+const (
+	AUCTION_BIDDING_SEALED = "sealed"
+	AUCTION_BIDDING_OPEN   = "open"
+)
+
+const (
+	AUCTION_STATUS_OPEN   = "open"
+	AUCTION_STATUS_CLOSED = "closed"
+)
+
+const (
+	AUCTION_PAYOUT_POT      = "pot"
+	AUCTION_PAYOUT_DIVIDEND = "dividend"
+)
+
+const (
+	MEETING_FREQUENCY_WEEKLY   = "weekly"
+	MEETING_FREQUENCY_BIWEEKLY = "biweekly"
+	MEETING_FREQUENCY_MONTHLY  = "monthly"
+)
+
+const (
+	MEETING_STATUS_SCHEDULED = "scheduled"
+	MEETING_STATUS_CANCELLED = "cancelled"
+)
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/storage"
+)
+
+type exportAccount interface {
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	ListMembershipsOfMember(ctx context.Context, memberID uint64) ([]*models.Membership, error)
+	ListSessionsOfMember(ctx context.Context, memberID uint64) ([]*models.MembersOfSession, error)
+	ListInvitationsOfMember(ctx context.Context, memberID uint64) ([]*models.Invitation, error)
+	ListOTPsFromPhone(ctx context.Context, phone string) ([]*models.Otp, error)
+}
+
+type scheduleAccountDeletion interface {
+	GetPendingAccountDeletion(ctx context.Context, memberID uint64) (*models.AccountDeletion, error)
+	ListOrganizationsLastAdministeredByMember(ctx context.Context, memberID uint64) ([]uint64, error)
+	CreateAccountDeletion(ctx context.Context, arg storage.CreateAccountDeletionParams) (*models.AccountDeletion, error)
+}
+
+type getAccountDeletion interface {
+	GetPendingAccountDeletion(ctx context.Context, memberID uint64) (*models.AccountDeletion, error)
+}
+
+type cancelAccountDeletion interface {
+	GetPendingAccountDeletion(ctx context.Context, memberID uint64) (*models.AccountDeletion, error)
+	CancelAccountDeletion(ctx context.Context, id uint64) error
+}
+
+type ExportProfile struct {
+	Member          *models.Member `json:"member"`
+	Email           string         `json:"email,omitempty"`
+	Phone           string         `json:"phone,omitempty"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty"`
+	CreatedAt       *time.Time     `json:"created_at,omitempty"`
+}
+
+// writeJSONToZip adds a file holding the indented JSON of data to the archive
+func writeJSONToZip(zw *zip.Writer, name string, data interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+func ExportAccount(mux chi.Router, s exportAccount) {
+	mux.Get("/user/export", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_EXPT_101", http.StatusUnauthorized)
+			return
+		}
+
+		profile := ExportProfile{Member: currentMember}
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_EXPT_102", http.StatusBadRequest)
+			return
+		}
+		if user != nil {
+			profile.Email = user.Email
+			profile.Phone = user.Phone
+			profile.EmailVerifiedAt = user.EmailVerifiedAt
+			profile.CreatedAt = &user.CreatedAt
+		}
+
+		memberships, err := s.ListMembershipsOfMember(ctx, currentMember.ID)
+		if err != nil {
+			log.Printf("error when listing the memberships of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_EXPT_103", http.StatusBadRequest)
+			return
+		}
+
+		sessions, err := s.ListSessionsOfMember(ctx, currentMember.ID)
+		if err != nil {
+			log.Printf("error when listing the sessions of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_EXPT_104", http.StatusBadRequest)
+			return
+		}
+
+		invitations, err := s.ListInvitationsOfMember(ctx, currentMember.ID)
+		if err != nil {
+			log.Printf("error when listing the invitations of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_EXPT_105", http.StatusBadRequest)
+			return
+		}
+
+		otps, err := s.ListOTPsFromPhone(ctx, currentMember.Phone)
+		if err != nil {
+			log.Printf("error when listing the otps of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_EXPT_106", http.StatusBadRequest)
+			return
+		}
+		// the history of the codes is exported, never the codes themselves
+		for _, otp := range otps {
+			otp.PinCode = ""
+		}
+
+		// the archive is built in memory so that a failure can still be reported to the client
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		files := []struct {
+			name string
+			data interface{}
+		}{
+			{"profile.json", profile},
+			{"memberships.json", memberships},
+			{"sessions.json", sessions},
+			{"invitations.json", invitations},
+			{"otps.json", otps},
+		}
+		for _, file := range files {
+			if err := writeJSONToZip(zw, file.name, file.data); err != nil {
+				log.Printf("error when writing %s of member[%d]: %s", file.name, currentMember.ID, err)
+				http.Error(w, "ERR_EXPT_107", http.StatusInternalServerError)
+				return
+			}
+		}
+		if err := zw.Close(); err != nil {
+			log.Printf("error when closing the export of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_EXPT_108", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="tschwaa-export.zip"`)
+		w.WriteHeader(http.StatusOK)
+		if _, err := buf.WriteTo(w); err != nil {
+			log.Println("error when writing the export: ", err)
+		}
+	})
+}
+
+func ScheduleAccountDeletion(mux chi.Router, s scheduleAccountDeletion) {
+	mux.Delete("/user", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_SDEL_101", http.StatusUnauthorized)
+			return
+		}
+
+		deletion, err := s.GetPendingAccountDeletion(ctx, currentMember.ID)
+		if err != nil {
+			log.Printf("error when getting the account deletion of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_SDEL_102", http.StatusBadRequest)
+			return
+		}
+		if deletion != nil {
+			log.Printf("the account of member[%d] is already scheduled for deletion", currentMember.ID)
+			http.Error(w, "ERR_SDEL_103", http.StatusConflict)
+			return
+		}
+
+		// an organization must keep an admin, another member has to be made admin first
+		organizations, err := s.ListOrganizationsLastAdministeredByMember(ctx, currentMember.ID)
+		if err != nil {
+			log.Printf("error when listing the organizations administered by member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_SDEL_106", http.StatusBadRequest)
+			return
+		}
+		if len(organizations) > 0 {
+			log.Printf("member[%d] is the last admin of organizations %v", currentMember.ID, organizations)
+			http.Error(w, "ERR_SDEL_107", http.StatusConflict)
+			return
+		}
+
+		// kept to tell the member in their language if the deletion has to be cancelled
+		language := r.URL.Query().Get("language")
+		if language == "" {
+			language = "fr"
+		}
+
+		deletion, err = s.CreateAccountDeletion(ctx, storage.CreateAccountDeletionParams{
+			MemberID:     currentMember.ID,
+			ScheduledFor: time.Now().UTC().Add(helpers.AccountDeletionGracePeriod),
+			Language:     language,
+		})
+		if err != nil {
+			log.Printf("error when scheduling the account deletion of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_SDEL_104", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(deletion); err != nil {
+			log.Println("error when encoding the account deletion: ", err)
+			http.Error(w, "ERR_SDEL_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func GetAccountDeletion(mux chi.Router, s getAccountDeletion) {
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_GDEL_101", http.StatusUnauthorized)
+			return
+		}
+
+		deletion, err := s.GetPendingAccountDeletion(ctx, currentMember.ID)
+		if err != nil {
+			log.Printf("error when getting the account deletion of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_GDEL_102", http.StatusBadRequest)
+			return
+		}
+		if deletion == nil {
+			http.Error(w, "ERR_GDEL_103", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(deletion); err != nil {
+			log.Println("error when encoding the account deletion: ", err)
+			http.Error(w, "ERR_GDEL_104", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func CancelAccountDeletion(mux chi.Router, s cancelAccountDeletion) {
+	mux.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_CDEL_101", http.StatusUnauthorized)
+			return
+		}
+
+		deletion, err := s.GetPendingAccountDeletion(ctx, currentMember.ID)
+		if err != nil {
+			log.Printf("error when getting the account deletion of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_CDEL_102", http.StatusBadRequest)
+			return
+		}
+		if deletion == nil {
+			http.Error(w, "ERR_CDEL_103", http.StatusNotFound)
+			return
+		}
+
+		if err := s.CancelAccountDeletion(ctx, deletion.ID); err != nil {
+			log.Printf("error when cancelling account deletion %d: %s", deletion.ID, err)
+			http.Error(w, "ERR_CDEL_104", http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
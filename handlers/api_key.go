@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/services"
+	"tschwaa.com/api/storage"
+)
+
+type createAPIKey interface {
+	CreateAPIKey(ctx context.Context, arg storage.CreateAPIKeyParams) (*models.APIKey, error)
+}
+
+type listAPIKeys interface {
+	ListAPIKeysOfOrganization(ctx context.Context, organizationID uint64) ([]*models.APIKey, error)
+}
+
+type revokeAPIKey interface {
+	RevokeAPIKey(ctx context.Context, arg storage.RevokeAPIKeyParams) (*models.APIKey, error)
+}
+
+var apiKeyPermissions = map[string]bool{
+	common.API_KEY_PERMISSION_READ_ORGANIZATION:  true,
+	common.API_KEY_PERMISSION_READ_MEMBERS:       true,
+	common.API_KEY_PERMISSION_READ_SESSIONS:      true,
+	common.API_KEY_PERMISSION_READ_CONTRIBUTIONS: true,
+}
+
+type CreateAPIKeyRequest struct {
+	Name        string   `json:"name,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+type CreateAPIKeyResult struct {
+	*models.APIKey
+	// Key is only ever shown in this response
+	Key string `json:"key"`
+}
+
+func CreateAPIKey(mux chi.Router, s createAPIKey) {
+	mux.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_CAPK_101", http.StatusBadRequest)
+			return
+		}
+
+		var input CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the api key request: ", err)
+			http.Error(w, "ERR_CAPK_102", http.StatusBadRequest)
+			return
+		}
+
+		input.Name = strings.TrimSpace(input.Name)
+		if input.Name == "" || len(input.Permissions) == 0 {
+			http.Error(w, "ERR_CAPK_103", http.StatusBadRequest)
+			return
+		}
+		for _, permission := range input.Permissions {
+			if !apiKeyPermissions[permission] {
+				log.Printf("unknown api key permission %q", permission)
+				http.Error(w, "ERR_CAPK_104", http.StatusBadRequest)
+				return
+			}
+		}
+
+		key, prefix, keyHash, err := services.GenerateAPIKey()
+		if err != nil {
+			log.Println("error when generating the api key: ", err)
+			http.Error(w, "ERR_CAPK_105", http.StatusInternalServerError)
+			return
+		}
+
+		var createdBy *uint64
+		if currentMember := GetCurrentMember(r); currentMember != nil {
+			createdBy = &currentMember.ID
+		}
+
+		apiKey, err := s.CreateAPIKey(ctx, storage.CreateAPIKeyParams{
+			OrganizationID: orgID,
+			CreatedBy:      createdBy,
+			Name:           input.Name,
+			Prefix:         prefix,
+			KeyHash:        keyHash,
+			Permissions:    input.Permissions,
+		})
+		if err != nil {
+			log.Printf("error when creating an api key for organization[%d]: %s", orgID, err)
+			http.Error(w, "ERR_CAPK_106", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(CreateAPIKeyResult{APIKey: apiKey, Key: key}); err != nil {
+			log.Println("error when encoding the api key: ", err)
+			http.Error(w, "ERR_CAPK_107", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func ListAPIKeys(mux chi.Router, s listAPIKeys) {
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_LAPK_101", http.StatusBadRequest)
+			return
+		}
+
+		apiKeys, err := s.ListAPIKeysOfOrganization(ctx, orgID)
+		if err != nil {
+			log.Printf("error when listing the api keys of organization[%d]: %s", orgID, err)
+			http.Error(w, "ERR_LAPK_102", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(apiKeys); err != nil {
+			log.Println("error when encoding the api keys: ", err)
+			http.Error(w, "ERR_LAPK_103", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func RevokeAPIKey(mux chi.Router, s revokeAPIKey) {
+	mux.Delete("/{apiKeyID}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_RAPK_101", http.StatusBadRequest)
+			return
+		}
+
+		apiKeyID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "apiKeyID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the api key id: ", err)
+			http.Error(w, "ERR_RAPK_102", http.StatusBadRequest)
+			return
+		}
+
+		apiKey, err := s.RevokeAPIKey(ctx, storage.RevokeAPIKeyParams{
+			ID:             apiKeyID,
+			OrganizationID: orgID,
+		})
+		if err != nil {
+			log.Printf("error when revoking api key[%d] of organization[%d]: %s", apiKeyID, orgID, err)
+			http.Error(w, "ERR_RAPK_103", http.StatusBadRequest)
+			return
+		}
+		if apiKey == nil {
+			http.Error(w, "ERR_RAPK_104", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
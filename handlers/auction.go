@@ -0,0 +1,563 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/storage"
+)
+
+type getAuctionMode interface {
+	GetAuctionMode(ctx context.Context, sessionID uint64) (*models.AuctionMode, error)
+}
+
+type updateAuctionMode interface {
+	UpsertAuctionMode(ctx context.Context, arg storage.UpsertAuctionModeParams) (*models.AuctionMode, error)
+}
+
+type listAuctions interface {
+	ListAuctionsOfSession(ctx context.Context, sessionID uint64) ([]*models.Auction, error)
+}
+
+type openAuction interface {
+	GetSession(ctx context.Context, arg storage.GetSessionParams) (*models.Session, error)
+	GetAuctionMode(ctx context.Context, sessionID uint64) (*models.AuctionMode, error)
+	CreateAuction(ctx context.Context, arg storage.CreateAuctionParams) (*models.Auction, error)
+}
+
+type getAuction interface {
+	GetAuction(ctx context.Context, arg storage.GetAuctionParams) (*models.Auction, error)
+	ListAuctionBids(ctx context.Context, auctionID uint64) ([]*models.AuctionBid, error)
+	ListAuctionPayouts(ctx context.Context, auctionID uint64) ([]*models.AuctionPayout, error)
+}
+
+type placeAuctionBid interface {
+	GetAuction(ctx context.Context, arg storage.GetAuctionParams) (*models.Auction, error)
+	GetSharesInSession(ctx context.Context, arg storage.IsMembershipInSessionParams) (int, error)
+	CountAuctionsWon(ctx context.Context, arg storage.CountAuctionsWonParams) (int64, error)
+	CreateAuctionBid(ctx context.Context, arg storage.CreateAuctionBidParams) (*models.AuctionBid, error)
+}
+
+type closeAuction interface {
+	GetAuction(ctx context.Context, arg storage.GetAuctionParams) (*models.Auction, error)
+	CloseAuctionTx(ctx context.Context, arg storage.CloseAuctionTxParams) (*models.Auction, error)
+	ListAuctionBids(ctx context.Context, auctionID uint64) ([]*models.AuctionBid, error)
+	ListAuctionPayouts(ctx context.Context, auctionID uint64) ([]*models.AuctionPayout, error)
+}
+
+var auctionBiddings = map[string]bool{
+	common.AUCTION_BIDDING_SEALED: true,
+	common.AUCTION_BIDDING_OPEN:   true,
+}
+
+type UpdateAuctionModeRequest struct {
+	Bidding  string `json:"bidding,omitempty"`
+	Pot      int64  `json:"pot,omitempty"`
+	Currency string `json:"currency,omitempty"`
+}
+
+type OpenAuctionRequest struct {
+	// MeetingDate is the day of the meeting whose pot is auctioned, as 2006-01-02
+	MeetingDate string `json:"meeting_date,omitempty"`
+	// Bidding and Pot default to the auction mode of the session
+	Bidding string `json:"bidding,omitempty"`
+	Pot     int64  `json:"pot,omitempty"`
+}
+
+type PlaceAuctionBidRequest struct {
+	Discount int64 `json:"discount"`
+}
+
+type AuctionDetails struct {
+	*models.Auction
+	// BidCount counts every bid, even the ones still sealed
+	BidCount int                     `json:"bid_count"`
+	Bids     []*models.AuctionBid    `json:"bids"`
+	Payouts  []*models.AuctionPayout `json:"payouts"`
+}
+
+// visibleAuctionBids hides the bids of the others while a sealed auction is open,
+// once it is closed every bid is there to be audited
+func visibleAuctionBids(auction *models.Auction, bids []*models.AuctionBid, membership *models.Membership) []*models.AuctionBid {
+	if auction.Bidding != common.AUCTION_BIDDING_SEALED || auction.Status != common.AUCTION_STATUS_OPEN {
+		return bids
+	}
+
+	visible := []*models.AuctionBid{}
+	if membership == nil {
+		return visible
+	}
+	for _, bid := range bids {
+		if bid.MembershipID == membership.ID {
+			visible = append(visible, bid)
+		}
+	}
+
+	return visible
+}
+
+func GetAuctionMode(mux chi.Router, s getAuctionMode) {
+	mux.Get("/mode", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_GAUM_101", http.StatusBadRequest)
+			return
+		}
+
+		mode, err := s.GetAuctionMode(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when getting the auction mode of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_GAUM_102", http.StatusBadRequest)
+			return
+		}
+		if mode == nil {
+			http.Error(w, "ERR_GAUM_103", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(mode); err != nil {
+			log.Println("error when encoding the auction mode: ", err)
+			http.Error(w, "ERR_GAUM_104", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// UpdateAuctionMode awards the pots of the session by auction, with the bidding and pot
+// of its meetings
+func UpdateAuctionMode(mux chi.Router, s updateAuctionMode) {
+	mux.Put("/mode", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_UAUM_101", http.StatusBadRequest)
+			return
+		}
+
+		var input UpdateAuctionModeRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the auction mode: ", err)
+			http.Error(w, "ERR_UAUM_102", http.StatusBadRequest)
+			return
+		}
+
+		input.Currency = strings.ToUpper(strings.TrimSpace(input.Currency))
+		if input.Currency == "" {
+			input.Currency = defaultCurrency
+		}
+		if !auctionBiddings[input.Bidding] || input.Pot <= 0 || !currencyCode.MatchString(input.Currency) {
+			log.Printf("invalid auction mode for session[%d]: %+v", sessionID, input)
+			http.Error(w, "ERR_UAUM_103", http.StatusBadRequest)
+			return
+		}
+
+		var createdBy *uint64
+		if currentMember := GetCurrentMember(r); currentMember != nil {
+			createdBy = &currentMember.ID
+		}
+
+		mode, err := s.UpsertAuctionMode(ctx, storage.UpsertAuctionModeParams{
+			SessionID: sessionID,
+			Bidding:   input.Bidding,
+			Pot:       input.Pot,
+			Currency:  input.Currency,
+			CreatedBy: createdBy,
+		})
+		if err != nil {
+			log.Printf("error when saving the auction mode of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_UAUM_104", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(mode); err != nil {
+			log.Println("error when encoding the auction mode: ", err)
+			http.Error(w, "ERR_UAUM_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func ListAuctions(mux chi.Router, s listAuctions) {
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_LAUC_101", http.StatusBadRequest)
+			return
+		}
+
+		auctions, err := s.ListAuctionsOfSession(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when listing the auctions of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_LAUC_102", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(auctions); err != nil {
+			log.Println("error when encoding the auctions: ", err)
+			http.Error(w, "ERR_LAUC_103", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// OpenAuction opens the bidding for the pot of a meeting of a session in auction mode
+func OpenAuction(mux chi.Router, s openAuction) {
+	mux.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_OAUC_101", http.StatusBadRequest)
+			return
+		}
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_OAUC_101", http.StatusBadRequest)
+			return
+		}
+
+		var input OpenAuctionRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the auction: ", err)
+			http.Error(w, "ERR_OAUC_102", http.StatusBadRequest)
+			return
+		}
+
+		mode, err := s.GetAuctionMode(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when getting the auction mode of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_OAUC_103", http.StatusBadRequest)
+			return
+		}
+		if mode == nil {
+			log.Printf("session[%d] is not in auction mode", sessionID)
+			http.Error(w, "ERR_OAUC_104", http.StatusConflict)
+			return
+		}
+
+		if input.Bidding == "" {
+			input.Bidding = mode.Bidding
+		}
+		if input.Pot == 0 {
+			input.Pot = mode.Pot
+		}
+		meetingDate, err := time.Parse("2006-01-02", input.MeetingDate)
+		if err != nil || !auctionBiddings[input.Bidding] || input.Pot <= 0 {
+			log.Printf("invalid auction for session[%d]: %+v", sessionID, input)
+			http.Error(w, "ERR_OAUC_105", http.StatusBadRequest)
+			return
+		}
+
+		session, err := s.GetSession(ctx, storage.GetSessionParams{
+			OrganizationID: orgID,
+			SessionID:      sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting session[%d] of organization[%d]: %s", sessionID, orgID, err)
+			http.Error(w, "ERR_OAUC_106", http.StatusBadRequest)
+			return
+		}
+		if meetingDate.Before(session.StartDate) || meetingDate.After(session.EndDate) {
+			log.Printf("the meeting of %s is out of session[%d]", input.MeetingDate, sessionID)
+			http.Error(w, "ERR_OAUC_107", http.StatusBadRequest)
+			return
+		}
+
+		var createdBy *uint64
+		if currentMember := GetCurrentMember(r); currentMember != nil {
+			createdBy = &currentMember.ID
+		}
+
+		auction, err := s.CreateAuction(ctx, storage.CreateAuctionParams{
+			SessionID:   sessionID,
+			MeetingDate: meetingDate,
+			Bidding:     input.Bidding,
+			Pot:         input.Pot,
+			Currency:    mode.Currency,
+			CreatedBy:   createdBy,
+		})
+		if err != nil {
+			log.Printf("error when opening the auction of %s in session[%d]: %s", input.MeetingDate, sessionID, err)
+			http.Error(w, "ERR_OAUC_108", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(auction); err != nil {
+			log.Println("error when encoding the auction: ", err)
+			http.Error(w, "ERR_OAUC_109", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func GetAuction(mux chi.Router, s getAuction) {
+	mux.Get("/{auctionID}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_GAUC_101", http.StatusBadRequest)
+			return
+		}
+		auctionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "auctionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the auction id: ", err)
+			http.Error(w, "ERR_GAUC_101", http.StatusBadRequest)
+			return
+		}
+
+		auction, err := s.GetAuction(ctx, storage.GetAuctionParams{
+			ID:        auctionID,
+			SessionID: sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting auction[%d] of session[%d]: %s", auctionID, sessionID, err)
+			http.Error(w, "ERR_GAUC_102", http.StatusBadRequest)
+			return
+		}
+		if auction == nil {
+			http.Error(w, "ERR_GAUC_103", http.StatusNotFound)
+			return
+		}
+
+		bids, err := s.ListAuctionBids(ctx, auction.ID)
+		if err != nil {
+			log.Printf("error when listing the bids of auction[%d]: %s", auction.ID, err)
+			http.Error(w, "ERR_GAUC_104", http.StatusBadRequest)
+			return
+		}
+		payouts, err := s.ListAuctionPayouts(ctx, auction.ID)
+		if err != nil {
+			log.Printf("error when listing the payouts of auction[%d]: %s", auction.ID, err)
+			http.Error(w, "ERR_GAUC_105", http.StatusBadRequest)
+			return
+		}
+
+		details := AuctionDetails{
+			Auction:  auction,
+			BidCount: len(bids),
+			Bids:     visibleAuctionBids(auction, bids, GetCurrentMembership(r)),
+			Payouts:  payouts,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(details); err != nil {
+			log.Println("error when encoding the auction: ", err)
+			http.Error(w, "ERR_GAUC_106", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// PlaceAuctionBid bids a discount on the pot for the current member. The members who
+// have already taken a pot of the session for each of their hands can not bid anymore.
+func PlaceAuctionBid(mux chi.Router, s placeAuctionBid) {
+	mux.Post("/{auctionID}/bids", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_PAUB_101", http.StatusBadRequest)
+			return
+		}
+		auctionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "auctionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the auction id: ", err)
+			http.Error(w, "ERR_PAUB_101", http.StatusBadRequest)
+			return
+		}
+
+		membership := GetCurrentMembership(r)
+		if membership == nil {
+			http.Error(w, "ERR_PAUB_102", http.StatusForbidden)
+			return
+		}
+
+		var input PlaceAuctionBidRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the bid: ", err)
+			http.Error(w, "ERR_PAUB_103", http.StatusBadRequest)
+			return
+		}
+
+		auction, err := s.GetAuction(ctx, storage.GetAuctionParams{
+			ID:        auctionID,
+			SessionID: sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting auction[%d] of session[%d]: %s", auctionID, sessionID, err)
+			http.Error(w, "ERR_PAUB_104", http.StatusBadRequest)
+			return
+		}
+		if auction == nil {
+			http.Error(w, "ERR_PAUB_105", http.StatusNotFound)
+			return
+		}
+		if input.Discount < 0 || input.Discount >= auction.Pot {
+			log.Printf("invalid bid of membership[%d] on auction[%d]: %+v", membership.ID, auction.ID, input)
+			http.Error(w, "ERR_PAUB_106", http.StatusBadRequest)
+			return
+		}
+
+		shares, err := s.GetSharesInSession(ctx, storage.IsMembershipInSessionParams{
+			SessionID:    sessionID,
+			MembershipID: membership.ID,
+		})
+		if err != nil {
+			log.Printf("error when checking membership[%d] in session[%d]: %s", membership.ID, sessionID, err)
+			http.Error(w, "ERR_PAUB_107", http.StatusBadRequest)
+			return
+		}
+		if shares == 0 {
+			log.Printf("membership[%d] does not take part in session[%d]", membership.ID, sessionID)
+			http.Error(w, "ERR_PAUB_108", http.StatusForbidden)
+			return
+		}
+
+		won, err := s.CountAuctionsWon(ctx, storage.CountAuctionsWonParams{
+			SessionID:    sessionID,
+			MembershipID: membership.ID,
+		})
+		if err != nil {
+			log.Printf("error when checking the auctions won by membership[%d] in session[%d]: %s", membership.ID, sessionID, err)
+			http.Error(w, "ERR_PAUB_109", http.StatusBadRequest)
+			return
+		}
+		// A member takes a pot once per hand held in the session
+		if won >= int64(shares) {
+			log.Printf("membership[%d] has already won its %d pots of session[%d]", membership.ID, shares, sessionID)
+			http.Error(w, "ERR_PAUB_110", http.StatusForbidden)
+			return
+		}
+
+		bid, err := s.CreateAuctionBid(ctx, storage.CreateAuctionBidParams{
+			AuctionID:    auction.ID,
+			MembershipID: membership.ID,
+			Discount:     input.Discount,
+		})
+		if err != nil {
+			log.Printf("error when placing the bid of membership[%d] on auction[%d]: %s", membership.ID, auction.ID, err)
+			http.Error(w, "ERR_PAUB_111", http.StatusBadRequest)
+			return
+		}
+		// Either the auction is closed, or the bid does not beat the highest one
+		if bid == nil {
+			http.Error(w, "ERR_PAUB_112", http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(bid); err != nil {
+			log.Println("error when encoding the bid: ", err)
+			http.Error(w, "ERR_PAUB_113", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// CloseAuction awards the pot to the highest bid and shares the discount among the others
+func CloseAuction(mux chi.Router, s closeAuction) {
+	mux.Post("/{auctionID}/close", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_CAUC_101", http.StatusBadRequest)
+			return
+		}
+		auctionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "auctionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the auction id: ", err)
+			http.Error(w, "ERR_CAUC_101", http.StatusBadRequest)
+			return
+		}
+
+		auction, err := s.GetAuction(ctx, storage.GetAuctionParams{
+			ID:        auctionID,
+			SessionID: sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting auction[%d] of session[%d]: %s", auctionID, sessionID, err)
+			http.Error(w, "ERR_CAUC_102", http.StatusBadRequest)
+			return
+		}
+		if auction == nil {
+			http.Error(w, "ERR_CAUC_103", http.StatusNotFound)
+			return
+		}
+
+		auction, err = s.CloseAuctionTx(ctx, storage.CloseAuctionTxParams{
+			AuctionID: auction.ID,
+			SessionID: sessionID,
+		})
+		if errors.Is(err, storage.ErrAuctionNotOpen) {
+			http.Error(w, "ERR_CAUC_104", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("error when closing auction[%d] of session[%d]: %s", auctionID, sessionID, err)
+			http.Error(w, "ERR_CAUC_105", http.StatusBadRequest)
+			return
+		}
+
+		bids, err := s.ListAuctionBids(ctx, auction.ID)
+		if err != nil {
+			log.Printf("error when listing the bids of auction[%d]: %s", auction.ID, err)
+			http.Error(w, "ERR_CAUC_106", http.StatusBadRequest)
+			return
+		}
+		payouts, err := s.ListAuctionPayouts(ctx, auction.ID)
+		if err != nil {
+			log.Printf("error when listing the payouts of auction[%d]: %s", auction.ID, err)
+			http.Error(w, "ERR_CAUC_107", http.StatusBadRequest)
+			return
+		}
+
+		details := AuctionDetails{
+			Auction:  auction,
+			BidCount: len(bids),
+			Bids:     bids,
+			Payouts:  payouts,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(details); err != nil {
+			log.Println("error when encoding the auction: ", err)
+			http.Error(w, "ERR_CAUC_108", http.StatusBadRequest)
+			return
+		}
+	})
+}
@@ -5,13 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/fatih/structs"
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v4"
 	"go.uber.org/zap"
+	"tschwaa.com/api/common"
 	"tschwaa.com/api/helpers"
 	"tschwaa.com/api/models"
 	"tschwaa.com/api/requests"
@@ -31,6 +34,7 @@ type SignUpInputs struct {
 type SignInInputs struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Language string `json:"language,omitempty"`
 }
 
 type SignInResult struct {
@@ -38,7 +42,12 @@ type SignInResult struct {
 	Name  string  `json:"name",omitempty`
 	Email string  `json:"email",omitempty`
 	Phone string  `json:"phone,omitempty"`
-	Token *string `json:"access_token",omitempty`
+	Token *string `json:"access_token,omitempty"`
+
+	EmailVerified bool `json:"email_verified"`
+	TwoFactor     bool `json:"two_factor"`
+
+	RefreshToken *string `json:"refresh_token,omitempty"`
 }
 
 type JWTClaims struct {
@@ -50,12 +59,39 @@ type authWeb interface {
 	GetMemberByUsername(ctx context.Context, arg storage.GetMemberByUsernameParams) (*models.Member, error)
 	CreateMember(ctx context.Context, arg storage.CreateMemberParams) (*models.Member, error)
 	CreateUserWithMemberTx(ctx context.Context, arg storage.CreateUserWithMemberParams) (uint64, error)
-	CreateMemberWithAssociatedUserTx(ctx context.Context, arg storage.CreateMemberWithAssociatedUserParams) error
+	CreateMemberWithAssociatedUserTx(ctx context.Context, arg storage.CreateMemberWithAssociatedUserParams) (*models.User, error)
+	GetUserByUsername(ctx context.Context, arg storage.GetUserByUsernameParams) (*models.User, error)
+	GetMemberByID(ctx context.Context, id uint64) (*models.Member, error)
+	CreateLoginTx(ctx context.Context, arg storage.CreateLoginTxParams) (*models.Login, error)
+}
+
+type signIn interface {
+	signInThrottler
 	GetUserByUsername(ctx context.Context, arg storage.GetUserByUsernameParams) (*models.User, error)
 	GetMemberByID(ctx context.Context, id uint64) (*models.Member, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	CreateLoginTx(ctx context.Context, arg storage.CreateLoginTxParams) (*models.Login, error)
+	UpdateUserPassword(ctx context.Context, arg storage.UpdateUserPasswordParams) error
+}
+
+type updateUserPassword interface {
+	UpdateUserPassword(ctx context.Context, arg storage.UpdateUserPasswordParams) error
+}
+
+// rehashPassword stores the password of the user hashed again with HashPassword
+func rehashPassword(ctx context.Context, s updateUserPassword, userID uint64, password string) error {
+	hashedPassword, err := helpers.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateUserPassword(ctx, storage.UpdateUserPasswordParams{
+		ID:       userID,
+		Password: hashedPassword,
+	})
 }
 
-func SignUp(mux chi.Router, s authWeb) {
+func SignUp(mux chi.Router, s authWeb, m requests.Mailer) {
 	mux.Post("/sign-up", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
@@ -68,6 +104,12 @@ func SignUp(mux chi.Router, s authWeb) {
 			return
 		}
 
+		if err := helpers.ValidatePassword(inputs.Password); err != nil {
+			log.Println("the password does not follow the password policy: ", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Check if a user with the same email exist
 		existingMember, err := s.GetMemberByUsername(ctx, storage.GetMemberByUsernameParams{
 			Phone: inputs.Phone,
@@ -80,7 +122,7 @@ func SignUp(mux chi.Router, s authWeb) {
 			return
 		}
 
-		err = s.CreateMemberWithAssociatedUserTx(ctx, storage.CreateMemberWithAssociatedUserParams{
+		user, err := s.CreateMemberWithAssociatedUserTx(ctx, storage.CreateMemberWithAssociatedUserParams{
 			FirstName: inputs.FirstName,
 			LastName:  inputs.LastName,
 			Sex:       inputs.Sex,
@@ -94,6 +136,11 @@ func SignUp(mux chi.Router, s authWeb) {
 			return
 		}
 
+		// the account is created unverified, the link can be sent again later
+		if err := sendEmailVerification(m, user.MemberID, user.Email); err != nil {
+			log.Println("Error sendEmailVerification: ", err)
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		if err := json.NewEncoder(w).Encode(true); err != nil {
@@ -103,7 +150,7 @@ func SignUp(mux chi.Router, s authWeb) {
 	})
 }
 
-func SignIn(mux chi.Router, s authWeb) {
+func SignIn(mux chi.Router, s signIn, n requests.LockoutNotifier) {
 	mux.Post("/sign-in", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
@@ -117,24 +164,61 @@ func SignIn(mux chi.Router, s authWeb) {
 			return
 		}
 
+		usernameKey, ipKey := signInThrottleKeys(r, credentials.Username)
+		wait, err := signInWaitRemaining(ctx, s, usernameKey, ipKey)
+		if err != nil {
+			log.Println("error when getting the sign-in throttles: ", err)
+			http.Error(w, "ERR_SGN_IN_101", http.StatusBadRequest)
+			return
+		}
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "ERR_SGN_IN_102", http.StatusTooManyRequests)
+			return
+		}
+
 		existingUser, err := s.GetUserByUsername(ctx, storage.GetUserByUsernameParams{
 			Phone: credentials.Username,
 			Email: credentials.Username,
 		})
-		if err != nil || existingUser == nil {
-			err = fmt.Errorf("user with that username does not exist: %w", err)
-			log.Println("Error CreateUser", zap.Error(err))
-			http.Error(w, "user with that username does not exist", http.StatusBadRequest)
+		if err != nil {
+			log.Println("Error GetUserByUsername", zap.Error(err))
+			http.Error(w, "ERR_SGN_IN_103", http.StatusBadRequest)
 			return
 		}
 
-		if !helpers.IsPasswordMatched(existingUser.Password, credentials.Password) {
-			err = fmt.Errorf("the password is not correct: %w", err)
-			log.Println("Error CreateUser", zap.Error(err))
-			http.Error(w, "the password is not correct", http.StatusBadRequest)
+		// an unknown username and a wrong password look the same from the outside
+		matched := false
+		if existingUser == nil {
+			helpers.WastePasswordCheck(credentials.Password)
+		} else {
+			matched = helpers.IsPasswordMatched(existingUser.Password, credentials.Password)
+		}
+		if !matched {
+			if lockedUntil, err := recordSignInFailure(ctx, s, usernameKey, helpers.SignInMaxFailures); err != nil {
+				log.Println("error when recording the sign-in failure of the username: ", err)
+			} else if lockedUntil != nil {
+				notifySignInLockout(n, existingUser, credentials.Language, *lockedUntil)
+			}
+			if _, err := recordSignInFailure(ctx, s, ipKey, helpers.SignInIPMaxFailures); err != nil {
+				log.Println("error when recording the sign-in failure of the ip: ", err)
+			}
+
+			http.Error(w, "ERR_SGN_IN_104", http.StatusBadRequest)
 			return
 		}
 
+		if err := s.ResetSignInThrottle(ctx, usernameKey); err != nil {
+			log.Println("error when resetting the sign-in throttle of the username: ", err)
+		}
+
+		// the password is known at last, its hash gets upgraded to the current format and parameters
+		if helpers.PasswordNeedsRehash(existingUser.Password) {
+			if err := rehashPassword(ctx, s, existingUser.ID, credentials.Password); err != nil {
+				log.Printf("error when rehashing the password of user[%d]: %s", existingUser.ID, err)
+			}
+		}
+
 		existingMember, err := s.GetMemberByID(ctx, existingUser.MemberID)
 		if err != nil || existingMember == nil {
 			err = fmt.Errorf("member related to the user does not exist: %w", err)
@@ -143,32 +227,46 @@ func SignIn(mux chi.Router, s authWeb) {
 			return
 		}
 
+		// the members with an authenticator app get their tokens once they enter its code
+		enrolled, err := hasTwoFactor(ctx, s, existingUser.ID)
+		if err != nil {
+			log.Printf("error when getting the two-factor authentication of user[%d]: %s", existingUser.ID, err)
+			http.Error(w, "ERR_SGN_IN_105", http.StatusBadRequest)
+			return
+		}
+		if enrolled {
+			if err := writeTwoFactorChallenge(w, existingMember.ID); err != nil {
+				log.Println("error when encoding the two-factor challenge: ", err)
+				http.Error(w, "ERR_SGN_IN_106", http.StatusBadRequest)
+			}
+			return
+		}
+
 		var signInResult SignInResult
 		signInResult.Name = fmt.Sprintf("%s %s", existingMember.FirstName, existingMember.LastName)
 		signInResult.Email = existingMember.Email
 		signInResult.ID = existingMember.ID
+		signInResult.EmailVerified = existingUser.EmailVerifiedAt != nil
+
+		channel := common.CLIENT_TYPE_WEB
+		if services.UsesBearerToken(r) {
+			channel = common.CLIENT_TYPE_MOBILE
+		}
+		login, refreshToken, err := openLogin(ctx, s, r, existingMember.ID, channel, false)
+		if err != nil {
+			log.Println("Error CreateLogin", zap.Error(err))
+			http.Error(w, "error when creating token", http.StatusBadRequest)
+			return
+		}
 
-		tokenString, err := services.GenerateJWTToken(structs.Map(&signInResult))
+		tokenString, err := services.GenerateJWTToken(structs.Map(&signInResult), login.ID)
 		if err != nil {
 			log.Println("Error CreateUser", zap.Error(err))
 			http.Error(w, "error when creating token", http.StatusBadRequest)
 			return
 		}
 
-		signInResult.Token = nil
-		http.SetCookie(
-			w,
-			&http.Cookie{
-				Name:     "jwt",
-				Value:    tokenString,
-				Path:     "/",
-				Expires:  time.Now().Add(1 * time.Hour),
-				HttpOnly: true,
-				Secure:   true,
-				MaxAge:   3600,
-				SameSite: http.SameSiteLaxMode,
-			},
-		)
+		setAuthTokens(w, r, &signInResult, tokenString, refreshToken)
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -183,13 +281,45 @@ type authMobile interface {
 	DoesUserExist(ctx context.Context, phoneNumber string) (bool, error)
 	CreateOTPTx(ctx context.Context, arg storage.CreateOTPParams) (*models.Otp, error)
 	DeactivateOTP(ctx context.Context, id uint64) error
-	CheckOTP(ctx context.Context, arg storage.CheckOTPParams) (*models.Otp, error)
+	VerifyOTPTx(ctx context.Context, arg storage.VerifyOTPParams) (*models.Otp, error)
+	GetOTPSendStatsFromPhone(ctx context.Context, arg storage.GetOTPSendStatsFromPhoneParams) (*storage.OTPSendStats, error)
 	GetMemberByPhone(ctx context.Context, phone string) (*models.Member, error)
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	CreateLoginTx(ctx context.Context, arg storage.CreateLoginTxParams) (*models.Login, error)
+}
+
+// otpDelivery sends a pin code over the channel asked for, falling back on the others
+type otpDelivery interface {
+	Send(preferred string, to requests.OtpRecipient, language, pinCode string) (*requests.OtpDeliveryResult, error)
+}
+
+type otpSendStats interface {
+	GetOTPSendStatsFromPhone(ctx context.Context, arg storage.GetOTPSendStatsFromPhoneParams) (*storage.OTPSendStats, error)
+}
+
+// canSendOtp tells whether a new pin code can be sent to the phone number, within both
+// the cooldown and the daily limit whatever the path sending it
+func canSendOtp(ctx context.Context, s otpSendStats, phone string) (bool, error) {
+	now := time.Now().UTC()
+	stats, err := s.GetOTPSendStatsFromPhone(ctx, storage.GetOTPSendStatsFromPhoneParams{
+		Phone: phone,
+		Since: now.Add(-24 * time.Hour),
+	})
+	if err != nil {
+		return false, err
+	}
+	if stats.Latest == nil {
+		return true, nil
+	}
+
+	return !now.Before(helpers.NextOtpSendAt(*stats.Latest, stats.Count, *stats.Oldest)), nil
 }
 
 type GetOtpRequest struct {
 	Phone    string `json:"phone,omitempty"`
 	Language string `json:"language,omitempty"`
+	Channel  string `json:"channel,omitempty"`
 }
 
 type CheckOtpRequest struct {
@@ -198,7 +328,7 @@ type CheckOtpRequest struct {
 	PinCode  string `json:"pin_code,omitempty"`
 }
 
-func GetOtp(mux chi.Router, a authMobile) {
+func GetOtp(mux chi.Router, a authMobile, d otpDelivery) {
 	mux.Post("/otp", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		var input GetOtpRequest
@@ -228,26 +358,48 @@ func GetOtp(mux chi.Router, a authMobile) {
 			return
 		}
 
-		// generate the pin code of 4 digits
-		now := time.Now()
-		pinCode := helpers.GeneratePinCode(now)
+		// only one pin code per cooldown period for a phone number, and a limited number per day
+		canSend, err := canSendOtp(ctx, a, input.Phone)
+		if err != nil {
+			log.Println("error when getting the Otp sent to the phone: ", err)
+			http.Error(w, "ERR_COTP_154", http.StatusBadRequest)
+			return
+		}
+		if !canSend {
+			log.Println("an Otp has been sent too recently to: ", input.Phone)
+			http.Error(w, "ERR_COTP_155", http.StatusTooManyRequests)
+			return
+		}
 
-		// send the pin code to a the phone number using Whatsapp API
-		res, err := requests.SendTschwaaOtp(
-			input.Phone,
-			input.Language,
-			pinCode,
-		)
+		// generate the pin code
+		pinCode, err := helpers.GeneratePinCode()
 		if err != nil {
-			log.Println("error when sending the Otp via WhatsApp: ", err)
+			log.Println("error when generating the pin code: ", err)
+			http.Error(w, "ERR_COTP_156", http.StatusBadRequest)
+			return
+		}
+
+		// the email is only there for the email channel
+		recipient := requests.OtpRecipient{Phone: input.Phone}
+		if member, err := a.GetMemberByPhone(ctx, input.Phone); err == nil && member != nil {
+			recipient.Email = member.Email
+		}
+
+		// send the pin code over the channel asked for, or the next one that works
+		res, err := d.Send(input.Channel, recipient, input.Language, pinCode)
+		if err != nil {
+			log.Println("error when sending the Otp: ", err)
 			http.Error(w, "ERR_COTP_152", http.StatusBadRequest)
 			return
 		}
 
 		_, err = a.CreateOTPTx(r.Context(), storage.CreateOTPParams{
-			WaMessageID: res.Messages[0].ID,
-			Phone:       input.Phone,
-			PinCode:     pinCode,
+			MessageID: res.MessageID,
+			Channel:   res.Channel,
+			Phone:     input.Phone,
+			PinCode:   pinCode,
+			ExpiresAt: time.Now().UTC().Add(helpers.OtpTTL),
+			Purpose:   common.OTP_PURPOSE_SIGN_IN,
 		})
 		if err != nil {
 			log.Println("error when saving the Otp: ", err)
@@ -281,13 +433,24 @@ func CheckOtp(mux chi.Router, a authMobile) {
 		}
 
 		// check that the phone number is correct
-		otp, err := a.CheckOTP(ctx, storage.CheckOTPParams{
-			Phone:   input.Phone,
-			PinCode: input.PinCode,
+		otp, err := a.VerifyOTPTx(ctx, storage.VerifyOTPParams{
+			Phone:       input.Phone,
+			PinCode:     input.PinCode,
+			MaxAttempts: helpers.OtpMaxAttempts,
+			Purpose:     common.OTP_PURPOSE_SIGN_IN,
 		})
 		if err != nil {
 			log.Println("error when checking the otp: ", err)
-			http.Error(w, fmt.Sprintf("ERR_COTP_102_%s", err), http.StatusBadRequest)
+			switch err {
+			case storage.ErrOTPExpired:
+				http.Error(w, "ERR_COTP_108", http.StatusBadRequest)
+			case storage.ErrOTPLocked:
+				http.Error(w, "ERR_COTP_109", http.StatusBadRequest)
+			case storage.ErrOTPWrongCode, storage.ErrOTPNotFound:
+				http.Error(w, "ERR_COTP_110", http.StatusBadRequest)
+			default:
+				http.Error(w, "ERR_COTP_102", http.StatusBadRequest)
+			}
 			return
 		}
 
@@ -301,37 +464,56 @@ func CheckOtp(mux chi.Router, a authMobile) {
 
 		// Generating the JWT Token
 		member, err := a.GetMemberByPhone(ctx, input.Phone)
-		if err != nil {
-			log.Println("error when looking for user: ", err)
+		if err != nil || member == nil {
+			log.Println("error when looking for the member of the phone: ", err)
 			http.Error(w, "ERR_COTP_104", http.StatusBadRequest)
 			return
 		}
 
+		user, err := a.GetUserByMemberID(ctx, member.ID)
+		if err != nil || user == nil {
+			log.Println("error when looking for the user of the member: ", err)
+			http.Error(w, "ERR_COTP_111", http.StatusBadRequest)
+			return
+		}
+
+		// the pin code is only a first factor, the authenticator app still has to be used
+		enrolled, err := hasTwoFactor(ctx, a, user.ID)
+		if err != nil {
+			log.Printf("error when getting the two-factor authentication of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_COTP_112", http.StatusBadRequest)
+			return
+		}
+		if enrolled {
+			if err := writeTwoFactorChallenge(w, member.ID); err != nil {
+				log.Println("error when encoding the two-factor challenge: ", err)
+				http.Error(w, "ERR_COTP_113", http.StatusBadRequest)
+			}
+			return
+		}
+
 		var signInResult SignInResult
 		signInResult.Name = fmt.Sprintf("%s %s", member.FirstName, member.LastName)
+		signInResult.Email = member.Email
 		signInResult.Phone = member.Phone
+		signInResult.ID = member.ID
+		signInResult.EmailVerified = user.EmailVerifiedAt != nil
+
+		login, refreshToken, err := openLogin(ctx, a, r, member.ID, common.CLIENT_TYPE_MOBILE, false)
+		if err != nil {
+			log.Println("error when recording the login ", err)
+			http.Error(w, "ERR_COTP_107", http.StatusBadRequest)
+			return
+		}
 
-		tokenString, err := services.GenerateJWTToken(structs.Map(signInResult))
+		tokenString, err := services.GenerateJWTToken(structs.Map(signInResult), login.ID)
 		if err != nil {
 			log.Println("error when generating jwt token ", err)
 			http.Error(w, "ERR_COTP_105", http.StatusBadRequest)
 			return
 		}
 
-		signInResult.Token = nil // tokenString
-		http.SetCookie(
-			w,
-			&http.Cookie{
-				Name:     "jwt",
-				Value:    tokenString,
-				Path:     "/",
-				Expires:  time.Now().Add(1 * time.Hour),
-				HttpOnly: true,
-				Secure:   true,
-				MaxAge:   3600,
-				SameSite: http.SameSiteLaxMode,
-			},
-		)
+		setAuthTokens(w, r, &signInResult, tokenString, refreshToken)
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -343,32 +525,274 @@ func CheckOtp(mux chi.Router, a authMobile) {
 	})
 }
 
-func ResendOtp(mux chi.Router, m authMobile) {
+type resendOtp interface {
+	GetActiveOTPFromPhone(ctx context.Context, phone string) (*models.Otp, error)
+	GetOTPSendStatsFromPhone(ctx context.Context, arg storage.GetOTPSendStatsFromPhoneParams) (*storage.OTPSendStats, error)
+	CreateOTPTx(ctx context.Context, arg storage.CreateOTPParams) (*models.Otp, error)
+	GetMemberByPhone(ctx context.Context, phone string) (*models.Member, error)
+}
+
+type ResendOtpResult struct {
+	ResendAfter time.Time `json:"resend_after"`
+}
+
+func ResendOtp(mux chi.Router, a resendOtp, d otpDelivery) {
 	mux.Post("/otp/resend", func(w http.ResponseWriter, r *http.Request) {
-		// // read the request body
-		// var input CheckOtpRequest
-
-		// // read the request body
-		// decoder := json.NewDecoder(r.Body)
-
-		// // extract the phone number and the pin code
-		// err := decoder.Decode(&input)
-		// if err != nil {
-		// 	http.Error(w, err.Error(), http.StatusBadRequest)
-		// 	return
-		// }
-
-		// // check that the pin code is 6 digit
-		// var m *models.Otp
-
-		// // check that the phone number is correct
-		// m, err = a.CheckOtp(r.Context(), input.Phone, input.PinCode)
-		// if err != nil {
-		// 	http.Error(w, err.Error(), http.StatusBadRequest)
-		// 	return
-		// }
-
-		// m.Active = false
-		// a.SaveOtp(r.Context(), *m)
+		ctx := r.Context()
+
+		var input GetOtpRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when extracting the request body: ", err)
+			http.Error(w, "ERR_ROTP_101", http.StatusBadRequest)
+			return
+		}
+
+		// a pin code can only be resent if one has been requested, and for the same purpose
+		active, err := a.GetActiveOTPFromPhone(ctx, input.Phone)
+		if err != nil {
+			log.Println("error when getting the active Otp: ", err)
+			http.Error(w, "ERR_ROTP_102", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now().UTC()
+		stats, err := a.GetOTPSendStatsFromPhone(ctx, storage.GetOTPSendStatsFromPhoneParams{
+			Phone: input.Phone,
+			Since: now.Add(-24 * time.Hour),
+		})
+		if err != nil {
+			log.Println("error when getting the Otp sent to the phone: ", err)
+			http.Error(w, "ERR_ROTP_103", http.StatusBadRequest)
+			return
+		}
+		if stats.Latest != nil && now.Before(helpers.NextOtpSendAt(*stats.Latest, stats.Count, *stats.Oldest)) {
+			log.Println("too many Otp sent to: ", input.Phone)
+			http.Error(w, "ERR_ROTP_104", http.StatusTooManyRequests)
+			return
+		}
+
+		pinCode, err := helpers.GeneratePinCode()
+		if err != nil {
+			log.Println("error when generating the pin code: ", err)
+			http.Error(w, "ERR_ROTP_105", http.StatusBadRequest)
+			return
+		}
+
+		// the pin codes confirming a phone number only go to the number itself
+		recipient := requests.OtpRecipient{Phone: input.Phone}
+		if active.Purpose == common.OTP_PURPOSE_SIGN_IN {
+			if member, err := a.GetMemberByPhone(ctx, input.Phone); err == nil && member != nil {
+				recipient.Email = member.Email
+			}
+		}
+
+		res, err := d.Send(input.Channel, recipient, input.Language, pinCode)
+		if err != nil {
+			log.Println("error when sending the Otp: ", err)
+			http.Error(w, "ERR_ROTP_106", http.StatusBadRequest)
+			return
+		}
+
+		// the previous active Otp is deactivated along with the creation of the new one
+		_, err = a.CreateOTPTx(ctx, storage.CreateOTPParams{
+			MessageID: res.MessageID,
+			Channel:   res.Channel,
+			Phone:     input.Phone,
+			PinCode:   pinCode,
+			ExpiresAt: now.Add(helpers.OtpTTL),
+			Purpose:   active.Purpose,
+		})
+		if err != nil {
+			log.Println("error when saving the Otp: ", err)
+			http.Error(w, "ERR_ROTP_107", http.StatusBadRequest)
+			return
+		}
+
+		oldest := now
+		if stats.Oldest != nil {
+			oldest = *stats.Oldest
+		}
+
+		var result ResendOtpResult
+		result.ResendAfter = helpers.NextOtpSendAt(now, stats.Count+1, oldest)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Println("error when encoding the resend result: ", err)
+			http.Error(w, "ERR_ROTP_108", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+type signOut interface {
+	SignOutTx(ctx context.Context, arg storage.SignOutParams) error
+	RevokeAllMemberTokensTx(ctx context.Context, memberID uint64) error
+}
+
+type SignOutRequest struct {
+	Everywhere   bool   `json:"everywhere,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func SignOut(mux chi.Router, s signOut) {
+	mux.Post("/sign-out", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var input SignOutRequest
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				log.Println("error when decoding the sign out request: ", err)
+				http.Error(w, "ERR_SOUT_101", http.StatusBadRequest)
+				return
+			}
+		}
+
+		var params storage.SignOutParams
+		if info, ok := services.GetTokenInfo(ctx); ok {
+			params.JTI = info.ID
+			params.MemberID = info.MemberID
+			params.ExpiresAt = info.ExpiresAt
+			params.LoginID = info.LoginID
+		}
+		// bearer clients send their refresh token in the body
+		refreshToken := input.RefreshToken
+		if cookie, err := r.Cookie("refresh_token"); err == nil && cookie.Value != "" {
+			refreshToken = cookie.Value
+		}
+		if refreshToken != "" {
+			params.RefreshTokenHash = services.HashOpaqueToken(refreshToken)
+		}
+
+		if err := s.SignOutTx(ctx, params); err != nil {
+			log.Println("error when signing out: ", err)
+			http.Error(w, "ERR_SOUT_102", http.StatusBadRequest)
+			return
+		}
+
+		if input.Everywhere {
+			if params.MemberID == 0 {
+				log.Println("no signed in member to sign out everywhere")
+				http.Error(w, "ERR_SOUT_103", http.StatusUnauthorized)
+				return
+			}
+
+			if err := s.RevokeAllMemberTokensTx(ctx, params.MemberID); err != nil {
+				log.Println("error when signing out everywhere: ", err)
+				http.Error(w, "ERR_SOUT_104", http.StatusBadRequest)
+				return
+			}
+		}
+
+		clearAuthCookies(w)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(true); err != nil {
+			log.Println("error when encoding the sign out result: ", err)
+			http.Error(w, "ERR_SOUT_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+type createLogin interface {
+	CreateLoginTx(ctx context.Context, arg storage.CreateLoginTxParams) (*models.Login, error)
+}
+
+// openLogin records the login of the member from the device of the request, and whether
+// the member passed a second factor, and returns it with the first token of its refresh token family
+func openLogin(ctx context.Context, s createLogin, r *http.Request, memberID uint64, channel string, twoFactor bool) (*models.Login, string, error) {
+	familyID, _, err := services.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	refreshToken, refreshTokenHash, err := services.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var twoFactorAt *time.Time
+	if twoFactor {
+		now := time.Now().UTC()
+		twoFactorAt = &now
+	}
+
+	login, err := s.CreateLoginTx(ctx, storage.CreateLoginTxParams{
+		MemberID:    memberID,
+		FamilyID:    familyID,
+		TokenHash:   refreshTokenHash,
+		ExpiresAt:   time.Now().UTC().Add(services.RefreshTokenTTL),
+		Channel:     channel,
+		UserAgent:   r.UserAgent(),
+		IP:          clientIP(r),
+		TwoFactorAt: twoFactorAt,
 	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return login, refreshToken, nil
+}
+
+// setAuthTokens hands the tokens over in the result to bearer clients, and in cookies to the others
+func setAuthTokens(w http.ResponseWriter, r *http.Request, result *SignInResult, accessToken, refreshToken string) {
+	if services.UsesBearerToken(r) {
+		result.Token = &accessToken
+		result.RefreshToken = &refreshToken
+		return
+	}
+
+	result.Token = nil
+	result.RefreshToken = nil
+	setAuthCookies(w, accessToken, refreshToken)
+}
+
+func setAuthCookies(w http.ResponseWriter, accessToken, refreshToken string) {
+	http.SetCookie(
+		w,
+		&http.Cookie{
+			Name:     "jwt",
+			Value:    accessToken,
+			Path:     "/",
+			Expires:  time.Now().Add(services.AccessTokenTTL),
+			HttpOnly: true,
+			Secure:   true,
+			MaxAge:   int(services.AccessTokenTTL.Seconds()),
+			SameSite: http.SameSiteLaxMode,
+		},
+	)
+	http.SetCookie(
+		w,
+		&http.Cookie{
+			Name:     "refresh_token",
+			Value:    refreshToken,
+			Path:     "/",
+			Expires:  time.Now().Add(services.RefreshTokenTTL),
+			HttpOnly: true,
+			Secure:   true,
+			MaxAge:   int(services.RefreshTokenTTL.Seconds()),
+			SameSite: http.SameSiteLaxMode,
+		},
+	)
+}
+
+func clearAuthCookies(w http.ResponseWriter) {
+	for _, name := range []string{"jwt", "refresh_token"} {
+		http.SetCookie(
+			w,
+			&http.Cookie{
+				Name:     name,
+				Value:    "",
+				Path:     "/",
+				Expires:  time.Unix(0, 0),
+				HttpOnly: true,
+				Secure:   true,
+				MaxAge:   -1,
+				SameSite: http.SameSiteLaxMode,
+			},
+		)
+	}
 }
@@ -0,0 +1,595 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/storage"
+)
+
+type listContributionPlans interface {
+	ListContributionPlansOfSession(ctx context.Context, sessionID uint64) ([]*models.ContributionPlan, error)
+}
+
+type createContributionPlan interface {
+	CreateContributionPlan(ctx context.Context, arg storage.CreateContributionPlanParams) (*models.ContributionPlan, error)
+}
+
+type updateContributionPlan interface {
+	GetContributionPlan(ctx context.Context, arg storage.GetContributionPlanParams) (*models.ContributionPlan, error)
+	UpdateContributionPlan(ctx context.Context, arg storage.UpdateContributionPlanParams) (*models.ContributionPlan, error)
+}
+
+type recordContribution interface {
+	GetSession(ctx context.Context, arg storage.GetSessionParams) (*models.Session, error)
+	GetContributionPlan(ctx context.Context, arg storage.GetContributionPlanParams) (*models.ContributionPlan, error)
+	IsMembershipInSession(ctx context.Context, arg storage.IsMembershipInSessionParams) (bool, error)
+	CreateContribution(ctx context.Context, arg storage.CreateContributionParams) (*models.Contribution, error)
+}
+
+type listContributions interface {
+	ListContributionsOfSession(ctx context.Context, arg storage.ListContributionsOfSessionParams) ([]*models.Contribution, error)
+}
+
+type deleteContribution interface {
+	DeleteContribution(ctx context.Context, arg storage.DeleteContributionParams) (*models.Contribution, error)
+}
+
+type getContributionBalances interface {
+	GetSession(ctx context.Context, arg storage.GetSessionParams) (*models.Session, error)
+	ListContributionPlansOfSession(ctx context.Context, sessionID uint64) ([]*models.ContributionPlan, error)
+	ListMembersInSession(ctx context.Context, sessionID uint64) ([]*models.MembersOfSession, error)
+	SumContributionsOfSession(ctx context.Context, arg storage.SumContributionsOfSessionParams) ([]*models.ContributionTotal, error)
+}
+
+var contributionFrequencies = map[string]bool{
+	common.CONTRIBUTION_FREQUENCY_ONCE:     true,
+	common.CONTRIBUTION_FREQUENCY_WEEKLY:   true,
+	common.CONTRIBUTION_FREQUENCY_BIWEEKLY: true,
+	common.CONTRIBUTION_FREQUENCY_MONTHLY:  true,
+}
+
+// currencyCode is an ISO 4217 code, like XAF
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+const defaultCurrency = "XAF"
+
+type CreateContributionPlanRequest struct {
+	Name      string `json:"name,omitempty"`
+	Amount    int64  `json:"amount,omitempty"`
+	Currency  string `json:"currency,omitempty"`
+	Frequency string `json:"frequency,omitempty"`
+}
+
+type UpdateContributionPlanRequest struct {
+	Name      *string `json:"name,omitempty"`
+	Amount    *int64  `json:"amount,omitempty"`
+	Currency  *string `json:"currency,omitempty"`
+	Frequency *string `json:"frequency,omitempty"`
+}
+
+type RecordContributionRequest struct {
+	PlanID       uint64 `json:"plan_id,omitempty"`
+	MembershipID uint64 `json:"membership_id,omitempty"`
+	// MeetingDate is the day of the meeting the payment is for, as 2006-01-02
+	MeetingDate string `json:"meeting_date,omitempty"`
+	Amount      int64  `json:"amount,omitempty"`
+	Note        string `json:"note,omitempty"`
+}
+
+// ContributionPlanBalance sums up what the members of the session owe to a plan
+type ContributionPlanBalance struct {
+	*models.ContributionPlan
+	DueCount    int                           `json:"due_count"`
+	Expected    int64                         `json:"expected"`
+	Paid        int64                         `json:"paid"`
+	Outstanding int64                         `json:"outstanding"`
+	Members     []*models.ContributionBalance `json:"members"`
+}
+
+type ContributionBalances struct {
+	SessionID uint64                     `json:"session_id"`
+	AsOf      string                     `json:"as_of"`
+	Plans     []*ContributionPlanBalance `json:"plans"`
+}
+
+// validContributionPlan tells whether the plan has a name, an amount, a known currency and frequency
+func validContributionPlan(name string, amount int64, currency, frequency string) bool {
+	return name != "" && amount > 0 && currencyCode.MatchString(currency) && contributionFrequencies[frequency]
+}
+
+// contributionBalances computes, for every plan, what each member should have paid by asOf
+// for each of their hands against what they did pay
+func contributionBalances(session *models.Session, plans []*models.ContributionPlan, members []*models.MembersOfSession, totals []*models.ContributionTotal, asOf time.Time) []*ContributionPlanBalance {
+	paid := map[uint64]map[uint64]int64{}
+	for _, total := range totals {
+		if paid[total.PlanID] == nil {
+			paid[total.PlanID] = map[uint64]int64{}
+		}
+		paid[total.PlanID][total.MembershipID] = total.Paid
+	}
+
+	balances := make([]*ContributionPlanBalance, 0, len(plans))
+	for _, plan := range plans {
+		dueCount := helpers.ContributionDueCount(plan.Frequency, session.StartDate, session.EndDate, asOf)
+		balance := &ContributionPlanBalance{
+			ContributionPlan: plan,
+			DueCount:         dueCount,
+			Members:          make([]*models.ContributionBalance, 0, len(members)),
+		}
+
+		for _, member := range members {
+			memberBalance := &models.ContributionBalance{
+				MembershipID: member.MembershipID,
+				MemberID:     member.MemberID,
+				FirstName:    member.FirstName,
+				LastName:     member.LastName,
+				Shares:       member.Shares,
+				Expected:     plan.Amount * int64(dueCount) * int64(member.Shares),
+				Paid:         paid[plan.ID][member.MembershipID],
+			}
+			memberBalance.Outstanding = memberBalance.Expected - memberBalance.Paid
+
+			balance.Expected += memberBalance.Expected
+			balance.Paid += memberBalance.Paid
+			balance.Outstanding += memberBalance.Outstanding
+			balance.Members = append(balance.Members, memberBalance)
+		}
+
+		balances = append(balances, balance)
+	}
+
+	return balances
+}
+
+func ListContributionPlans(mux chi.Router, s listContributionPlans) {
+	mux.Get("/plans", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_LCTP_101", http.StatusBadRequest)
+			return
+		}
+
+		plans, err := s.ListContributionPlansOfSession(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when listing the contribution plans of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_LCTP_102", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(plans); err != nil {
+			log.Println("error when encoding the contribution plans: ", err)
+			http.Error(w, "ERR_LCTP_103", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func CreateContributionPlan(mux chi.Router, s createContributionPlan) {
+	mux.Post("/plans", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_CCTP_101", http.StatusBadRequest)
+			return
+		}
+
+		var input CreateContributionPlanRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the contribution plan: ", err)
+			http.Error(w, "ERR_CCTP_102", http.StatusBadRequest)
+			return
+		}
+
+		input.Name = strings.TrimSpace(input.Name)
+		input.Currency = strings.ToUpper(strings.TrimSpace(input.Currency))
+		if input.Currency == "" {
+			input.Currency = defaultCurrency
+		}
+		if !validContributionPlan(input.Name, input.Amount, input.Currency, input.Frequency) {
+			log.Printf("invalid contribution plan for session[%d]: %+v", sessionID, input)
+			http.Error(w, "ERR_CCTP_103", http.StatusBadRequest)
+			return
+		}
+
+		var createdBy *uint64
+		if currentMember := GetCurrentMember(r); currentMember != nil {
+			createdBy = &currentMember.ID
+		}
+
+		plan, err := s.CreateContributionPlan(ctx, storage.CreateContributionPlanParams{
+			SessionID: sessionID,
+			Name:      input.Name,
+			Amount:    input.Amount,
+			Currency:  input.Currency,
+			Frequency: input.Frequency,
+			CreatedBy: createdBy,
+		})
+		if err != nil {
+			log.Printf("error when creating a contribution plan for session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_CCTP_104", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(plan); err != nil {
+			log.Println("error when encoding the contribution plan: ", err)
+			http.Error(w, "ERR_CCTP_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func UpdateContributionPlan(mux chi.Router, s updateContributionPlan) {
+	mux.Patch("/plans/{planID}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_UCTP_101", http.StatusBadRequest)
+			return
+		}
+		planID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "planID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the contribution plan id: ", err)
+			http.Error(w, "ERR_UCTP_101", http.StatusBadRequest)
+			return
+		}
+
+		var input UpdateContributionPlanRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the contribution plan: ", err)
+			http.Error(w, "ERR_UCTP_102", http.StatusBadRequest)
+			return
+		}
+
+		plan, err := s.GetContributionPlan(ctx, storage.GetContributionPlanParams{
+			ID:        planID,
+			SessionID: sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting contribution plan[%d] of session[%d]: %s", planID, sessionID, err)
+			http.Error(w, "ERR_UCTP_103", http.StatusBadRequest)
+			return
+		}
+		if plan == nil {
+			http.Error(w, "ERR_UCTP_104", http.StatusNotFound)
+			return
+		}
+
+		// the fields left out of the request keep their value
+		arg := storage.UpdateContributionPlanParams{
+			ID:        plan.ID,
+			SessionID: plan.SessionID,
+			Name:      plan.Name,
+			Amount:    plan.Amount,
+			Currency:  plan.Currency,
+			Frequency: plan.Frequency,
+		}
+		if input.Name != nil {
+			arg.Name = strings.TrimSpace(*input.Name)
+		}
+		if input.Amount != nil {
+			arg.Amount = *input.Amount
+		}
+		if input.Currency != nil {
+			arg.Currency = strings.ToUpper(strings.TrimSpace(*input.Currency))
+		}
+		if input.Frequency != nil {
+			arg.Frequency = *input.Frequency
+		}
+		if !validContributionPlan(arg.Name, arg.Amount, arg.Currency, arg.Frequency) {
+			log.Printf("invalid contribution plan[%d] of session[%d]: %+v", planID, sessionID, arg)
+			http.Error(w, "ERR_UCTP_105", http.StatusBadRequest)
+			return
+		}
+
+		plan, err = s.UpdateContributionPlan(ctx, arg)
+		if err != nil || plan == nil {
+			log.Printf("error when updating contribution plan[%d] of session[%d]: %v", planID, sessionID, err)
+			http.Error(w, "ERR_UCTP_106", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(plan); err != nil {
+			log.Println("error when encoding the contribution plan: ", err)
+			http.Error(w, "ERR_UCTP_107", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func RecordContribution(mux chi.Router, s recordContribution) {
+	mux.Post("/payments", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_RCTB_101", http.StatusBadRequest)
+			return
+		}
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_RCTB_101", http.StatusBadRequest)
+			return
+		}
+
+		var input RecordContributionRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the contribution: ", err)
+			http.Error(w, "ERR_RCTB_102", http.StatusBadRequest)
+			return
+		}
+
+		// a partial payment is fine, the rest of the meeting comes with the next ones
+		meetingDate, err := time.Parse("2006-01-02", input.MeetingDate)
+		if err != nil || input.Amount <= 0 {
+			log.Printf("invalid contribution for session[%d]: %+v", sessionID, input)
+			http.Error(w, "ERR_RCTB_103", http.StatusBadRequest)
+			return
+		}
+
+		session, err := s.GetSession(ctx, storage.GetSessionParams{
+			OrganizationID: orgID,
+			SessionID:      sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting session[%d] of organization[%d]: %s", sessionID, orgID, err)
+			http.Error(w, "ERR_RCTB_104", http.StatusBadRequest)
+			return
+		}
+		if meetingDate.Before(session.StartDate) || meetingDate.After(session.EndDate) {
+			log.Printf("the meeting of %s is out of session[%d]", input.MeetingDate, sessionID)
+			http.Error(w, "ERR_RCTB_105", http.StatusBadRequest)
+			return
+		}
+
+		plan, err := s.GetContributionPlan(ctx, storage.GetContributionPlanParams{
+			ID:        input.PlanID,
+			SessionID: sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting contribution plan[%d] of session[%d]: %s", input.PlanID, sessionID, err)
+			http.Error(w, "ERR_RCTB_106", http.StatusBadRequest)
+			return
+		}
+		if plan == nil {
+			http.Error(w, "ERR_RCTB_107", http.StatusNotFound)
+			return
+		}
+
+		inSession, err := s.IsMembershipInSession(ctx, storage.IsMembershipInSessionParams{
+			SessionID:    sessionID,
+			MembershipID: input.MembershipID,
+		})
+		if err != nil {
+			log.Printf("error when checking membership[%d] in session[%d]: %s", input.MembershipID, sessionID, err)
+			http.Error(w, "ERR_RCTB_108", http.StatusBadRequest)
+			return
+		}
+		if !inSession {
+			log.Printf("membership[%d] does not take part in session[%d]", input.MembershipID, sessionID)
+			http.Error(w, "ERR_RCTB_109", http.StatusBadRequest)
+			return
+		}
+
+		var recordedBy *uint64
+		if currentMember := GetCurrentMember(r); currentMember != nil {
+			recordedBy = &currentMember.ID
+		}
+
+		contribution, err := s.CreateContribution(ctx, storage.CreateContributionParams{
+			PlanID:       plan.ID,
+			MembershipID: input.MembershipID,
+			MeetingDate:  meetingDate,
+			Amount:       input.Amount,
+			Note:         strings.TrimSpace(input.Note),
+			RecordedBy:   recordedBy,
+		})
+		if err != nil {
+			log.Printf("error when recording the contribution of membership[%d] to plan[%d]: %s", input.MembershipID, plan.ID, err)
+			http.Error(w, "ERR_RCTB_110", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(contribution); err != nil {
+			log.Println("error when encoding the contribution: ", err)
+			http.Error(w, "ERR_RCTB_111", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func ListContributions(mux chi.Router, s listContributions) {
+	mux.Get("/payments", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_LCTB_101", http.StatusBadRequest)
+			return
+		}
+
+		arg := storage.ListContributionsOfSessionParams{SessionID: sessionID}
+		if planID := r.URL.Query().Get("plan_id"); planID != "" {
+			if arg.PlanID, err = strconv.ParseUint(planID, 10, 64); err != nil {
+				http.Error(w, "ERR_LCTB_102", http.StatusBadRequest)
+				return
+			}
+		}
+		if membershipID := r.URL.Query().Get("membership_id"); membershipID != "" {
+			if arg.MembershipID, err = strconv.ParseUint(membershipID, 10, 64); err != nil {
+				http.Error(w, "ERR_LCTB_102", http.StatusBadRequest)
+				return
+			}
+		}
+
+		contributions, err := s.ListContributionsOfSession(ctx, arg)
+		if err != nil {
+			log.Printf("error when listing the contributions of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_LCTB_103", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(contributions); err != nil {
+			log.Println("error when encoding the contributions: ", err)
+			http.Error(w, "ERR_LCTB_104", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func DeleteContribution(mux chi.Router, s deleteContribution) {
+	mux.Delete("/payments/{contributionID}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_DCTB_101", http.StatusBadRequest)
+			return
+		}
+		contributionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "contributionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the contribution id: ", err)
+			http.Error(w, "ERR_DCTB_101", http.StatusBadRequest)
+			return
+		}
+
+		contribution, err := s.DeleteContribution(ctx, storage.DeleteContributionParams{
+			ID:        contributionID,
+			SessionID: sessionID,
+		})
+		if err != nil {
+			log.Printf("error when deleting contribution[%d] of session[%d]: %s", contributionID, sessionID, err)
+			http.Error(w, "ERR_DCTB_102", http.StatusBadRequest)
+			return
+		}
+		if contribution == nil {
+			http.Error(w, "ERR_DCTB_103", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func GetContributionBalances(mux chi.Router, s getContributionBalances) {
+	mux.Get("/balances", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_GCTB_101", http.StatusBadRequest)
+			return
+		}
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_GCTB_101", http.StatusBadRequest)
+			return
+		}
+
+		// the balances are those of today, unless another day is asked for
+		asOf := time.Now().UTC().Truncate(24 * time.Hour)
+		if day := r.URL.Query().Get("as_of"); day != "" {
+			if asOf, err = time.Parse("2006-01-02", day); err != nil {
+				http.Error(w, "ERR_GCTB_102", http.StatusBadRequest)
+				return
+			}
+		}
+		var membershipID uint64
+		if id := r.URL.Query().Get("membership_id"); id != "" {
+			if membershipID, err = strconv.ParseUint(id, 10, 64); err != nil {
+				http.Error(w, "ERR_GCTB_102", http.StatusBadRequest)
+				return
+			}
+		}
+
+		session, err := s.GetSession(ctx, storage.GetSessionParams{
+			OrganizationID: orgID,
+			SessionID:      sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting session[%d] of organization[%d]: %s", sessionID, orgID, err)
+			http.Error(w, "ERR_GCTB_103", http.StatusBadRequest)
+			return
+		}
+
+		plans, err := s.ListContributionPlansOfSession(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when listing the contribution plans of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_GCTB_104", http.StatusBadRequest)
+			return
+		}
+
+		members, err := s.ListMembersInSession(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when listing the members of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_GCTB_105", http.StatusBadRequest)
+			return
+		}
+		if membershipID != 0 {
+			filtered := make([]*models.MembersOfSession, 0, 1)
+			for _, member := range members {
+				if member.MembershipID == membershipID {
+					filtered = append(filtered, member)
+				}
+			}
+			members = filtered
+		}
+
+		totals, err := s.SumContributionsOfSession(ctx, storage.SumContributionsOfSessionParams{
+			SessionID: sessionID,
+			AsOf:      asOf,
+		})
+		if err != nil {
+			log.Printf("error when summing the contributions of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_GCTB_106", http.StatusBadRequest)
+			return
+		}
+
+		balances := ContributionBalances{
+			SessionID: sessionID,
+			AsOf:      asOf.Format("2006-01-02"),
+			Plans:     contributionBalances(session, plans, members, totals, asOf),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(balances); err != nil {
+			log.Println("error when encoding the contribution balances: ", err)
+			http.Error(w, "ERR_GCTB_107", http.StatusBadRequest)
+			return
+		}
+	})
+}
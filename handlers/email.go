@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/requests"
+	"tschwaa.com/api/services"
+	"tschwaa.com/api/storage"
+)
+
+type verifyEmail interface {
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	MarkUserEmailVerified(ctx context.Context, arg storage.MarkUserEmailVerifiedParams) error
+}
+
+type resendEmailVerification interface {
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token,omitempty"`
+}
+
+func sendEmailVerification(m requests.Mailer, memberID uint64, email string) error {
+	link := services.EmailVerificationLink(memberID, email)
+	body := fmt.Sprintf(
+		"Welcome to Tschwaa!\n\nConfirm your email address by opening the link below:\n%s\n\nThe link expires in %s.",
+		link,
+		services.EmailVerificationTTL,
+	)
+
+	return m.SendEmail(email, "Confirm your email address", body)
+}
+
+func VerifyEmail(mux chi.Router, s verifyEmail) {
+	mux.Post("/email/verify", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var input VerifyEmailRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the email verification request: ", err)
+			http.Error(w, "ERR_VEML_101", http.StatusBadRequest)
+			return
+		}
+
+		memberID, email, err := services.ParseEmailVerificationToken(input.Token)
+		if err != nil {
+			log.Println("error when parsing the email verification token: ", err)
+			if err == services.ErrExpiredVerificationToken {
+				http.Error(w, "ERR_VEML_102", http.StatusBadRequest)
+			} else {
+				http.Error(w, "ERR_VEML_103", http.StatusBadRequest)
+			}
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, memberID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", memberID, err)
+			http.Error(w, "ERR_VEML_104", http.StatusBadRequest)
+			return
+		}
+
+		// the link is only good for the address it was sent to
+		if user.Email != email {
+			log.Printf("the email of member[%d] has changed since the link was sent", memberID)
+			http.Error(w, "ERR_VEML_103", http.StatusBadRequest)
+			return
+		}
+
+		err = s.MarkUserEmailVerified(ctx, storage.MarkUserEmailVerifiedParams{
+			ID:    user.ID,
+			Email: email,
+		})
+		if err != nil {
+			log.Printf("error when verifying the email of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_VEML_105", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(true); err != nil {
+			log.Println("error when encoding the email verification result: ", err)
+			http.Error(w, "ERR_VEML_106", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func ResendEmailVerification(mux chi.Router, s resendEmailVerification, m requests.Mailer) {
+	mux.Post("/verification", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_REML_101", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_REML_102", http.StatusBadRequest)
+			return
+		}
+		if user.EmailVerifiedAt != nil {
+			log.Printf("the email of user[%d] is already verified", user.ID)
+			http.Error(w, "ERR_REML_103", http.StatusConflict)
+			return
+		}
+
+		if err := sendEmailVerification(m, user.MemberID, user.Email); err != nil {
+			log.Printf("error when sending the verification email to user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_REML_104", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(true); err != nil {
+			log.Println("error when encoding the resend verification result: ", err)
+			http.Error(w, "ERR_REML_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/helpers"
 	"tschwaa.com/api/models"
+	"tschwaa.com/api/requests"
 	"tschwaa.com/api/storage"
 )
 
@@ -143,7 +145,7 @@ func GetInvitation(mux chi.Router, d getInvitation) {
 	})
 }
 
-func JoinOrganization(mux chi.Router, j joinOrganization) {
+func JoinOrganization(mux chi.Router, j joinOrganization, m requests.Mailer) {
 	mux.Post("/{joinId}", func(w http.ResponseWriter, r *http.Request) {
 		joinId := chi.URLParamFromCtx(r.Context(), "joinId")
 		log.Println("Get Invitation ID: ", joinId)
@@ -173,6 +175,12 @@ func JoinOrganization(mux chi.Router, j joinOrganization) {
 		if len(data.Code) > 0 {
 
 		} else {
+			if err := helpers.ValidatePassword(data.Password); err != nil {
+				log.Println("the password does not follow the password policy: ", err)
+				http.Error(w, "ERR_JOIN_612", http.StatusBadRequest)
+				return
+			}
+
 			var member models.Member
 			member.ID = data.ID
 			member.FirstName = data.FirstName
@@ -194,6 +202,13 @@ func JoinOrganization(mux chi.Router, j joinOrganization) {
 				http.Error(w, "ERR_JOIN_613", http.StatusBadRequest)
 				return
 			}
+
+			// the account is created unverified as on sign up, the link can be sent again later
+			if data.Email != "" {
+				if err := sendEmailVerification(m, data.ID, data.Email); err != nil {
+					log.Println("error when sending the verification email: ", err)
+				}
+			}
 		}
 
 		err = j.ApprovedInvitationTx(r.Context(), joinId)
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/services"
+)
+
+// JWKS publishes the public keys other services verify our access tokens with
+func JWKS(mux chi.Router) {
+	mux.Get("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		jwks := services.GetKeySet().JWKS(time.Now().UTC())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(jwks); err != nil {
+			log.Println("error when encoding the jwks: ", err)
+			http.Error(w, "ERR_JWKS_101", http.StatusBadRequest)
+			return
+		}
+	})
+}
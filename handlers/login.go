@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/services"
+	"tschwaa.com/api/storage"
+)
+
+type listLogins interface {
+	ListActiveLoginsOfMember(ctx context.Context, arg storage.ListActiveLoginsOfMemberParams) ([]*models.Login, error)
+}
+
+type revokeLogin interface {
+	RevokeLoginTx(ctx context.Context, arg storage.RevokeLoginParams) error
+}
+
+type LoginResult struct {
+	*models.Login
+	Current bool `json:"current"`
+}
+
+func ListLogins(mux chi.Router, s listLogins) {
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_LLGN_101", http.StatusUnauthorized)
+			return
+		}
+
+		// a login is over once its refresh token could not have been used for a while
+		logins, err := s.ListActiveLoginsOfMember(ctx, storage.ListActiveLoginsOfMemberParams{
+			MemberID: currentMember.ID,
+			Since:    time.Now().UTC().Add(-services.RefreshTokenTTL),
+		})
+		if err != nil {
+			log.Printf("error when listing the logins of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_LLGN_102", http.StatusBadRequest)
+			return
+		}
+
+		info, _ := services.GetTokenInfo(ctx)
+		results := make([]LoginResult, 0, len(logins))
+		for _, login := range logins {
+			results = append(results, LoginResult{
+				Login:   login,
+				Current: login.ID == info.LoginID,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			log.Println("error when encoding the logins: ", err)
+			http.Error(w, "ERR_LLGN_103", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func RevokeLogin(mux chi.Router, s revokeLogin) {
+	mux.Delete("/{loginID}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_RLGN_101", http.StatusUnauthorized)
+			return
+		}
+
+		loginIdParam := chi.URLParamFromCtx(ctx, "loginID")
+		loginID, err := strconv.ParseUint(loginIdParam, 10, 64)
+		if err != nil {
+			log.Println("error when parsing the login id: ", err)
+			http.Error(w, "ERR_RLGN_102", http.StatusBadRequest)
+			return
+		}
+
+		err = s.RevokeLoginTx(ctx, storage.RevokeLoginParams{
+			ID:       loginID,
+			MemberID: currentMember.ID,
+		})
+		if err != nil {
+			log.Printf("error when revoking login[%d] of member[%d]: %s", loginID, currentMember.ID, err)
+			if err == storage.ErrLoginNotFound {
+				http.Error(w, "ERR_RLGN_103", http.StatusNotFound)
+			} else {
+				http.Error(w, "ERR_RLGN_104", http.StatusBadRequest)
+			}
+			return
+		}
+
+		// revoking the current login signs out of this device as well
+		if info, ok := services.GetTokenInfo(ctx); ok && info.LoginID == loginID {
+			clearAuthCookies(w)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(true); err != nil {
+			log.Println("error when encoding the revoke login result: ", err)
+			http.Error(w, "ERR_RLGN_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/requests"
+	"tschwaa.com/api/services"
+	"tschwaa.com/api/storage"
+)
+
+// magicLinkDeviceCookie ties a magic link to the browser that asked for it
+const magicLinkDeviceCookie = "magic_link_device"
+
+type requestMagicLink interface {
+	GetMemberByPhone(ctx context.Context, phone string) (*models.Member, error)
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetMagicLinkSendStatsFromPhone(ctx context.Context, arg storage.GetOTPSendStatsFromPhoneParams) (*storage.OTPSendStats, error)
+	CreateMagicLinkTx(ctx context.Context, arg storage.CreateMagicLinkParams) (*models.MagicLink, error)
+	DeleteMagicLink(ctx context.Context, id uint64) error
+}
+
+type verifyMagicLink interface {
+	ConsumeMagicLink(ctx context.Context, arg storage.ConsumeMagicLinkParams) (*models.MagicLink, error)
+	GetMemberByPhone(ctx context.Context, phone string) (*models.Member, error)
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	CreateLoginTx(ctx context.Context, arg storage.CreateLoginTxParams) (*models.Login, error)
+}
+
+type MagicLinkRequest struct {
+	Phone    string `json:"phone,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+type MagicLinkResult struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	// DeviceToken is only returned to bearer clients, the browsers keep it in a cookie
+	DeviceToken *string `json:"device_token,omitempty"`
+}
+
+type VerifyMagicLinkRequest struct {
+	Token       string `json:"token,omitempty"`
+	DeviceToken string `json:"device_token,omitempty"`
+}
+
+func RequestMagicLink(mux chi.Router, s requestMagicLink) {
+	mux.Post("/magic-link", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var input MagicLinkRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the magic link request: ", err)
+			http.Error(w, "ERR_RMLK_101", http.StatusBadRequest)
+			return
+		}
+
+		deviceToken, deviceHash, err := services.GenerateOpaqueToken()
+		if err != nil {
+			log.Println("error when generating the device token: ", err)
+			http.Error(w, "ERR_RMLK_105", http.StatusInternalServerError)
+			return
+		}
+
+		// the unknown phones, the limits and the failed sends get the answer of a sent link,
+		// so that the endpoint does not tell which accounts exist
+		expiresAt := time.Now().UTC().Add(services.MagicLinkTTL)
+
+		member, err := s.GetMemberByPhone(ctx, input.Phone)
+		if err != nil || member == nil {
+			log.Println("no member with the phone number: ", input.Phone)
+			writeMagicLinkResult(w, r, deviceToken, expiresAt)
+			return
+		}
+		user, err := s.GetUserByMemberID(ctx, member.ID)
+		if err != nil || user == nil {
+			log.Printf("no user for member[%d]: %s", member.ID, err)
+			writeMagicLinkResult(w, r, deviceToken, expiresAt)
+			return
+		}
+
+		// the magic links have the same cooldown and daily limit as the pin codes
+		now := time.Now().UTC()
+		stats, err := s.GetMagicLinkSendStatsFromPhone(ctx, storage.GetOTPSendStatsFromPhoneParams{
+			Phone: input.Phone,
+			Since: now.Add(-24 * time.Hour),
+		})
+		if err != nil {
+			log.Println("error when getting the magic links sent to the phone: ", err)
+			http.Error(w, "ERR_RMLK_103", http.StatusBadRequest)
+			return
+		}
+		if stats.Latest != nil && now.Before(helpers.NextOtpSendAt(*stats.Latest, stats.Count, *stats.Oldest)) {
+			log.Println("too many magic links sent to: ", input.Phone)
+			writeMagicLinkResult(w, r, deviceToken, expiresAt)
+			return
+		}
+
+		link, err := s.CreateMagicLinkTx(ctx, storage.CreateMagicLinkParams{
+			MemberID:   member.ID,
+			Phone:      input.Phone,
+			DeviceHash: deviceHash,
+			ExpiresAt:  expiresAt,
+		})
+		if err != nil {
+			log.Printf("error when creating a magic link for member[%d]: %s", member.ID, err)
+			http.Error(w, "ERR_RMLK_106", http.StatusBadRequest)
+			return
+		}
+
+		token := services.GenerateMagicLinkToken(link.ID, link.Phone, link.ExpiresAt)
+		if _, err := requests.SendMagicLink(input.Phone, input.Language, services.MagicLink(token), token); err != nil {
+			log.Printf("error when sending the magic link of member[%d]: %s", member.ID, err)
+			// a link which never left does not count against the limits
+			if err := s.DeleteMagicLink(ctx, link.ID); err != nil {
+				log.Printf("error when deleting magic link[%d]: %s", link.ID, err)
+			}
+		}
+
+		writeMagicLinkResult(w, r, deviceToken, link.ExpiresAt)
+	})
+}
+
+// writeMagicLinkResult answers a magic link request, the same way whatever happened to the link
+func writeMagicLinkResult(w http.ResponseWriter, r *http.Request, deviceToken string, expiresAt time.Time) {
+	result := MagicLinkResult{ExpiresAt: expiresAt}
+	if services.UsesBearerToken(r) {
+		result.DeviceToken = &deviceToken
+	} else {
+		http.SetCookie(w, &http.Cookie{
+			Name:     magicLinkDeviceCookie,
+			Value:    deviceToken,
+			Path:     "/",
+			Expires:  expiresAt,
+			HttpOnly: true,
+			Secure:   true,
+			MaxAge:   int(services.MagicLinkTTL.Seconds()),
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Println("error when encoding the magic link result: ", err)
+		http.Error(w, "ERR_RMLK_108", http.StatusBadRequest)
+	}
+}
+
+func VerifyMagicLink(mux chi.Router, s verifyMagicLink) {
+	mux.Post("/magic-link/verify", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var input VerifyMagicLinkRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the magic link verification: ", err)
+			http.Error(w, "ERR_VMLK_101", http.StatusBadRequest)
+			return
+		}
+
+		linkID, phone, err := services.ParseMagicLinkToken(input.Token)
+		if err != nil {
+			log.Println("error when parsing the magic link token: ", err)
+			if err == services.ErrExpiredMagicLink {
+				http.Error(w, "ERR_VMLK_102", http.StatusBadRequest)
+			} else {
+				http.Error(w, "ERR_VMLK_103", http.StatusBadRequest)
+			}
+			return
+		}
+
+		// only the device which asked for the link can use it
+		deviceToken := input.DeviceToken
+		if cookie, err := r.Cookie(magicLinkDeviceCookie); err == nil && cookie.Value != "" {
+			deviceToken = cookie.Value
+		}
+		if deviceToken == "" {
+			log.Printf("no device token to open magic link[%d]", linkID)
+			http.Error(w, "ERR_VMLK_104", http.StatusBadRequest)
+			return
+		}
+
+		link, err := s.ConsumeMagicLink(ctx, storage.ConsumeMagicLinkParams{
+			ID:         linkID,
+			Phone:      phone,
+			DeviceHash: services.HashOpaqueToken(deviceToken),
+		})
+		if err != nil {
+			log.Printf("error when consuming magic link[%d]: %s", linkID, err)
+			http.Error(w, "ERR_VMLK_105", http.StatusBadRequest)
+			return
+		}
+		if link == nil {
+			log.Printf("magic link[%d] is used, expired or opened on another device", linkID)
+			http.Error(w, "ERR_VMLK_106", http.StatusBadRequest)
+			return
+		}
+
+		// the link is bound to the phone, it is over if the member moved to another number
+		member, err := s.GetMemberByPhone(ctx, link.Phone)
+		if err != nil || member == nil || member.ID != link.MemberID {
+			log.Printf("the member of magic link[%d] does not have its phone anymore: %s", link.ID, err)
+			http.Error(w, "ERR_VMLK_107", http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, member.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", member.ID, err)
+			http.Error(w, "ERR_VMLK_108", http.StatusBadRequest)
+			return
+		}
+
+		// the link is only a first factor, the authenticator app still has to be used
+		enrolled, err := hasTwoFactor(ctx, s, user.ID)
+		if err != nil {
+			log.Printf("error when getting the two-factor authentication of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_VMLK_112", http.StatusBadRequest)
+			return
+		}
+		if enrolled {
+			http.SetCookie(w, &http.Cookie{
+				Name:   magicLinkDeviceCookie,
+				Value:  "",
+				Path:   "/",
+				MaxAge: -1,
+			})
+			if err := writeTwoFactorChallenge(w, member.ID); err != nil {
+				log.Println("error when encoding the two-factor challenge: ", err)
+				http.Error(w, "ERR_VMLK_113", http.StatusBadRequest)
+			}
+			return
+		}
+
+		var signInResult SignInResult
+		signInResult.Name = fmt.Sprintf("%s %s", member.FirstName, member.LastName)
+		signInResult.Email = member.Email
+		signInResult.Phone = member.Phone
+		signInResult.ID = member.ID
+		signInResult.EmailVerified = user.EmailVerifiedAt != nil
+
+		channel := common.CLIENT_TYPE_WEB
+		if services.UsesBearerToken(r) {
+			channel = common.CLIENT_TYPE_MOBILE
+		}
+		login, refreshToken, err := openLogin(ctx, s, r, member.ID, channel, false)
+		if err != nil {
+			log.Println("error when recording the login ", err)
+			http.Error(w, "ERR_VMLK_109", http.StatusBadRequest)
+			return
+		}
+
+		tokenString, err := services.GenerateJWTToken(structs.Map(signInResult), login.ID)
+		if err != nil {
+			log.Println("error when generating jwt token ", err)
+			http.Error(w, "ERR_VMLK_110", http.StatusBadRequest)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:   magicLinkDeviceCookie,
+			Value:  "",
+			Path:   "/",
+			MaxAge: -1,
+		})
+		setAuthTokens(w, r, &signInResult, tokenString, refreshToken)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(signInResult); err != nil {
+			log.Println("error when encoding auth result: ", err)
+			http.Error(w, "ERR_VMLK_111", http.StatusBadRequest)
+			return
+		}
+	})
+}
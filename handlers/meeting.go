@@ -0,0 +1,497 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/storage"
+)
+
+type getMeetingRecurrence interface {
+	GetMeetingRecurrence(ctx context.Context, sessionID uint64) (*models.MeetingRecurrence, error)
+}
+
+type setMeetingRecurrence interface {
+	GetSession(ctx context.Context, arg storage.GetSessionParams) (*models.Session, error)
+	SetMeetingRecurrenceTx(ctx context.Context, arg storage.SetMeetingRecurrenceParams) (*models.MeetingRecurrence, error)
+}
+
+type listMeetings interface {
+	ListMeetingsOfSession(ctx context.Context, sessionID uint64) ([]*models.Meeting, error)
+}
+
+type cancelMeeting interface {
+	GetMeeting(ctx context.Context, arg storage.GetMeetingParams) (*models.Meeting, error)
+	CancelMeeting(ctx context.Context, arg storage.CancelMeetingParams) (*models.Meeting, error)
+}
+
+type rescheduleMeeting interface {
+	GetSession(ctx context.Context, arg storage.GetSessionParams) (*models.Session, error)
+	GetMeeting(ctx context.Context, arg storage.GetMeetingParams) (*models.Meeting, error)
+	RescheduleMeeting(ctx context.Context, arg storage.RescheduleMeetingParams) (*models.Meeting, error)
+}
+
+type getMeetingsOfCurrentSession interface {
+	GetCurrentSession(ctx context.Context, organizationID uint64) (*models.Session, error)
+	ListMeetingsOfSession(ctx context.Context, sessionID uint64) ([]*models.Meeting, error)
+}
+
+var meetingFrequencies = map[string]bool{
+	common.MEETING_FREQUENCY_WEEKLY:   true,
+	common.MEETING_FREQUENCY_BIWEEKLY: true,
+	common.MEETING_FREQUENCY_MONTHLY:  true,
+}
+
+var meetingStartTime = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+type SetMeetingRecurrenceRequest struct {
+	Frequency string `json:"frequency,omitempty"`
+	// Weekday goes from 0 (Sunday) to 6, it defaults to the weekday the session starts
+	Weekday *int `json:"weekday,omitempty"`
+	// WeekOfMonth sets a monthly meeting on the nth weekday of the month, -1 for the last one.
+	// Without it the meeting falls on the day of the month the session starts.
+	WeekOfMonth *int   `json:"week_of_month,omitempty"`
+	StartTime   string `json:"start_time,omitempty"`
+	Place       string `json:"place,omitempty"`
+}
+
+type CancelMeetingRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// RescheduleMeetingRequest moves a meeting, the fields left out keep their value
+type RescheduleMeetingRequest struct {
+	Date      string  `json:"date,omitempty"`
+	StartTime string  `json:"start_time,omitempty"`
+	Place     *string `json:"place,omitempty"`
+	Note      *string `json:"note,omitempty"`
+}
+
+type MeetingsOfSession struct {
+	Session  *models.Session   `json:"session"`
+	Past     []*models.Meeting `json:"past"`
+	Upcoming []*models.Meeting `json:"upcoming"`
+}
+
+func validMeetingRecurrence(input SetMeetingRecurrenceRequest) bool {
+	if !meetingFrequencies[input.Frequency] || !meetingStartTime.MatchString(input.StartTime) {
+		return false
+	}
+	if input.Weekday != nil && (*input.Weekday < 0 || *input.Weekday > 6) {
+		return false
+	}
+	if input.WeekOfMonth != nil {
+		if input.Frequency != common.MEETING_FREQUENCY_MONTHLY {
+			return false
+		}
+		if *input.WeekOfMonth != -1 && (*input.WeekOfMonth < 1 || *input.WeekOfMonth > 4) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+func GetMeetingRecurrence(mux chi.Router, s getMeetingRecurrence) {
+	mux.Get("/recurrence", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_GMTR_101", http.StatusBadRequest)
+			return
+		}
+
+		recurrence, err := s.GetMeetingRecurrence(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when getting the meeting recurrence of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_GMTR_102", http.StatusBadRequest)
+			return
+		}
+		if recurrence == nil {
+			http.Error(w, "ERR_GMTR_103", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(recurrence); err != nil {
+			log.Println("error when encoding the meeting recurrence: ", err)
+			http.Error(w, "ERR_GMTR_104", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// SetMeetingRecurrence saves the recurrence of the session meetings and sets the meetings
+// to come until the end of the session
+func SetMeetingRecurrence(mux chi.Router, s setMeetingRecurrence) {
+	mux.Put("/recurrence", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_SMTR_101", http.StatusBadRequest)
+			return
+		}
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_SMTR_101", http.StatusBadRequest)
+			return
+		}
+
+		var input SetMeetingRecurrenceRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the meeting recurrence: ", err)
+			http.Error(w, "ERR_SMTR_102", http.StatusBadRequest)
+			return
+		}
+
+		input.Place = strings.TrimSpace(input.Place)
+		if !validMeetingRecurrence(input) {
+			log.Printf("invalid meeting recurrence for session[%d]: %+v", sessionID, input)
+			http.Error(w, "ERR_SMTR_103", http.StatusBadRequest)
+			return
+		}
+
+		session, err := s.GetSession(ctx, storage.GetSessionParams{
+			OrganizationID: orgID,
+			SessionID:      sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting session[%d] of organization[%d]: %s", sessionID, orgID, err)
+			http.Error(w, "ERR_SMTR_104", http.StatusBadRequest)
+			return
+		}
+
+		var createdBy *uint64
+		if currentMember := GetCurrentMember(r); currentMember != nil {
+			createdBy = &currentMember.ID
+		}
+
+		from := today()
+		dates := []time.Time{}
+		for _, date := range helpers.MeetingDates(&models.MeetingRecurrence{
+			Frequency:   input.Frequency,
+			Weekday:     input.Weekday,
+			WeekOfMonth: input.WeekOfMonth,
+		}, session.StartDate, session.EndDate) {
+			if !date.Before(from) {
+				dates = append(dates, date)
+			}
+		}
+
+		recurrence, err := s.SetMeetingRecurrenceTx(ctx, storage.SetMeetingRecurrenceParams{
+			SessionID:   sessionID,
+			Frequency:   input.Frequency,
+			Weekday:     input.Weekday,
+			WeekOfMonth: input.WeekOfMonth,
+			StartTime:   input.StartTime,
+			Place:       input.Place,
+			CreatedBy:   createdBy,
+			From:        from,
+			Dates:       dates,
+		})
+		if err != nil {
+			log.Printf("error when setting the meeting recurrence of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_SMTR_105", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(recurrence); err != nil {
+			log.Println("error when encoding the meeting recurrence: ", err)
+			http.Error(w, "ERR_SMTR_106", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func ListMeetings(mux chi.Router, s listMeetings) {
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_LMTG_101", http.StatusBadRequest)
+			return
+		}
+
+		meetings, err := s.ListMeetingsOfSession(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when listing the meetings of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_LMTG_102", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(meetings); err != nil {
+			log.Println("error when encoding the meetings: ", err)
+			http.Error(w, "ERR_LMTG_103", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func CancelMeeting(mux chi.Router, s cancelMeeting) {
+	mux.Post("/{meetingID}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_CMTG_101", http.StatusBadRequest)
+			return
+		}
+		meetingID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "meetingID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the meeting id: ", err)
+			http.Error(w, "ERR_CMTG_101", http.StatusBadRequest)
+			return
+		}
+
+		var input CancelMeetingRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the cancellation: ", err)
+			http.Error(w, "ERR_CMTG_102", http.StatusBadRequest)
+			return
+		}
+
+		meeting, err := s.GetMeeting(ctx, storage.GetMeetingParams{
+			ID:        meetingID,
+			SessionID: sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting meeting[%d] of session[%d]: %s", meetingID, sessionID, err)
+			http.Error(w, "ERR_CMTG_103", http.StatusBadRequest)
+			return
+		}
+		if meeting == nil {
+			http.Error(w, "ERR_CMTG_104", http.StatusNotFound)
+			return
+		}
+
+		var updatedBy *uint64
+		if currentMember := GetCurrentMember(r); currentMember != nil {
+			updatedBy = &currentMember.ID
+		}
+
+		meeting, err = s.CancelMeeting(ctx, storage.CancelMeetingParams{
+			ID:        meetingID,
+			SessionID: sessionID,
+			Note:      strings.TrimSpace(input.Note),
+			UpdatedBy: updatedBy,
+		})
+		if err != nil {
+			log.Printf("error when cancelling meeting[%d]: %s", meetingID, err)
+			http.Error(w, "ERR_CMTG_105", http.StatusBadRequest)
+			return
+		}
+		if meeting == nil {
+			log.Printf("meeting[%d] has already been cancelled", meetingID)
+			http.Error(w, "ERR_CMTG_106", http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(meeting); err != nil {
+			log.Println("error when encoding the meeting: ", err)
+			http.Error(w, "ERR_CMTG_107", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// RescheduleMeeting moves a meeting to another day, time or place of the session. A
+// rescheduled meeting is kept as it is when the recurrence changes.
+func RescheduleMeeting(mux chi.Router, s rescheduleMeeting) {
+	mux.Patch("/{meetingID}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_RMTG_101", http.StatusBadRequest)
+			return
+		}
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_RMTG_101", http.StatusBadRequest)
+			return
+		}
+		meetingID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "meetingID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the meeting id: ", err)
+			http.Error(w, "ERR_RMTG_101", http.StatusBadRequest)
+			return
+		}
+
+		var input RescheduleMeetingRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the meeting: ", err)
+			http.Error(w, "ERR_RMTG_102", http.StatusBadRequest)
+			return
+		}
+
+		meeting, err := s.GetMeeting(ctx, storage.GetMeetingParams{
+			ID:        meetingID,
+			SessionID: sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting meeting[%d] of session[%d]: %s", meetingID, sessionID, err)
+			http.Error(w, "ERR_RMTG_103", http.StatusBadRequest)
+			return
+		}
+		if meeting == nil {
+			http.Error(w, "ERR_RMTG_104", http.StatusNotFound)
+			return
+		}
+
+		params := storage.RescheduleMeetingParams{
+			ID:        meeting.ID,
+			SessionID: sessionID,
+			Date:      meeting.Date,
+			StartTime: meeting.StartTime,
+			Place:     meeting.Place,
+			Note:      meeting.Note,
+		}
+		if input.Date != "" {
+			params.Date, err = time.Parse("2006-01-02", input.Date)
+			if err != nil {
+				log.Printf("invalid date for meeting[%d]: %s", meetingID, input.Date)
+				http.Error(w, "ERR_RMTG_105", http.StatusBadRequest)
+				return
+			}
+		}
+		if input.StartTime != "" {
+			if !meetingStartTime.MatchString(input.StartTime) {
+				log.Printf("invalid start time for meeting[%d]: %s", meetingID, input.StartTime)
+				http.Error(w, "ERR_RMTG_105", http.StatusBadRequest)
+				return
+			}
+			params.StartTime = input.StartTime
+		}
+		if input.Place != nil {
+			params.Place = strings.TrimSpace(*input.Place)
+		}
+		if input.Note != nil {
+			params.Note = strings.TrimSpace(*input.Note)
+		}
+
+		session, err := s.GetSession(ctx, storage.GetSessionParams{
+			OrganizationID: orgID,
+			SessionID:      sessionID,
+		})
+		if err != nil {
+			log.Printf("error when getting session[%d] of organization[%d]: %s", sessionID, orgID, err)
+			http.Error(w, "ERR_RMTG_106", http.StatusBadRequest)
+			return
+		}
+		if params.Date.Before(session.StartDate) || params.Date.After(session.EndDate) {
+			log.Printf("the meeting of %s is out of session[%d]", params.Date.Format("2006-01-02"), sessionID)
+			http.Error(w, "ERR_RMTG_107", http.StatusBadRequest)
+			return
+		}
+
+		if currentMember := GetCurrentMember(r); currentMember != nil {
+			params.UpdatedBy = &currentMember.ID
+		}
+
+		meeting, err = s.RescheduleMeeting(ctx, params)
+		if err != nil {
+			log.Printf("error when rescheduling meeting[%d]: %s", meetingID, err)
+			http.Error(w, "ERR_RMTG_108", http.StatusBadRequest)
+			return
+		}
+		if meeting == nil {
+			log.Printf("meeting[%d] has been cancelled", meetingID)
+			http.Error(w, "ERR_RMTG_109", http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(meeting); err != nil {
+			log.Println("error when encoding the meeting: ", err)
+			http.Error(w, "ERR_RMTG_110", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// GetMeetingsOfCurrentSession lists the meetings of the session in progress, the ones
+// held before today apart from the ones to come
+func GetMeetingsOfCurrentSession(mux chi.Router, s getMeetingsOfCurrentSession) {
+	mux.Get("/current/meetings", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_GCSM_101", http.StatusBadRequest)
+			return
+		}
+
+		session, err := s.GetCurrentSession(ctx, orgID)
+		if err != nil {
+			log.Printf("error when getting the current session of organization[%d]: %s", orgID, err)
+			http.Error(w, "ERR_GCSM_102", http.StatusBadRequest)
+			return
+		}
+		if session == nil {
+			http.Error(w, "ERR_GCSM_103", http.StatusNotFound)
+			return
+		}
+
+		meetings, err := s.ListMeetingsOfSession(ctx, session.ID)
+		if err != nil {
+			log.Printf("error when listing the meetings of session[%d]: %s", session.ID, err)
+			http.Error(w, "ERR_GCSM_104", http.StatusBadRequest)
+			return
+		}
+
+		from := today()
+		result := MeetingsOfSession{
+			Session:  session,
+			Past:     []*models.Meeting{},
+			Upcoming: []*models.Meeting{},
+		}
+		for _, meeting := range meetings {
+			if meeting.Date.Before(from) {
+				result.Past = append(result.Past, meeting)
+			} else {
+				result.Upcoming = append(result.Upcoming, meeting)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Println("error when encoding the meetings: ", err)
+			http.Error(w, "ERR_GCSM_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
@@ -268,3 +268,50 @@ func InviteMembersIntoOrganization(mux chi.Router, o inviteMembersIntoOrganizati
 		}
 	})
 }
+
+type signOutMemberEverywhere interface {
+	DoesMembershipExist(ctx context.Context, arg storage.DoesMembershipExistParams) (*models.Membership, error)
+	RevokeAllMemberTokensTx(ctx context.Context, memberID uint64) error
+}
+
+func SignOutMemberEverywhere(mux chi.Router, s signOutMemberEverywhere) {
+	mux.Post("/members/{memberID}/sign-out", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgIdParam := chi.URLParamFromCtx(ctx, "orgID")
+		orgID, _ := strconv.ParseUint(orgIdParam, 10, 64)
+
+		memberIdParam := chi.URLParamFromCtx(ctx, "memberID")
+		memberID, _ := strconv.ParseUint(memberIdParam, 10, 64)
+
+		// only the admins get here, see the route policies
+		membership, err := s.DoesMembershipExist(ctx, storage.DoesMembershipExistParams{
+			MemberID:       memberID,
+			OrganizationID: orgID,
+		})
+		if err != nil {
+			log.Printf("error when getting membership of member[%d] in organization[%d]: %s", memberID, orgID, err)
+			http.Error(w, "ERR_SOME_105", http.StatusBadRequest)
+			return
+		}
+		if membership == nil {
+			log.Printf("member[%d] is not a member of organization[%d]", memberID, orgID)
+			http.Error(w, "ERR_SOME_106", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.RevokeAllMemberTokensTx(ctx, memberID); err != nil {
+			log.Printf("error when revoking all tokens of member[%d]: %s", memberID, err)
+			http.Error(w, "ERR_SOME_107", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(true); err != nil {
+			log.Println("error when encoding the sign out result")
+			http.Error(w, "ERR_SOME_108", http.StatusBadRequest)
+			return
+		}
+	})
+}
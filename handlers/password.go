@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/requests"
+	"tschwaa.com/api/storage"
+)
+
+type forgotPassword interface {
+	GetUserByUsername(ctx context.Context, arg storage.GetUserByUsernameParams) (*models.User, error)
+	GetOTPSendStatsFromPhone(ctx context.Context, arg storage.GetOTPSendStatsFromPhoneParams) (*storage.OTPSendStats, error)
+	CreateOTPTx(ctx context.Context, arg storage.CreateOTPParams) (*models.Otp, error)
+}
+
+type resetPassword interface {
+	GetUserByUsername(ctx context.Context, arg storage.GetUserByUsernameParams) (*models.User, error)
+	VerifyOTPTx(ctx context.Context, arg storage.VerifyOTPParams) (*models.Otp, error)
+	ResetPasswordTx(ctx context.Context, arg storage.ResetPasswordParams) error
+}
+
+type ForgotPasswordRequest struct {
+	Username string `json:"username,omitempty"`
+	Language string `json:"language,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+}
+
+type ResetPasswordRequest struct {
+	Username string `json:"username,omitempty"`
+	PinCode  string `json:"pin_code,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+func ForgotPassword(mux chi.Router, s forgotPassword, d otpDelivery) {
+	mux.Post("/password/forgot", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var input ForgotPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the forgot password request: ", err)
+			http.Error(w, "ERR_FPWD_101", http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.GetUserByUsername(ctx, storage.GetUserByUsernameParams{
+			Phone: input.Username,
+			Email: input.Username,
+		})
+		if err != nil {
+			log.Println("error when getting the user: ", err)
+			http.Error(w, "ERR_FPWD_102", http.StatusBadRequest)
+			return
+		}
+		// the unknown usernames, the cooldown and the failed sends get the answer of a sent pin code,
+		// so that the endpoint does not tell which accounts exist
+		if user == nil {
+			log.Println("no user with the username: ", input.Username)
+			writeForgotPasswordResult(w)
+			return
+		}
+
+		canSend, err := canSendOtp(ctx, s, user.Phone)
+		if err != nil {
+			log.Println("error when getting the Otp sent to the phone: ", err)
+			http.Error(w, "ERR_FPWD_107", http.StatusBadRequest)
+			return
+		}
+		if !canSend {
+			log.Println("an Otp has been sent too recently to: ", user.Phone)
+			writeForgotPasswordResult(w)
+			return
+		}
+
+		pinCode, err := helpers.GeneratePinCode()
+		if err != nil {
+			log.Println("error when generating the pin code: ", err)
+			http.Error(w, "ERR_FPWD_109", http.StatusBadRequest)
+			return
+		}
+
+		// the pin code is bound to the phone number, even if the username is the email,
+		// and only goes to the email once it has been verified
+		recipient := requests.OtpRecipient{Phone: user.Phone}
+		if user.EmailVerifiedAt != nil {
+			recipient.Email = user.Email
+		}
+		res, err := d.Send(input.Channel, recipient, input.Language, pinCode)
+		if err != nil {
+			log.Println("error when sending the Otp: ", err)
+			writeForgotPasswordResult(w)
+			return
+		}
+
+		_, err = s.CreateOTPTx(ctx, storage.CreateOTPParams{
+			MessageID: res.MessageID,
+			Channel:   res.Channel,
+			Phone:     user.Phone,
+			PinCode:   pinCode,
+			ExpiresAt: time.Now().UTC().Add(helpers.OtpTTL),
+			Purpose:   common.OTP_PURPOSE_PASSWORD_RESET,
+		})
+		if err != nil {
+			log.Println("error when saving the Otp: ", err)
+			http.Error(w, "ERR_FPWD_105", http.StatusBadRequest)
+			return
+		}
+
+		writeForgotPasswordResult(w)
+	})
+}
+
+// writeForgotPasswordResult answers a forgot password request, the same way whatever happened to the pin code
+func writeForgotPasswordResult(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(true); err != nil {
+		log.Println("error when encoding the forgot password result: ", err)
+		http.Error(w, "ERR_FPWD_106", http.StatusBadRequest)
+	}
+}
+
+func ResetPassword(mux chi.Router, s resetPassword) {
+	mux.Post("/password/reset", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var input ResetPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the reset password request: ", err)
+			http.Error(w, "ERR_RPWD_101", http.StatusBadRequest)
+			return
+		}
+
+		if err := helpers.ValidatePassword(input.Password); err != nil {
+			log.Println("the password does not follow the password policy: ", err)
+			http.Error(w, "ERR_RPWD_102", http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.GetUserByUsername(ctx, storage.GetUserByUsernameParams{
+			Phone: input.Username,
+			Email: input.Username,
+		})
+		if err != nil || user == nil {
+			log.Println("error when getting the user: ", err)
+			http.Error(w, "ERR_RPWD_103", http.StatusBadRequest)
+			return
+		}
+
+		otp, err := s.VerifyOTPTx(ctx, storage.VerifyOTPParams{
+			Phone:       user.Phone,
+			PinCode:     input.PinCode,
+			MaxAttempts: helpers.OtpMaxAttempts,
+			Purpose:     common.OTP_PURPOSE_PASSWORD_RESET,
+		})
+		if err != nil {
+			log.Println("error when checking the otp: ", err)
+			switch err {
+			case storage.ErrOTPExpired:
+				http.Error(w, "ERR_RPWD_107", http.StatusBadRequest)
+			case storage.ErrOTPLocked:
+				http.Error(w, "ERR_RPWD_108", http.StatusBadRequest)
+			case storage.ErrOTPWrongCode, storage.ErrOTPNotFound:
+				http.Error(w, "ERR_RPWD_109", http.StatusBadRequest)
+			default:
+				http.Error(w, "ERR_RPWD_104", http.StatusBadRequest)
+			}
+			return
+		}
+
+		err = s.ResetPasswordTx(ctx, storage.ResetPasswordParams{
+			UserID:   user.ID,
+			MemberID: user.MemberID,
+			OtpID:    otp.ID,
+			Password: input.Password,
+		})
+		if err != nil {
+			log.Println("error when resetting the password: ", err)
+			http.Error(w, "ERR_RPWD_105", http.StatusBadRequest)
+			return
+		}
+
+		clearAuthCookies(w)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(true); err != nil {
+			log.Println("error when encoding the reset password result: ", err)
+			http.Error(w, "ERR_RPWD_106", http.StatusBadRequest)
+			return
+		}
+	})
+}
@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/requests"
+	"tschwaa.com/api/storage"
+)
+
+type sendPhoneOtp interface {
+	GetOTPSendStatsFromPhone(ctx context.Context, arg storage.GetOTPSendStatsFromPhoneParams) (*storage.OTPSendStats, error)
+	CreateOTPTx(ctx context.Context, arg storage.CreateOTPParams) (*models.Otp, error)
+}
+
+type requestPhoneChange interface {
+	sendPhoneOtp
+	GetMemberByPhone(ctx context.Context, phone string) (*models.Member, error)
+	CreatePhoneChangeTx(ctx context.Context, arg storage.CreatePhoneChangeParams) (*models.PhoneChange, error)
+	CancelPendingPhoneChanges(ctx context.Context, memberID uint64) error
+}
+
+type confirmPhoneChange interface {
+	GetPendingPhoneChangeOfMember(ctx context.Context, memberID uint64) (*models.PhoneChange, error)
+	VerifyOTPTx(ctx context.Context, arg storage.VerifyOTPParams) (*models.Otp, error)
+	ChangePhoneTx(ctx context.Context, arg storage.ChangePhoneParams) error
+}
+
+type cancelPhoneChange interface {
+	CancelPendingPhoneChanges(ctx context.Context, memberID uint64) error
+}
+
+type approvePhoneRecovery interface {
+	GetMemberByID(ctx context.Context, id uint64) (*models.Member, error)
+	GetMemberByPhone(ctx context.Context, phone string) (*models.Member, error)
+	DoesMembershipExist(ctx context.Context, arg storage.DoesMembershipExistParams) (*models.Membership, error)
+	CreatePhoneChangeTx(ctx context.Context, arg storage.CreatePhoneChangeParams) (*models.PhoneChange, error)
+	CancelPendingPhoneChanges(ctx context.Context, memberID uint64) error
+}
+
+type getPhoneRecoveryOtp interface {
+	sendPhoneOtp
+	GetApprovedPhoneChangeToPhone(ctx context.Context, phone string) (*models.PhoneChange, error)
+}
+
+type recoverPhone interface {
+	GetApprovedPhoneChangeToPhone(ctx context.Context, phone string) (*models.PhoneChange, error)
+	VerifyOTPTx(ctx context.Context, arg storage.VerifyOTPParams) (*models.Otp, error)
+	ChangePhoneTx(ctx context.Context, arg storage.ChangePhoneParams) error
+}
+
+type PhoneChangeRequest struct {
+	Phone    string `json:"phone,omitempty"`
+	Language string `json:"language,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+}
+
+type ConfirmPhoneChangeRequest struct {
+	OldPinCode string `json:"old_pin_code,omitempty"`
+	NewPinCode string `json:"new_pin_code,omitempty"`
+}
+
+type RecoverPhoneRequest struct {
+	Phone   string `json:"phone,omitempty"`
+	PinCode string `json:"pin_code,omitempty"`
+}
+
+// sendOtpToPhone sends a new pin code for the purpose to the phone number itself, never to the email
+// of the member, since it is the number that has to be confirmed
+func sendOtpToPhone(ctx context.Context, s sendPhoneOtp, d otpDelivery, purpose, channel, phone, language string) (int, error) {
+	canSend, err := canSendOtp(ctx, s, phone)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if !canSend {
+		return http.StatusTooManyRequests, nil
+	}
+
+	pinCode, err := helpers.GeneratePinCode()
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	res, err := d.Send(channel, requests.OtpRecipient{Phone: phone}, language, pinCode)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	_, err = s.CreateOTPTx(ctx, storage.CreateOTPParams{
+		MessageID: res.MessageID,
+		Channel:   res.Channel,
+		Phone:     phone,
+		PinCode:   pinCode,
+		ExpiresAt: time.Now().UTC().Add(helpers.OtpTTL),
+		Purpose:   purpose,
+	})
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return http.StatusOK, nil
+}
+
+func RequestPhoneChange(mux chi.Router, s requestPhoneChange, d otpDelivery) {
+	mux.Post("/change", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_RPHC_101", http.StatusUnauthorized)
+			return
+		}
+
+		var input PhoneChangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the phone change request: ", err)
+			http.Error(w, "ERR_RPHC_102", http.StatusBadRequest)
+			return
+		}
+		if !models.IsValidPhone(input.Phone) || input.Phone == currentMember.Phone {
+			log.Println("invalid new phone number: ", input.Phone)
+			http.Error(w, "ERR_RPHC_103", http.StatusBadRequest)
+			return
+		}
+
+		existingMember, err := s.GetMemberByPhone(ctx, input.Phone)
+		if err != nil {
+			log.Println("error when looking for the member of the new phone: ", err)
+			http.Error(w, "ERR_RPHC_104", http.StatusBadRequest)
+			return
+		}
+		if existingMember != nil {
+			log.Println("the new phone number is already used: ", input.Phone)
+			http.Error(w, "ERR_RPHC_105", http.StatusConflict)
+			return
+		}
+
+		// both numbers must be able to receive a pin code before the change is created
+		for _, phone := range []string{currentMember.Phone, input.Phone} {
+			canSend, err := canSendOtp(ctx, s, phone)
+			if err != nil {
+				log.Println("error when getting the Otp sent to the phone: ", err)
+				http.Error(w, "ERR_RPHC_109", http.StatusBadRequest)
+				return
+			}
+			if !canSend {
+				log.Println("an Otp has been sent too recently to: ", phone)
+				http.Error(w, "ERR_RPHC_110", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		now := time.Now().UTC()
+		change, err := s.CreatePhoneChangeTx(ctx, storage.CreatePhoneChangeParams{
+			MemberID:    currentMember.ID,
+			OldPhone:    currentMember.Phone,
+			NewPhone:    input.Phone,
+			AvailableAt: now,
+			ExpiresAt:   now.Add(helpers.PhoneChangeTTL),
+		})
+		if err != nil {
+			log.Println("error when creating the phone change: ", err)
+			http.Error(w, "ERR_RPHC_106", http.StatusBadRequest)
+			return
+		}
+
+		for _, phone := range []string{change.OldPhone, change.NewPhone} {
+			status, err := sendOtpToPhone(ctx, s, d, common.OTP_PURPOSE_PHONE_CHANGE, input.Channel, phone, input.Language)
+			if err != nil || status != http.StatusOK {
+				log.Printf("error when sending the Otp to %s: %v", phone, err)
+				// without both pin codes the change cannot be confirmed, so it is not left pending
+				if err := s.CancelPendingPhoneChanges(ctx, currentMember.ID); err != nil {
+					log.Println("error when cancelling the phone change: ", err)
+				}
+				http.Error(w, "ERR_RPHC_107", status)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(change); err != nil {
+			log.Println("error when encoding the phone change: ", err)
+			http.Error(w, "ERR_RPHC_108", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func ConfirmPhoneChange(mux chi.Router, s confirmPhoneChange) {
+	mux.Post("/change/confirm", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_CPHC_101", http.StatusUnauthorized)
+			return
+		}
+
+		var input ConfirmPhoneChangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the phone change confirmation: ", err)
+			http.Error(w, "ERR_CPHC_102", http.StatusBadRequest)
+			return
+		}
+
+		change, err := s.GetPendingPhoneChangeOfMember(ctx, currentMember.ID)
+		if err != nil {
+			log.Println("error when getting the pending phone change: ", err)
+			http.Error(w, "ERR_CPHC_103", http.StatusBadRequest)
+			return
+		}
+		if change == nil {
+			log.Printf("no pending phone change for member[%d]", currentMember.ID)
+			http.Error(w, "ERR_CPHC_104", http.StatusNotFound)
+			return
+		}
+
+		// both numbers have to be confirmed before anything changes
+		oldOtp, err := s.VerifyOTPTx(ctx, storage.VerifyOTPParams{
+			Phone:       change.OldPhone,
+			PinCode:     input.OldPinCode,
+			MaxAttempts: helpers.OtpMaxAttempts,
+			Purpose:     common.OTP_PURPOSE_PHONE_CHANGE,
+		})
+		if err != nil {
+			log.Println("error when checking the otp of the old phone: ", err)
+			switch err {
+			case storage.ErrOTPExpired:
+				http.Error(w, "ERR_CPHC_109", http.StatusBadRequest)
+			case storage.ErrOTPLocked:
+				http.Error(w, "ERR_CPHC_110", http.StatusBadRequest)
+			case storage.ErrOTPWrongCode, storage.ErrOTPNotFound:
+				http.Error(w, "ERR_CPHC_111", http.StatusBadRequest)
+			default:
+				http.Error(w, "ERR_CPHC_105", http.StatusBadRequest)
+			}
+			return
+		}
+
+		newOtp, err := s.VerifyOTPTx(ctx, storage.VerifyOTPParams{
+			Phone:       change.NewPhone,
+			PinCode:     input.NewPinCode,
+			MaxAttempts: helpers.OtpMaxAttempts,
+			Purpose:     common.OTP_PURPOSE_PHONE_CHANGE,
+		})
+		if err != nil {
+			log.Println("error when checking the otp of the new phone: ", err)
+			switch err {
+			case storage.ErrOTPExpired:
+				http.Error(w, "ERR_CPHC_109", http.StatusBadRequest)
+			case storage.ErrOTPLocked:
+				http.Error(w, "ERR_CPHC_110", http.StatusBadRequest)
+			case storage.ErrOTPWrongCode, storage.ErrOTPNotFound:
+				http.Error(w, "ERR_CPHC_112", http.StatusBadRequest)
+			default:
+				http.Error(w, "ERR_CPHC_106", http.StatusBadRequest)
+			}
+			return
+		}
+
+		err = s.ChangePhoneTx(ctx, storage.ChangePhoneParams{
+			PhoneChangeID: change.ID,
+			MemberID:      change.MemberID,
+			NewPhone:      change.NewPhone,
+			OtpIDs:        []uint64{oldOtp.ID, newOtp.ID},
+		})
+		if err != nil {
+			log.Println("error when changing the phone: ", err)
+			http.Error(w, "ERR_CPHC_107", http.StatusBadRequest)
+			return
+		}
+
+		clearAuthCookies(w)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(true); err != nil {
+			log.Println("error when encoding the phone change result: ", err)
+			http.Error(w, "ERR_CPHC_108", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// CancelPhoneChange drops the pending phone changes of the current member, starting with
+// a recovery an admin approved without them
+func CancelPhoneChange(mux chi.Router, s cancelPhoneChange) {
+	mux.Post("/change/cancel", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_CNPC_101", http.StatusUnauthorized)
+			return
+		}
+
+		if err := s.CancelPendingPhoneChanges(ctx, currentMember.ID); err != nil {
+			log.Printf("error when cancelling the phone changes of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_CNPC_102", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(true); err != nil {
+			log.Println("error when encoding the phone change cancellation: ", err)
+			http.Error(w, "ERR_CNPC_103", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// ApprovePhoneRecovery lets an admin move a member who joined the organization to a new
+// number. The old number is warned and the change is held back for PhoneRecoveryDelay,
+// during which the member can still cancel it.
+func ApprovePhoneRecovery(mux chi.Router, s approvePhoneRecovery, n requests.PhoneRecoveryNotifier) {
+	mux.Post("/members/{memberID}/phone/recovery", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// only the admins get here, see the route policies
+		currentMember := GetCurrentMember(r)
+		orgID, _ := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		memberID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "memberID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the member id: ", err)
+			http.Error(w, "ERR_APHR_101", http.StatusBadRequest)
+			return
+		}
+
+		var input PhoneChangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the phone recovery request: ", err)
+			http.Error(w, "ERR_APHR_102", http.StatusBadRequest)
+			return
+		}
+		if !models.IsValidPhone(input.Phone) {
+			log.Println("invalid new phone number: ", input.Phone)
+			http.Error(w, "ERR_APHR_103", http.StatusBadRequest)
+			return
+		}
+
+		membership, err := s.DoesMembershipExist(ctx, storage.DoesMembershipExistParams{
+			MemberID:       memberID,
+			OrganizationID: orgID,
+		})
+		// a pending invitation says nothing about who the member is
+		if err != nil || membership == nil || !membership.Joined {
+			log.Printf("member[%d] has not joined organization[%d]: %v", memberID, orgID, err)
+			http.Error(w, "ERR_APHR_104", http.StatusBadRequest)
+			return
+		}
+		if currentMember.ID == memberID {
+			log.Printf("member[%d] can not approve its own phone recovery", memberID)
+			http.Error(w, "ERR_APHR_110", http.StatusForbidden)
+			return
+		}
+
+		member, err := s.GetMemberByID(ctx, memberID)
+		if err != nil || member == nil {
+			log.Printf("error when getting member[%d]: %v", memberID, err)
+			http.Error(w, "ERR_APHR_105", http.StatusBadRequest)
+			return
+		}
+
+		existingMember, err := s.GetMemberByPhone(ctx, input.Phone)
+		if err != nil {
+			log.Println("error when looking for the member of the new phone: ", err)
+			http.Error(w, "ERR_APHR_106", http.StatusBadRequest)
+			return
+		}
+		if existingMember != nil {
+			log.Println("the new phone number is already used: ", input.Phone)
+			http.Error(w, "ERR_APHR_107", http.StatusConflict)
+			return
+		}
+
+		availableAt := time.Now().UTC().Add(helpers.PhoneRecoveryDelay)
+		change, err := s.CreatePhoneChangeTx(ctx, storage.CreatePhoneChangeParams{
+			MemberID:    member.ID,
+			OldPhone:    member.Phone,
+			NewPhone:    input.Phone,
+			ApprovedBy:  &currentMember.ID,
+			AvailableAt: availableAt,
+			ExpiresAt:   availableAt.Add(helpers.PhoneRecoveryTTL),
+		})
+		if err != nil {
+			log.Println("error when creating the phone recovery: ", err)
+			http.Error(w, "ERR_APHR_108", http.StatusBadRequest)
+			return
+		}
+
+		// the recovery does not go through unless the old number has been warned
+		if err := n.NotifyPhoneRecovery(change.OldPhone, input.Language, change.AvailableAt); err != nil {
+			log.Printf("error when warning %s of the phone recovery: %s", change.OldPhone, err)
+			if err := s.CancelPendingPhoneChanges(ctx, member.ID); err != nil {
+				log.Printf("error when cancelling the phone recovery of member[%d]: %s", member.ID, err)
+			}
+			http.Error(w, "ERR_APHR_111", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(change); err != nil {
+			log.Println("error when encoding the phone recovery: ", err)
+			http.Error(w, "ERR_APHR_109", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func GetPhoneRecoveryOtp(mux chi.Router, s getPhoneRecoveryOtp, d otpDelivery) {
+	mux.Post("/phone/recovery/otp", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var input PhoneChangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the phone recovery otp request: ", err)
+			http.Error(w, "ERR_GPRO_101", http.StatusBadRequest)
+			return
+		}
+
+		change, err := s.GetApprovedPhoneChangeToPhone(ctx, input.Phone)
+		if err != nil || change == nil {
+			log.Printf("no approved phone recovery to %s: %v", input.Phone, err)
+			http.Error(w, "ERR_GPRO_102", http.StatusBadRequest)
+			return
+		}
+
+		status, err := sendOtpToPhone(ctx, s, d, common.OTP_PURPOSE_PHONE_RECOVERY, input.Channel, change.NewPhone, input.Language)
+		if err != nil || status != http.StatusOK {
+			log.Printf("error when sending the Otp to %s: %v", change.NewPhone, err)
+			http.Error(w, "ERR_GPRO_103", status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(true); err != nil {
+			log.Println("error when encoding the phone recovery otp result: ", err)
+			http.Error(w, "ERR_GPRO_104", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func RecoverPhone(mux chi.Router, s recoverPhone) {
+	mux.Post("/phone/recovery", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var input RecoverPhoneRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the phone recovery: ", err)
+			http.Error(w, "ERR_RCPH_101", http.StatusBadRequest)
+			return
+		}
+
+		change, err := s.GetApprovedPhoneChangeToPhone(ctx, input.Phone)
+		if err != nil || change == nil {
+			log.Printf("no approved phone recovery to %s: %v", input.Phone, err)
+			http.Error(w, "ERR_RCPH_102", http.StatusBadRequest)
+			return
+		}
+
+		// the admin approval stands in for the confirmation of the lost number
+		otp, err := s.VerifyOTPTx(ctx, storage.VerifyOTPParams{
+			Phone:       change.NewPhone,
+			PinCode:     input.PinCode,
+			MaxAttempts: helpers.OtpMaxAttempts,
+			Purpose:     common.OTP_PURPOSE_PHONE_RECOVERY,
+		})
+		if err != nil {
+			log.Println("error when checking the otp of the new phone: ", err)
+			switch err {
+			case storage.ErrOTPExpired:
+				http.Error(w, "ERR_RCPH_106", http.StatusBadRequest)
+			case storage.ErrOTPLocked:
+				http.Error(w, "ERR_RCPH_107", http.StatusBadRequest)
+			case storage.ErrOTPWrongCode, storage.ErrOTPNotFound:
+				http.Error(w, "ERR_RCPH_108", http.StatusBadRequest)
+			default:
+				http.Error(w, "ERR_RCPH_103", http.StatusBadRequest)
+			}
+			return
+		}
+
+		err = s.ChangePhoneTx(ctx, storage.ChangePhoneParams{
+			PhoneChangeID: change.ID,
+			MemberID:      change.MemberID,
+			NewPhone:      change.NewPhone,
+			OtpIDs:        []uint64{otp.ID},
+		})
+		if err != nil {
+			log.Println("error when recovering the phone: ", err)
+			http.Error(w, "ERR_RCPH_104", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(true); err != nil {
+			log.Println("error when encoding the phone recovery result: ", err)
+			http.Error(w, "ERR_RCPH_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
@@ -0,0 +1,553 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/storage"
+)
+
+type getBeneficiaryRotation interface {
+	GetBeneficiaryRotation(ctx context.Context, sessionID uint64) (*models.BeneficiaryRotation, error)
+	ListBeneficiarySlots(ctx context.Context, sessionID uint64) ([]*models.BeneficiarySlot, error)
+}
+
+type generateBeneficiaryRotation interface {
+	ListMembersInSession(ctx context.Context, sessionID uint64) ([]*models.MembersOfSession, error)
+	GenerateBeneficiaryRotationTx(ctx context.Context, arg storage.GenerateBeneficiaryRotationParams) (*models.BeneficiaryRotation, error)
+	ListBeneficiarySlots(ctx context.Context, sessionID uint64) ([]*models.BeneficiarySlot, error)
+}
+
+type getCurrentBeneficiary interface {
+	ListBeneficiarySlots(ctx context.Context, sessionID uint64) ([]*models.BeneficiarySlot, error)
+}
+
+type payBeneficiary interface {
+	GetBeneficiarySlot(ctx context.Context, arg storage.GetBeneficiarySlotParams) (*models.BeneficiarySlot, error)
+	ServeBeneficiarySlot(ctx context.Context, arg storage.GetBeneficiarySlotParams) (bool, error)
+}
+
+type listBeneficiarySwaps interface {
+	ListBeneficiarySwaps(ctx context.Context, sessionID uint64) ([]*models.BeneficiarySwap, error)
+}
+
+type requestBeneficiarySwap interface {
+	GetBeneficiarySlot(ctx context.Context, arg storage.GetBeneficiarySlotParams) (*models.BeneficiarySlot, error)
+	CreateBeneficiarySwap(ctx context.Context, arg storage.CreateBeneficiarySwapParams) (*models.BeneficiarySwap, error)
+}
+
+type decideBeneficiarySwap interface {
+	GetBeneficiarySwap(ctx context.Context, arg storage.GetBeneficiarySwapParams) (*models.BeneficiarySwap, error)
+	GetBeneficiarySlot(ctx context.Context, arg storage.GetBeneficiarySlotParams) (*models.BeneficiarySlot, error)
+	DecideBeneficiarySwapTx(ctx context.Context, arg storage.DecideBeneficiarySwapTxParams) (*models.BeneficiarySwap, error)
+}
+
+type GenerateBeneficiaryRotationRequest struct {
+	Method string `json:"method,omitempty"`
+	// Seed draws the order again, a new one is picked when left out
+	Seed *int64 `json:"seed,omitempty"`
+	// MembershipIDs is the order set by hand, every member of the session once per share
+	MembershipIDs []uint64 `json:"membership_ids,omitempty"`
+}
+
+type RequestBeneficiarySwapRequest struct {
+	FromSlotID uint64 `json:"from_slot_id,omitempty"`
+	ToSlotID   uint64 `json:"to_slot_id,omitempty"`
+}
+
+// CurrentBeneficiary is who takes the pot at the coming meeting, and who takes it after
+type CurrentBeneficiary struct {
+	Current *models.BeneficiarySlot `json:"current"`
+	Next    *models.BeneficiarySlot `json:"next"`
+}
+
+type BeneficiarySchedule struct {
+	Rotation *models.BeneficiaryRotation `json:"rotation"`
+	Slots    []*models.BeneficiarySlot   `json:"slots"`
+	CurrentBeneficiary
+}
+
+func newBeneficiarySchedule(rotation *models.BeneficiaryRotation, slots []*models.BeneficiarySlot) *BeneficiarySchedule {
+	current, next := helpers.CurrentAndNextBeneficiary(slots)
+	return &BeneficiarySchedule{
+		Rotation: rotation,
+		Slots:    slots,
+		CurrentBeneficiary: CurrentBeneficiary{
+			Current: current,
+			Next:    next,
+		},
+	}
+}
+
+// sameMemberships tells whether the order holds every membership of the session once per hand
+func sameMemberships(order []uint64, members []*models.MembersOfSession) bool {
+	left := map[uint64]int{}
+	for _, member := range members {
+		left[member.MembershipID] = member.Shares
+	}
+	for _, membershipID := range order {
+		if left[membershipID] == 0 {
+			return false
+		}
+		left[membershipID]--
+	}
+	for _, hands := range left {
+		if hands != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isOfficer tells whether the membership may manage the sessions of the organization
+func isOfficer(membership *models.Membership) bool {
+	return membership.Role == common.MEMBERSHIP_ROLE_ADMIN || membership.Role == common.MEMBERSHIP_ROLE_OFFICER
+}
+
+func newRotationSeed() (int64, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+func GetBeneficiaryRotation(mux chi.Router, s getBeneficiaryRotation) {
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_GBRT_101", http.StatusBadRequest)
+			return
+		}
+
+		rotation, err := s.GetBeneficiaryRotation(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when getting the rotation of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_GBRT_102", http.StatusBadRequest)
+			return
+		}
+		if rotation == nil {
+			http.Error(w, "ERR_GBRT_103", http.StatusNotFound)
+			return
+		}
+
+		slots, err := s.ListBeneficiarySlots(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when listing the slots of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_GBRT_104", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(newBeneficiarySchedule(rotation, slots)); err != nil {
+			log.Println("error when encoding the rotation: ", err)
+			http.Error(w, "ERR_GBRT_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// GenerateBeneficiaryRotation sets the order in which the members of the session take the pot:
+// the order they joined the session in, a random draw, or an order given by hand
+func GenerateBeneficiaryRotation(mux chi.Router, s generateBeneficiaryRotation) {
+	mux.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_CBRT_101", http.StatusBadRequest)
+			return
+		}
+
+		var input GenerateBeneficiaryRotationRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the rotation: ", err)
+			http.Error(w, "ERR_CBRT_102", http.StatusBadRequest)
+			return
+		}
+
+		members, err := s.ListMembersInSession(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when listing the members of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_CBRT_103", http.StatusBadRequest)
+			return
+		}
+		if len(members) == 0 {
+			http.Error(w, "ERR_CBRT_104", http.StatusBadRequest)
+			return
+		}
+		membershipIDs := helpers.BeneficiaryHands(members)
+
+		var seed *int64
+		switch input.Method {
+		case common.ROTATION_METHOD_FIXED:
+		case common.ROTATION_METHOD_DRAW:
+			seed = input.Seed
+			if seed == nil {
+				newSeed, err := newRotationSeed()
+				if err != nil {
+					log.Println("error when picking a seed: ", err)
+					http.Error(w, "ERR_CBRT_105", http.StatusInternalServerError)
+					return
+				}
+				seed = &newSeed
+			}
+			membershipIDs = helpers.DrawBeneficiaryOrder(membershipIDs, *seed)
+		case common.ROTATION_METHOD_MANUAL:
+			if !sameMemberships(input.MembershipIDs, members) {
+				log.Printf("the order given for session[%d] is not its members: %v", sessionID, input.MembershipIDs)
+				http.Error(w, "ERR_CBRT_106", http.StatusBadRequest)
+				return
+			}
+			membershipIDs = input.MembershipIDs
+		default:
+			http.Error(w, "ERR_CBRT_107", http.StatusBadRequest)
+			return
+		}
+
+		var createdBy *uint64
+		if currentMember := GetCurrentMember(r); currentMember != nil {
+			createdBy = &currentMember.ID
+		}
+
+		rotation, err := s.GenerateBeneficiaryRotationTx(ctx, storage.GenerateBeneficiaryRotationParams{
+			SessionID:     sessionID,
+			Method:        input.Method,
+			Seed:          seed,
+			CreatedBy:     createdBy,
+			MembershipIDs: membershipIDs,
+		})
+		if errors.Is(err, storage.ErrRotationStarted) {
+			http.Error(w, "ERR_CBRT_108", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("error when generating the rotation of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_CBRT_109", http.StatusBadRequest)
+			return
+		}
+
+		slots, err := s.ListBeneficiarySlots(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when listing the slots of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_CBRT_110", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(newBeneficiarySchedule(rotation, slots)); err != nil {
+			log.Println("error when encoding the rotation: ", err)
+			http.Error(w, "ERR_CBRT_111", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func GetCurrentBeneficiary(mux chi.Router, s getCurrentBeneficiary) {
+	mux.Get("/current", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_GCBN_101", http.StatusBadRequest)
+			return
+		}
+
+		slots, err := s.ListBeneficiarySlots(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when listing the slots of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_GCBN_102", http.StatusBadRequest)
+			return
+		}
+		if len(slots) == 0 {
+			http.Error(w, "ERR_GCBN_103", http.StatusNotFound)
+			return
+		}
+
+		current, next := helpers.CurrentAndNextBeneficiary(slots)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(CurrentBeneficiary{Current: current, Next: next}); err != nil {
+			log.Println("error when encoding the current beneficiary: ", err)
+			http.Error(w, "ERR_GCBN_104", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// PayBeneficiary records that the pot has been handed over to the member whose turn it is
+func PayBeneficiary(mux chi.Router, s payBeneficiary) {
+	mux.Post("/slots/{slotID}/payout", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_PBNF_101", http.StatusBadRequest)
+			return
+		}
+		slotID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "slotID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the slot id: ", err)
+			http.Error(w, "ERR_PBNF_101", http.StatusBadRequest)
+			return
+		}
+
+		arg := storage.GetBeneficiarySlotParams{
+			ID:        slotID,
+			SessionID: sessionID,
+		}
+		served, err := s.ServeBeneficiarySlot(ctx, arg)
+		if err != nil {
+			log.Printf("error when serving slot[%d] of session[%d]: %s", slotID, sessionID, err)
+			http.Error(w, "ERR_PBNF_102", http.StatusBadRequest)
+			return
+		}
+
+		slot, err := s.GetBeneficiarySlot(ctx, arg)
+		if err != nil {
+			log.Printf("error when getting slot[%d] of session[%d]: %s", slotID, sessionID, err)
+			http.Error(w, "ERR_PBNF_103", http.StatusBadRequest)
+			return
+		}
+		if slot == nil {
+			http.Error(w, "ERR_PBNF_104", http.StatusNotFound)
+			return
+		}
+		// Either served already, or not the turn of this slot yet
+		if !served {
+			http.Error(w, "ERR_PBNF_105", http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(slot); err != nil {
+			log.Println("error when encoding the slot: ", err)
+			http.Error(w, "ERR_PBNF_106", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func ListBeneficiarySwaps(mux chi.Router, s listBeneficiarySwaps) {
+	mux.Get("/swaps", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_LBSW_101", http.StatusBadRequest)
+			return
+		}
+
+		swaps, err := s.ListBeneficiarySwaps(ctx, sessionID)
+		if err != nil {
+			log.Printf("error when listing the swaps of session[%d]: %s", sessionID, err)
+			http.Error(w, "ERR_LBSW_102", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(swaps); err != nil {
+			log.Println("error when encoding the swaps: ", err)
+			http.Error(w, "ERR_LBSW_103", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+// RequestBeneficiarySwap lets a member offer their turn in exchange for another one,
+// the swap takes place once the holder of the other turn or an officer approves it
+func RequestBeneficiarySwap(mux chi.Router, s requestBeneficiarySwap) {
+	mux.Post("/swaps", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_RBSW_101", http.StatusBadRequest)
+			return
+		}
+
+		membership := GetCurrentMembership(r)
+		if membership == nil {
+			http.Error(w, "ERR_RBSW_102", http.StatusForbidden)
+			return
+		}
+
+		var input RequestBeneficiarySwapRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the swap: ", err)
+			http.Error(w, "ERR_RBSW_103", http.StatusBadRequest)
+			return
+		}
+		if input.FromSlotID == input.ToSlotID {
+			http.Error(w, "ERR_RBSW_104", http.StatusBadRequest)
+			return
+		}
+
+		from, err := s.GetBeneficiarySlot(ctx, storage.GetBeneficiarySlotParams{ID: input.FromSlotID, SessionID: sessionID})
+		if err != nil {
+			log.Printf("error when getting slot[%d] of session[%d]: %s", input.FromSlotID, sessionID, err)
+			http.Error(w, "ERR_RBSW_105", http.StatusBadRequest)
+			return
+		}
+		to, err := s.GetBeneficiarySlot(ctx, storage.GetBeneficiarySlotParams{ID: input.ToSlotID, SessionID: sessionID})
+		if err != nil {
+			log.Printf("error when getting slot[%d] of session[%d]: %s", input.ToSlotID, sessionID, err)
+			http.Error(w, "ERR_RBSW_105", http.StatusBadRequest)
+			return
+		}
+		if from == nil || to == nil {
+			http.Error(w, "ERR_RBSW_106", http.StatusNotFound)
+			return
+		}
+		if from.MembershipID != membership.ID {
+			log.Printf("membership[%d] does not hold slot[%d]", membership.ID, from.ID)
+			http.Error(w, "ERR_RBSW_107", http.StatusForbidden)
+			return
+		}
+		// Both slots are hands of the same member, there is nothing to swap
+		if to.MembershipID == membership.ID {
+			http.Error(w, "ERR_RBSW_104", http.StatusBadRequest)
+			return
+		}
+		if from.ServedAt != nil || to.ServedAt != nil {
+			http.Error(w, "ERR_RBSW_108", http.StatusConflict)
+			return
+		}
+
+		swap, err := s.CreateBeneficiarySwap(ctx, storage.CreateBeneficiarySwapParams{
+			SessionID:   sessionID,
+			FromSlotID:  from.ID,
+			ToSlotID:    to.ID,
+			RequestedBy: membership.ID,
+		})
+		if err != nil {
+			log.Printf("error when requesting a swap of slot[%d] with slot[%d]: %s", from.ID, to.ID, err)
+			http.Error(w, "ERR_RBSW_109", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(swap); err != nil {
+			log.Println("error when encoding the swap: ", err)
+			http.Error(w, "ERR_RBSW_110", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func ApproveBeneficiarySwap(mux chi.Router, s decideBeneficiarySwap) {
+	mux.Post("/swaps/{swapID}/approve", decideBeneficiarySwapHandler(s, true))
+}
+
+func RejectBeneficiarySwap(mux chi.Router, s decideBeneficiarySwap) {
+	mux.Post("/swaps/{swapID}/reject", decideBeneficiarySwapHandler(s, false))
+}
+
+// decideBeneficiarySwapHandler lets the holder of the turn asked for, or an officer, decide on a swap
+func decideBeneficiarySwapHandler(s decideBeneficiarySwap, approved bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the session id: ", err)
+			http.Error(w, "ERR_DBSW_101", http.StatusBadRequest)
+			return
+		}
+		swapID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "swapID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the swap id: ", err)
+			http.Error(w, "ERR_DBSW_101", http.StatusBadRequest)
+			return
+		}
+
+		membership := GetCurrentMembership(r)
+		if membership == nil {
+			http.Error(w, "ERR_DBSW_102", http.StatusForbidden)
+			return
+		}
+
+		swap, err := s.GetBeneficiarySwap(ctx, storage.GetBeneficiarySwapParams{ID: swapID, SessionID: sessionID})
+		if err != nil {
+			log.Printf("error when getting swap[%d] of session[%d]: %s", swapID, sessionID, err)
+			http.Error(w, "ERR_DBSW_103", http.StatusBadRequest)
+			return
+		}
+		if swap == nil {
+			http.Error(w, "ERR_DBSW_104", http.StatusNotFound)
+			return
+		}
+
+		if !isOfficer(membership) {
+			to, err := s.GetBeneficiarySlot(ctx, storage.GetBeneficiarySlotParams{ID: swap.ToSlotID, SessionID: sessionID})
+			if err != nil {
+				log.Printf("error when getting slot[%d] of session[%d]: %s", swap.ToSlotID, sessionID, err)
+				http.Error(w, "ERR_DBSW_105", http.StatusBadRequest)
+				return
+			}
+			if to == nil || to.MembershipID != membership.ID {
+				log.Printf("membership[%d] can not decide on swap[%d]", membership.ID, swap.ID)
+				http.Error(w, "ERR_DBSW_106", http.StatusForbidden)
+				return
+			}
+		}
+
+		var decidedBy *uint64
+		if currentMember := GetCurrentMember(r); currentMember != nil {
+			decidedBy = &currentMember.ID
+		}
+
+		swap, err = s.DecideBeneficiarySwapTx(ctx, storage.DecideBeneficiarySwapTxParams{
+			SwapID:    swap.ID,
+			SessionID: sessionID,
+			Approved:  approved,
+			DecidedBy: decidedBy,
+		})
+		if errors.Is(err, storage.ErrSwapNotFound) {
+			http.Error(w, "ERR_DBSW_104", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, storage.ErrSwapAlreadyDecided) || errors.Is(err, storage.ErrSwapSlotsChanged) {
+			http.Error(w, "ERR_DBSW_107", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("error when deciding swap[%d] of session[%d]: %s", swapID, sessionID, err)
+			http.Error(w, "ERR_DBSW_108", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(swap); err != nil {
+			log.Println("error when encoding the swap: ", err)
+			http.Error(w, "ERR_DBSW_109", http.StatusBadRequest)
+			return
+		}
+	}
+}
@@ -39,7 +39,7 @@ type addMemberToSession interface {
 
 type removeMemberFromSession interface {
 	DoesMembershipConcernOrganization(ctx context.Context, arg storage.DoesMembershipConcernOrganizationParams) (*models.Membership, error)
-	RemoveMemberFromSession(ctx context.Context, arg storage.RemoveMemberFromSessionParams) error
+	RemoveMemberFromSessionTx(ctx context.Context, arg storage.RemoveMemberFromSessionParams) error
 }
 
 type CreateSessionRequest struct {
@@ -147,6 +147,8 @@ func GetMembersOfSession(mux chi.Router, svc getMembersOfSession) {
 
 type UpdateSessionMembersRequest struct {
 	MembershipIDs []uint64 `json:"membership_ids"`
+	// Shares are the hands held by the memberships, one when left out
+	Shares map[uint64]int `json:"shares,omitempty"`
 }
 
 func UpdateSessionMembers(mux chi.Router, svc updateSessionMembers) {
@@ -170,6 +172,14 @@ func UpdateSessionMembers(mux chi.Router, svc updateSessionMembers) {
 		}
 		log.Println("Request - ", inputs)
 
+		for membershipID, shares := range inputs.Shares {
+			if shares < 1 {
+				log.Printf("invalid shares for membership[%d]: %d", membershipID, shares)
+				http.Error(w, "ERR_UPD_MBSHIP_SESS_101", http.StatusBadRequest)
+				return
+			}
+		}
+
 		memberships := make([]models.Membership, 0, len(inputs.MembershipIDs))
 		countNoMembership := 0
 		wg := new(sync.WaitGroup)
@@ -201,6 +211,7 @@ func UpdateSessionMembers(mux chi.Router, svc updateSessionMembers) {
 			OrganizationID: orgID,
 			SessionID:      sessionID,
 			Memberships:    memberships,
+			Shares:         inputs.Shares,
 		})
 		if err != nil {
 			log.Printf("error because there are [%d] members who does not belong to the organization [%d]", countNoMembership, orgID)
@@ -219,6 +230,8 @@ func UpdateSessionMembers(mux chi.Router, svc updateSessionMembers) {
 
 type AddMemberToSessionRequest struct {
 	MembershipID uint64 `json:"membership_id"`
+	// Shares is the number of hands of the member, one when left out
+	Shares int `json:"shares,omitempty"`
 }
 
 func AddMemberToSession(mux chi.Router, s addMemberToSession) {
@@ -242,6 +255,14 @@ func AddMemberToSession(mux chi.Router, s addMemberToSession) {
 		}
 
 		membershipID := inputs.MembershipID
+		if inputs.Shares == 0 {
+			inputs.Shares = 1
+		}
+		if inputs.Shares < 0 {
+			log.Printf("invalid shares for membership[%d]: %d", membershipID, inputs.Shares)
+			http.Error(w, "ERR_ADD_MBSHIP_SESS_106", http.StatusBadRequest)
+			return
+		}
 
 		membership, err := s.DoesMembershipConcernOrganization(ctx, storage.DoesMembershipConcernOrganizationParams{
 			ID:             membershipID,
@@ -261,6 +282,7 @@ func AddMemberToSession(mux chi.Router, s addMemberToSession) {
 		mos, err := s.AddMemberToSession(ctx, storage.AddMemberToSessionParams{
 			MembershipID: membershipID,
 			SessionID:    sessionID,
+			Shares:       inputs.Shares,
 		})
 		if err != nil {
 			log.Printf("error when adding membership[%d] to session[%d]: %s", membershipID, sessionID, err)
@@ -295,7 +317,7 @@ func RemoveMemberFromSession(mux chi.Router, s removeMemberFromSession) {
 		mosIdParam := chi.URLParamFromCtx(ctx, "mosID")
 		mosID, _ := strconv.ParseUint(mosIdParam, 10, 64)
 
-		err := s.RemoveMemberFromSession(ctx, storage.RemoveMemberFromSessionParams{
+		err := s.RemoveMemberFromSessionTx(ctx, storage.RemoveMemberFromSessionParams{
 			ID:             mosID,
 			SessionID:      sessionID,
 			OrganizationID: orgID,
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/requests"
+	"tschwaa.com/api/storage"
+)
+
+type signInThrottler interface {
+	GetSignInThrottle(ctx context.Context, key string) (*models.SignInThrottle, error)
+	RecordSignInFailure(ctx context.Context, arg storage.RecordSignInFailureParams) (*models.SignInThrottle, error)
+	LockSignIn(ctx context.Context, arg storage.LockSignInParams) error
+	ResetSignInThrottle(ctx context.Context, key string) error
+}
+
+// signInThrottleKeys returns the keys the failed sign-ins are counted under,
+// one for the username whether it exists or not and one for the address of the client
+func signInThrottleKeys(r *http.Request, username string) (string, string) {
+	return helpers.UsernameThrottleKey(username), "ip:" + clientIP(r)
+}
+
+// signInWaitRemaining returns how long the client has to wait before trying to sign in again
+func signInWaitRemaining(ctx context.Context, s signInThrottler, keys ...string) (time.Duration, error) {
+	now := time.Now().UTC()
+
+	var wait time.Duration
+	for _, key := range keys {
+		throttle, err := s.GetSignInThrottle(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if throttle == nil {
+			continue
+		}
+
+		if remaining := helpers.SignInWaitRemaining(throttle.Failures, throttle.LastFailedAt, throttle.LockedUntil, now); remaining > wait {
+			wait = remaining
+		}
+	}
+
+	return wait, nil
+}
+
+// recordSignInFailure counts a failed sign-in under the key and locks the key once it
+// reaches maxFailures. It returns the end of the lockout when the key has just been locked.
+func recordSignInFailure(ctx context.Context, s signInThrottler, key string, maxFailures int) (*time.Time, error) {
+	now := time.Now().UTC()
+
+	throttle, err := s.RecordSignInFailure(ctx, storage.RecordSignInFailureParams{
+		Key:   key,
+		Since: now.Add(-helpers.SignInFailureWindow),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if throttle.Failures < maxFailures || (throttle.LockedUntil != nil && throttle.LockedUntil.After(now)) {
+		return nil, nil
+	}
+
+	lockedUntil := now.Add(helpers.SignInLockoutDuration)
+	err = s.LockSignIn(ctx, storage.LockSignInParams{
+		Key:         key,
+		LockedUntil: lockedUntil,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &lockedUntil, nil
+}
+
+// notifySignInLockout warns the owner of the account in the background, in the language of
+// the sign-in request, so that the response does not tell whether the username exists
+func notifySignInLockout(n requests.LockoutNotifier, user *models.User, language string, lockedUntil time.Time) {
+	if user == nil || user.Phone == "" {
+		return
+	}
+	if language == "" {
+		language = "fr"
+	}
+
+	go func() {
+		if err := n.NotifySignInLockout(user.Phone, language, lockedUntil); err != nil {
+			log.Printf("error when notifying the sign-in lockout of user[%d]: %s", user.ID, err)
+		}
+	}()
+}
@@ -1,21 +1,198 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"time"
 
+	"github.com/fatih/structs"
 	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/services"
+	"tschwaa.com/api/storage"
 )
 
-func Refresh(mux chi.Router) {
-	mux.Post("/refresh", func(w http.ResponseWriter, r *http.Request) {})
+type refreshToken interface {
+	RotateRefreshTokenTx(ctx context.Context, arg storage.RotateRefreshTokenParams) (*models.RefreshToken, error)
+	GetMemberByID(ctx context.Context, id uint64) (*models.Member, error)
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	TouchLogin(ctx context.Context, familyID string) (*models.Login, error)
 }
 
-func IsTokenValid(mux chi.Router) {
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func Refresh(mux chi.Router, s refreshToken) {
+	mux.Post("/refresh", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// The web app sends the refresh token as a cookie, the mobile app in the body
+		var token string
+		if cookie, err := r.Cookie("refresh_token"); err == nil {
+			token = cookie.Value
+		}
+		if token == "" {
+			var input RefreshRequest
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				log.Println("error when decoding the refresh request: ", err)
+				http.Error(w, "ERR_RFSH_101", http.StatusBadRequest)
+				return
+			}
+			token = input.RefreshToken
+		}
+		if token == "" {
+			log.Println("no refresh token provided")
+			http.Error(w, "ERR_RFSH_102", http.StatusUnauthorized)
+			return
+		}
+
+		nextToken, nextTokenHash, err := services.GenerateOpaqueToken()
+		if err != nil {
+			log.Println("error when generating the refresh token: ", err)
+			http.Error(w, "ERR_RFSH_103", http.StatusBadRequest)
+			return
+		}
+
+		rotated, err := s.RotateRefreshTokenTx(ctx, storage.RotateRefreshTokenParams{
+			TokenHash:    services.HashOpaqueToken(token),
+			NewTokenHash: nextTokenHash,
+			ExpiresAt:    time.Now().UTC().Add(services.RefreshTokenTTL),
+		})
+		if err != nil {
+			log.Println("error when rotating the refresh token: ", err)
+			switch err {
+			case storage.ErrRefreshTokenNotFound:
+				http.Error(w, "ERR_RFSH_104", http.StatusUnauthorized)
+			case storage.ErrRefreshTokenExpired:
+				http.Error(w, "ERR_RFSH_105", http.StatusUnauthorized)
+			case storage.ErrRefreshTokenReused:
+				http.Error(w, "ERR_RFSH_106", http.StatusUnauthorized)
+			default:
+				http.Error(w, "ERR_RFSH_107", http.StatusBadRequest)
+			}
+			return
+		}
+
+		member, err := s.GetMemberByID(ctx, rotated.MemberID)
+		if err != nil || member == nil {
+			log.Println("error when getting the member of the refresh token: ", err)
+			http.Error(w, "ERR_RFSH_108", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, member.ID)
+		if err != nil || user == nil {
+			log.Println("error when getting the user of the refresh token: ", err)
+			http.Error(w, "ERR_RFSH_112", http.StatusUnauthorized)
+			return
+		}
+
+		// tokens of families opened before logins were recorded carry no login
+		var loginID uint64
+		var twoFactor bool
+		login, err := s.TouchLogin(ctx, rotated.FamilyID)
+		if err != nil {
+			log.Println("error when updating the login of the refresh token: ", err)
+			http.Error(w, "ERR_RFSH_111", http.StatusBadRequest)
+			return
+		}
+		if login != nil {
+			loginID = login.ID
+			twoFactor = login.TwoFactorAt != nil
+		}
+
+		var signInResult SignInResult
+		signInResult.Name = fmt.Sprintf("%s %s", member.FirstName, member.LastName)
+		signInResult.Email = member.Email
+		signInResult.Phone = member.Phone
+		signInResult.ID = member.ID
+		signInResult.EmailVerified = user.EmailVerifiedAt != nil
+		signInResult.TwoFactor = twoFactor
+
+		tokenString, err := services.GenerateJWTToken(structs.Map(&signInResult), loginID)
+		if err != nil {
+			log.Println("error when generating jwt token: ", err)
+			http.Error(w, "ERR_RFSH_109", http.StatusBadRequest)
+			return
+		}
+
+		setAuthTokens(w, r, &signInResult, tokenString, nextToken)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(signInResult); err != nil {
+			log.Println("error when encoding the refresh result: ", err)
+			http.Error(w, "ERR_RFSH_110", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+type isTokenValid interface {
+	GetMemberByID(ctx context.Context, id uint64) (*models.Member, error)
+	ListOrganizationOfMember(ctx context.Context, memberID uint64) ([]*models.Organization, error)
+}
+
+type IsTokenValidRequest struct {
+	Token string `json:"token,omitempty"`
+}
+
+type IsTokenValidResult struct {
+	Valid         bool                   `json:"valid"`
+	Error         string                 `json:"error,omitempty"`
+	ExpiresAt     *time.Time             `json:"expires_at,omitempty"`
+	IssuedAt      *time.Time             `json:"issued_at,omitempty"`
+	Member        *models.Member         `json:"member,omitempty"`
+	Organizations []*models.Organization `json:"organizations,omitempty"`
+}
+
+func IsTokenValid(mux chi.Router, s isTokenValid) {
 	mux.Post("/valid", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// The token to check is the one in the body, or else the one sent the usual way
+		var input IsTokenValidRequest
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				log.Println("error when decoding the token validity request: ", err)
+				http.Error(w, "ERR_TKVL_101", http.StatusBadRequest)
+				return
+			}
+		}
+		tokenString := input.Token
+		if tokenString == "" {
+			tokenString, _ = ctx.Value(services.JWTTokenKey).(string)
+		}
+
+		var result IsTokenValidResult
+		claims, info, err := services.ValidateJWTToken(ctx, tokenString)
+		if err == nil {
+			result.Member, err = services.GetMemberFromClaims(ctx, s, claims["user"])
+			if err == nil && result.Member == nil {
+				err = errors.New("no member related to the token")
+			}
+		}
+		if err == nil {
+			result.Organizations, err = s.ListOrganizationOfMember(ctx, result.Member.ID)
+		}
+
+		if err != nil {
+			log.Println("the token is not valid: ", err)
+			result = IsTokenValidResult{Error: err.Error()}
+		} else {
+			result.Valid = true
+			result.ExpiresAt = &info.ExpiresAt
+			result.IssuedAt = &info.IssuedAt
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(false); err != nil {
+		if err := json.NewEncoder(w).Encode(result); err != nil {
 			http.Error(w, "error encoding the result", http.StatusBadRequest)
 			return
 		}
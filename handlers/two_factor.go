@@ -0,0 +1,747 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/services"
+	"tschwaa.com/api/storage"
+)
+
+type twoFactorGetter interface {
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+}
+
+type secondFactorChecker interface {
+	signInThrottler
+	UseTwoFactorStep(ctx context.Context, arg storage.TwoFactorStepParams) (bool, error)
+	UseRecoveryCode(ctx context.Context, arg storage.RecoveryCodeParams) (bool, error)
+}
+
+type verifyTwoFactor interface {
+	secondFactorChecker
+	GetMemberByID(ctx context.Context, id uint64) (*models.Member, error)
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	CreateLoginTx(ctx context.Context, arg storage.CreateLoginTxParams) (*models.Login, error)
+}
+
+type getTwoFactorStatus interface {
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	CountRecoveryCodesLeft(ctx context.Context, userID uint64) (int64, error)
+}
+
+type enrollTwoFactor interface {
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	UpsertTwoFactor(ctx context.Context, arg storage.UpsertTwoFactorParams) (*models.TwoFactor, error)
+}
+
+type confirmTwoFactor interface {
+	signInThrottler
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	ConfirmTwoFactorTx(ctx context.Context, arg storage.ConfirmTwoFactorParams) error
+	MarkLoginTwoFactor(ctx context.Context, arg storage.MarkLoginTwoFactorParams) error
+}
+
+type regenerateRecoveryCodes interface {
+	secondFactorChecker
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	ReplaceRecoveryCodesTx(ctx context.Context, arg storage.ReplaceRecoveryCodesParams) error
+}
+
+type disableTwoFactor interface {
+	secondFactorChecker
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	CountTwoFactorRequiredMemberships(ctx context.Context, memberID uint64) (int64, error)
+	DisableTwoFactorTx(ctx context.Context, userID uint64) error
+}
+
+type getTwoFactorPolicy interface {
+	IsTwoFactorRequired(ctx context.Context, id uint64) (bool, error)
+}
+
+type updateTwoFactorPolicy interface {
+	SetTwoFactorRequired(ctx context.Context, arg storage.SetTwoFactorRequiredParams) error
+}
+
+// TwoFactorChallenge is the answer of the sign in to the members with an authenticator app,
+// they exchange the token and a code for their tokens at /auth/2fa/verify
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	TwoFactorToken    string    `json:"two_factor_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+type VerifyTwoFactorRequest struct {
+	TwoFactorToken string `json:"two_factor_token,omitempty"`
+	Code           string `json:"code,omitempty"`
+	RecoveryCode   string `json:"recovery_code,omitempty"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	ConfirmedAt       *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesLeft int64      `json:"recovery_codes_left"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	// ProvisioningURI is the content of the QR code scanned by the authenticator apps
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type RecoveryCodesResult struct {
+	// RecoveryCodes are only ever shown in this response
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorPolicy struct {
+	Required bool `json:"required"`
+}
+
+// twoFactorThrottleKey counts the wrong codes of the member, the way the wrong passwords are
+func twoFactorThrottleKey(memberID uint64) string {
+	return fmt.Sprintf("two-factor:%d", memberID)
+}
+
+// checkSecondFactor tells whether the code of the authenticator app, or else the recovery code,
+// is valid. Both can only be used once, and every wrong one counts against the member.
+func checkSecondFactor(ctx context.Context, s secondFactorChecker, memberID uint64, twoFactor *models.TwoFactor, code, recoveryCode string) (bool, error) {
+	var valid bool
+	switch {
+	case code != "":
+		secret, err := services.DecryptTOTPSecret(twoFactor.Secret)
+		if err != nil {
+			return false, err
+		}
+		if step, ok := services.ValidateTOTP(secret, code, time.Now().UTC()); ok {
+			valid, err = s.UseTwoFactorStep(ctx, storage.TwoFactorStepParams{
+				UserID: twoFactor.UserID,
+				Step:   step,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+	case recoveryCode != "":
+		var err error
+		valid, err = s.UseRecoveryCode(ctx, storage.RecoveryCodeParams{
+			UserID:   twoFactor.UserID,
+			CodeHash: services.HashRecoveryCode(recoveryCode),
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	key := twoFactorThrottleKey(memberID)
+	if valid {
+		return true, s.ResetSignInThrottle(ctx, key)
+	}
+	_, err := recordSignInFailure(ctx, s, key, helpers.SignInMaxFailures)
+	return false, err
+}
+
+// hasTwoFactor tells whether the user confirmed an authenticator app
+func hasTwoFactor(ctx context.Context, s twoFactorGetter, userID uint64) (bool, error) {
+	twoFactor, err := s.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return twoFactor != nil && twoFactor.ConfirmedAt != nil, nil
+}
+
+// writeTwoFactorChallenge answers a first factor of a member with an authenticator app, whatever
+// it is, with the token to exchange for their tokens along with a code at /auth/2fa/verify
+func writeTwoFactorChallenge(w http.ResponseWriter, memberID uint64) error {
+	expiresAt := time.Now().UTC().Add(services.TwoFactorTTL)
+	challenge := TwoFactorChallenge{
+		TwoFactorRequired: true,
+		TwoFactorToken:    services.GenerateTwoFactorToken(memberID, expiresAt),
+		ExpiresAt:         expiresAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(challenge)
+}
+
+// newRecoveryCodes returns a new set of recovery codes along with the hashes to store
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := services.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, services.HashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func VerifyTwoFactor(mux chi.Router, s verifyTwoFactor) {
+	mux.Post("/2fa/verify", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var input VerifyTwoFactorRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the two-factor verification: ", err)
+			http.Error(w, "ERR_VTFA_101", http.StatusBadRequest)
+			return
+		}
+
+		memberID, err := services.ParseTwoFactorToken(input.TwoFactorToken)
+		if err != nil {
+			log.Println("error when parsing the two-factor token: ", err)
+			if err == services.ErrExpiredTwoFactorToken {
+				http.Error(w, "ERR_VTFA_102", http.StatusUnauthorized)
+			} else {
+				http.Error(w, "ERR_VTFA_103", http.StatusUnauthorized)
+			}
+			return
+		}
+
+		wait, err := signInWaitRemaining(ctx, s, twoFactorThrottleKey(memberID))
+		if err != nil {
+			log.Println("error when getting the two-factor throttle: ", err)
+			http.Error(w, "ERR_VTFA_104", http.StatusBadRequest)
+			return
+		}
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "ERR_VTFA_105", http.StatusTooManyRequests)
+			return
+		}
+
+		member, err := s.GetMemberByID(ctx, memberID)
+		if err != nil || member == nil {
+			log.Printf("error when getting member[%d]: %s", memberID, err)
+			http.Error(w, "ERR_VTFA_106", http.StatusBadRequest)
+			return
+		}
+		user, err := s.GetUserByMemberID(ctx, member.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", member.ID, err)
+			http.Error(w, "ERR_VTFA_106", http.StatusBadRequest)
+			return
+		}
+		twoFactor, err := s.GetTwoFactor(ctx, user.ID)
+		if err != nil || twoFactor == nil || twoFactor.ConfirmedAt == nil {
+			log.Printf("no two-factor authentication for user[%d]: %v", user.ID, err)
+			http.Error(w, "ERR_VTFA_107", http.StatusBadRequest)
+			return
+		}
+
+		valid, err := checkSecondFactor(ctx, s, member.ID, twoFactor, input.Code, input.RecoveryCode)
+		if err != nil {
+			log.Printf("error when checking the second factor of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_VTFA_108", http.StatusBadRequest)
+			return
+		}
+		if !valid {
+			http.Error(w, "ERR_VTFA_109", http.StatusBadRequest)
+			return
+		}
+
+		var signInResult SignInResult
+		signInResult.Name = fmt.Sprintf("%s %s", member.FirstName, member.LastName)
+		signInResult.Email = member.Email
+		signInResult.ID = member.ID
+		signInResult.EmailVerified = user.EmailVerifiedAt != nil
+		signInResult.TwoFactor = true
+
+		channel := common.CLIENT_TYPE_WEB
+		if services.UsesBearerToken(r) {
+			channel = common.CLIENT_TYPE_MOBILE
+		}
+		login, refreshToken, err := openLogin(ctx, s, r, member.ID, channel, true)
+		if err != nil {
+			log.Println("error when recording the login ", err)
+			http.Error(w, "ERR_VTFA_110", http.StatusBadRequest)
+			return
+		}
+
+		tokenString, err := services.GenerateJWTToken(structs.Map(&signInResult), login.ID)
+		if err != nil {
+			log.Println("error when generating jwt token ", err)
+			http.Error(w, "ERR_VTFA_111", http.StatusBadRequest)
+			return
+		}
+		setAuthTokens(w, r, &signInResult, tokenString, refreshToken)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(signInResult); err != nil {
+			log.Println("error when encoding auth result: ", err)
+			http.Error(w, "ERR_VTFA_112", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func GetTwoFactorStatus(mux chi.Router, s getTwoFactorStatus) {
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_GTFA_101", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_GTFA_102", http.StatusBadRequest)
+			return
+		}
+
+		twoFactor, err := s.GetTwoFactor(ctx, user.ID)
+		if err != nil {
+			log.Printf("error when getting the two-factor authentication of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_GTFA_103", http.StatusBadRequest)
+			return
+		}
+
+		var status TwoFactorStatus
+		if twoFactor != nil && twoFactor.ConfirmedAt != nil {
+			status.Enabled = true
+			status.ConfirmedAt = twoFactor.ConfirmedAt
+			status.RecoveryCodesLeft, err = s.CountRecoveryCodesLeft(ctx, user.ID)
+			if err != nil {
+				log.Printf("error when counting the recovery codes of user[%d]: %s", user.ID, err)
+				http.Error(w, "ERR_GTFA_104", http.StatusBadRequest)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			log.Println("error when encoding the two-factor status: ", err)
+			http.Error(w, "ERR_GTFA_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func EnrollTwoFactor(mux chi.Router, s enrollTwoFactor) {
+	mux.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_ETFA_101", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_ETFA_102", http.StatusBadRequest)
+			return
+		}
+
+		secret, err := services.GenerateTOTPSecret()
+		if err != nil {
+			log.Println("error when generating the totp secret: ", err)
+			http.Error(w, "ERR_ETFA_103", http.StatusInternalServerError)
+			return
+		}
+		encrypted, err := services.EncryptTOTPSecret(secret)
+		if err != nil {
+			log.Println("error when encrypting the totp secret: ", err)
+			http.Error(w, "ERR_ETFA_103", http.StatusInternalServerError)
+			return
+		}
+
+		twoFactor, err := s.UpsertTwoFactor(ctx, storage.UpsertTwoFactorParams{
+			UserID: user.ID,
+			Secret: encrypted,
+		})
+		if err != nil {
+			log.Printf("error when enrolling user[%d] in the two-factor authentication: %s", user.ID, err)
+			http.Error(w, "ERR_ETFA_104", http.StatusBadRequest)
+			return
+		}
+		if twoFactor == nil {
+			log.Printf("user[%d] already has the two-factor authentication", user.ID)
+			http.Error(w, "ERR_ETFA_105", http.StatusConflict)
+			return
+		}
+
+		enrollment := TwoFactorEnrollment{
+			Secret:          secret,
+			ProvisioningURI: services.TOTPProvisioningURI(secret, user.Phone),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(enrollment); err != nil {
+			log.Println("error when encoding the two-factor enrollment: ", err)
+			http.Error(w, "ERR_ETFA_106", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func ConfirmTwoFactor(mux chi.Router, s confirmTwoFactor) {
+	mux.Post("/confirm", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_CTFA_101", http.StatusUnauthorized)
+			return
+		}
+
+		var input TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the two-factor confirmation: ", err)
+			http.Error(w, "ERR_CTFA_102", http.StatusBadRequest)
+			return
+		}
+
+		key := twoFactorThrottleKey(currentMember.ID)
+		wait, err := signInWaitRemaining(ctx, s, key)
+		if err != nil {
+			log.Println("error when getting the two-factor throttle: ", err)
+			http.Error(w, "ERR_CTFA_103", http.StatusBadRequest)
+			return
+		}
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "ERR_CTFA_104", http.StatusTooManyRequests)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_CTFA_105", http.StatusBadRequest)
+			return
+		}
+		twoFactor, err := s.GetTwoFactor(ctx, user.ID)
+		if err != nil {
+			log.Printf("error when getting the two-factor authentication of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_CTFA_105", http.StatusBadRequest)
+			return
+		}
+		if twoFactor == nil || twoFactor.ConfirmedAt != nil {
+			log.Printf("no pending two-factor enrollment for user[%d]", user.ID)
+			http.Error(w, "ERR_CTFA_106", http.StatusConflict)
+			return
+		}
+
+		secret, err := services.DecryptTOTPSecret(twoFactor.Secret)
+		if err != nil {
+			log.Printf("error when decrypting the totp secret of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_CTFA_107", http.StatusBadRequest)
+			return
+		}
+		step, ok := services.ValidateTOTP(secret, input.Code, time.Now().UTC())
+		if !ok {
+			if _, err := recordSignInFailure(ctx, s, key, helpers.SignInMaxFailures); err != nil {
+				log.Println("error when recording the two-factor failure: ", err)
+			}
+			http.Error(w, "ERR_CTFA_108", http.StatusBadRequest)
+			return
+		}
+
+		codes, hashes, err := newRecoveryCodes()
+		if err != nil {
+			log.Println("error when generating the recovery codes: ", err)
+			http.Error(w, "ERR_CTFA_109", http.StatusInternalServerError)
+			return
+		}
+		err = s.ConfirmTwoFactorTx(ctx, storage.ConfirmTwoFactorParams{
+			UserID:     user.ID,
+			Step:       step,
+			CodeHashes: hashes,
+		})
+		if err != nil {
+			log.Printf("error when confirming the two-factor authentication of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_CTFA_110", http.StatusBadRequest)
+			return
+		}
+
+		// the code has just been entered on this login, its next tokens carry the second factor
+		if info, ok := services.GetTokenInfo(ctx); ok && info.LoginID != 0 {
+			err = s.MarkLoginTwoFactor(ctx, storage.MarkLoginTwoFactorParams{
+				ID:       info.LoginID,
+				MemberID: currentMember.ID,
+			})
+			if err != nil {
+				log.Printf("error when marking login[%d] with the second factor: %s", info.LoginID, err)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(RecoveryCodesResult{RecoveryCodes: codes}); err != nil {
+			log.Println("error when encoding the recovery codes: ", err)
+			http.Error(w, "ERR_CTFA_111", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func RegenerateRecoveryCodes(mux chi.Router, s regenerateRecoveryCodes) {
+	mux.Post("/recovery-codes", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_RTFA_101", http.StatusUnauthorized)
+			return
+		}
+
+		var input TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the recovery codes request: ", err)
+			http.Error(w, "ERR_RTFA_102", http.StatusBadRequest)
+			return
+		}
+
+		wait, err := signInWaitRemaining(ctx, s, twoFactorThrottleKey(currentMember.ID))
+		if err != nil {
+			log.Println("error when getting the two-factor throttle: ", err)
+			http.Error(w, "ERR_RTFA_103", http.StatusBadRequest)
+			return
+		}
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "ERR_RTFA_104", http.StatusTooManyRequests)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_RTFA_105", http.StatusBadRequest)
+			return
+		}
+		twoFactor, err := s.GetTwoFactor(ctx, user.ID)
+		if err != nil || twoFactor == nil || twoFactor.ConfirmedAt == nil {
+			log.Printf("no two-factor authentication for user[%d]: %v", user.ID, err)
+			http.Error(w, "ERR_RTFA_106", http.StatusBadRequest)
+			return
+		}
+
+		// the recovery codes can only be renewed with the authenticator app itself
+		valid, err := checkSecondFactor(ctx, s, currentMember.ID, twoFactor, input.Code, "")
+		if err != nil {
+			log.Printf("error when checking the second factor of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_RTFA_107", http.StatusBadRequest)
+			return
+		}
+		if !valid {
+			http.Error(w, "ERR_RTFA_108", http.StatusBadRequest)
+			return
+		}
+
+		codes, hashes, err := newRecoveryCodes()
+		if err != nil {
+			log.Println("error when generating the recovery codes: ", err)
+			http.Error(w, "ERR_RTFA_109", http.StatusInternalServerError)
+			return
+		}
+		err = s.ReplaceRecoveryCodesTx(ctx, storage.ReplaceRecoveryCodesParams{
+			UserID:     user.ID,
+			CodeHashes: hashes,
+		})
+		if err != nil {
+			log.Printf("error when replacing the recovery codes of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_RTFA_110", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(RecoveryCodesResult{RecoveryCodes: codes}); err != nil {
+			log.Println("error when encoding the recovery codes: ", err)
+			http.Error(w, "ERR_RTFA_111", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func DisableTwoFactor(mux chi.Router, s disableTwoFactor) {
+	mux.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_DTFA_101", http.StatusUnauthorized)
+			return
+		}
+
+		var input TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the two-factor deactivation: ", err)
+			http.Error(w, "ERR_DTFA_102", http.StatusBadRequest)
+			return
+		}
+
+		wait, err := signInWaitRemaining(ctx, s, twoFactorThrottleKey(currentMember.ID))
+		if err != nil {
+			log.Println("error when getting the two-factor throttle: ", err)
+			http.Error(w, "ERR_DTFA_103", http.StatusBadRequest)
+			return
+		}
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "ERR_DTFA_104", http.StatusTooManyRequests)
+			return
+		}
+
+		// an officer can not drop the second factor an organization requires
+		required, err := s.CountTwoFactorRequiredMemberships(ctx, currentMember.ID)
+		if err != nil {
+			log.Printf("error when counting the organizations requiring two factors of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_DTFA_105", http.StatusBadRequest)
+			return
+		}
+		if required > 0 {
+			log.Printf("member[%d] is an officer of organizations requiring two factors", currentMember.ID)
+			http.Error(w, "ERR_DTFA_106", http.StatusConflict)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_DTFA_107", http.StatusBadRequest)
+			return
+		}
+		twoFactor, err := s.GetTwoFactor(ctx, user.ID)
+		if err != nil || twoFactor == nil {
+			log.Printf("no two-factor authentication for user[%d]: %v", user.ID, err)
+			http.Error(w, "ERR_DTFA_108", http.StatusNotFound)
+			return
+		}
+
+		// a pending enrollment goes away without a code
+		if twoFactor.ConfirmedAt != nil {
+			valid, err := checkSecondFactor(ctx, s, currentMember.ID, twoFactor, input.Code, input.RecoveryCode)
+			if err != nil {
+				log.Printf("error when checking the second factor of user[%d]: %s", user.ID, err)
+				http.Error(w, "ERR_DTFA_109", http.StatusBadRequest)
+				return
+			}
+			if !valid {
+				http.Error(w, "ERR_DTFA_110", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := s.DisableTwoFactorTx(ctx, user.ID); err != nil {
+			log.Printf("error when disabling the two-factor authentication of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_DTFA_111", http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func GetTwoFactorPolicy(mux chi.Router, s getTwoFactorPolicy) {
+	mux.Get("/two-factor-policy", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_GTFP_101", http.StatusBadRequest)
+			return
+		}
+
+		required, err := s.IsTwoFactorRequired(ctx, orgID)
+		if err != nil {
+			log.Printf("error when getting the two-factor policy of organization[%d]: %s", orgID, err)
+			http.Error(w, "ERR_GTFP_102", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(TwoFactorPolicy{Required: required}); err != nil {
+			log.Println("error when encoding the two-factor policy: ", err)
+			http.Error(w, "ERR_GTFP_103", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func UpdateTwoFactorPolicy(mux chi.Router, s updateTwoFactorPolicy) {
+	mux.Put("/two-factor-policy", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the organization id: ", err)
+			http.Error(w, "ERR_UTFP_101", http.StatusBadRequest)
+			return
+		}
+
+		var input TwoFactorPolicy
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the two-factor policy: ", err)
+			http.Error(w, "ERR_UTFP_102", http.StatusBadRequest)
+			return
+		}
+
+		// the admin turning the policy on would be locked out without a second factor
+		if info, _ := services.GetTokenInfo(ctx); input.Required && !info.TwoFactor {
+			log.Printf("the admin of organization[%d] has not signed in with a second factor", orgID)
+			http.Error(w, "ERR_UTFP_103", http.StatusForbidden)
+			return
+		}
+
+		err = s.SetTwoFactorRequired(ctx, storage.SetTwoFactorRequiredParams{
+			ID:       orgID,
+			Required: input.Required,
+		})
+		if err != nil {
+			log.Printf("error when updating the two-factor policy of organization[%d]: %s", orgID, err)
+			http.Error(w, "ERR_UTFP_104", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(input); err != nil {
+			log.Println("error when encoding the two-factor policy: ", err)
+			http.Error(w, "ERR_UTFP_105", http.StatusBadRequest)
+			return
+		}
+	})
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 
+	"tschwaa.com/api/helpers"
 	"tschwaa.com/api/models"
 	"tschwaa.com/api/services"
 )
@@ -19,3 +20,25 @@ func GetCurrentMember(req *http.Request) *models.Member {
 
 	return nil
 }
+
+// GetCurrentMembership returns the membership of the current member in the organization of the route
+func GetCurrentMembership(req *http.Request) *models.Membership {
+	membership, _ := req.Context().Value(services.JWTMembershipKey).(*models.Membership)
+	return membership
+}
+
+// GetCurrentAPIKey returns the API key the request has been authenticated with, if any
+func GetCurrentAPIKey(req *http.Request) *models.APIKey {
+	apiKey, _ := req.Context().Value(services.JWTAPIKeyKey).(*models.APIKey)
+	return apiKey
+}
+
+// clientIP returns the address of the client, as forwarded by the proxy when it is a trusted one
+func clientIP(req *http.Request) string {
+	return helpers.ClientIP(
+		req.RemoteAddr,
+		req.Header.Get("X-Forwarded-For"),
+		req.Header.Get("X-Real-Ip"),
+		helpers.TrustedProxies,
+	)
+}
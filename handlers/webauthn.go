@@ -0,0 +1,699 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/go-chi/chi/v5"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/services"
+	"tschwaa.com/api/storage"
+)
+
+type beginPasskeyRegistration interface {
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	ListWebAuthnCredentialsOfUser(ctx context.Context, userID uint64) ([]*models.WebAuthnCredential, error)
+	CreateWebAuthnChallengeTx(ctx context.Context, arg storage.CreateWebAuthnChallengeParams) (*models.WebAuthnChallenge, error)
+}
+
+type finishPasskeyRegistration interface {
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	ConsumeWebAuthnChallenge(ctx context.Context, arg storage.ConsumeWebAuthnChallengeParams) (*models.WebAuthnChallenge, error)
+	GetWebAuthnCredential(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error)
+	CreateWebAuthnCredential(ctx context.Context, arg storage.CreateWebAuthnCredentialParams) (*models.WebAuthnCredential, error)
+}
+
+type beginPasskeyLogin interface {
+	CreateWebAuthnChallengeTx(ctx context.Context, arg storage.CreateWebAuthnChallengeParams) (*models.WebAuthnChallenge, error)
+}
+
+type finishPasskeyLogin interface {
+	GetWebAuthnCredential(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error)
+	ConsumeWebAuthnChallenge(ctx context.Context, arg storage.ConsumeWebAuthnChallengeParams) (*models.WebAuthnChallenge, error)
+	UseWebAuthnCredential(ctx context.Context, arg storage.UseWebAuthnCredentialParams) (bool, error)
+	GetUserByID(ctx context.Context, id uint64) (*models.User, error)
+	GetMemberByID(ctx context.Context, id uint64) (*models.Member, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	CreateLoginTx(ctx context.Context, arg storage.CreateLoginTxParams) (*models.Login, error)
+}
+
+type listPasskeys interface {
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	ListWebAuthnCredentialsOfUser(ctx context.Context, userID uint64) ([]*models.WebAuthnCredential, error)
+}
+
+type renamePasskey interface {
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	RenameWebAuthnCredential(ctx context.Context, arg storage.RenameWebAuthnCredentialParams) (*models.WebAuthnCredential, error)
+}
+
+type deletePasskey interface {
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	DeleteWebAuthnCredential(ctx context.Context, arg storage.DeleteWebAuthnCredentialParams) (*models.WebAuthnCredential, error)
+}
+
+// The options and responses of the ceremonies follow the JSON encoding of the WebAuthn
+// API, where the binary fields are base64url strings
+
+type PasskeyRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type PasskeyUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type PasskeyCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type PasskeyCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type PasskeyCreationOptions struct {
+	Challenge              string                        `json:"challenge"`
+	RP                     PasskeyRelyingParty           `json:"rp"`
+	User                   PasskeyUser                   `json:"user"`
+	PubKeyCredParams       []PasskeyCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                         `json:"timeout"`
+	ExcludeCredentials     []PasskeyCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                        `json:"attestation"`
+}
+
+type PasskeyRequestOptions struct {
+	Challenge        string                        `json:"challenge"`
+	RPID             string                        `json:"rpId"`
+	Timeout          int64                         `json:"timeout"`
+	AllowCredentials []PasskeyCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                        `json:"userVerification"`
+}
+
+type PasskeyRegistrationRequest struct {
+	Name     string `json:"name,omitempty"`
+	ID       string `json:"id"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AttestationObject string `json:"attestationObject"`
+	} `json:"response"`
+}
+
+type PasskeyLoginRequest struct {
+	ID       string `json:"id"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+type RenamePasskeyRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+// passkeyUserHandle is the id of the user stored by the authenticators alongside the passkey
+func passkeyUserHandle(userID uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(userID, 10)))
+}
+
+// passkeyNeedsSecondFactor tells whether the member has to have passed its authenticator app
+// on the current login to add a passkey, which stands in for the app once added
+func passkeyNeedsSecondFactor(ctx context.Context, s twoFactorGetter, userID uint64) (bool, error) {
+	enrolled, err := hasTwoFactor(ctx, s, userID)
+	if err != nil {
+		return false, err
+	}
+	info, _ := services.GetTokenInfo(ctx)
+
+	return enrolled && !info.TwoFactor, nil
+}
+
+// decodeBase64URL accepts the base64url strings with or without padding
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func BeginPasskeyRegistration(mux chi.Router, s beginPasskeyRegistration) {
+	mux.Post("/webauthn/register/begin", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_BPRG_101", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_BPRG_102", http.StatusBadRequest)
+			return
+		}
+
+		needed, err := passkeyNeedsSecondFactor(ctx, s, user.ID)
+		if err != nil {
+			log.Printf("error when getting the two-factor authentication of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_BPRG_107", http.StatusBadRequest)
+			return
+		}
+		if needed {
+			log.Printf("member[%d] has to sign in with its second factor to add a passkey", currentMember.ID)
+			http.Error(w, "ERR_BPRG_108", http.StatusForbidden)
+			return
+		}
+
+		credentials, err := s.ListWebAuthnCredentialsOfUser(ctx, user.ID)
+		if err != nil {
+			log.Printf("error when listing the passkeys of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_BPRG_103", http.StatusBadRequest)
+			return
+		}
+
+		challenge, err := services.NewWebAuthnChallenge()
+		if err != nil {
+			log.Println("error when generating the webauthn challenge: ", err)
+			http.Error(w, "ERR_BPRG_104", http.StatusInternalServerError)
+			return
+		}
+		_, err = s.CreateWebAuthnChallengeTx(ctx, storage.CreateWebAuthnChallengeParams{
+			Challenge: challenge,
+			Ceremony:  common.WEBAUTHN_CEREMONY_REGISTRATION,
+			UserID:    &user.ID,
+			ExpiresAt: time.Now().UTC().Add(services.WebAuthnChallengeTTL),
+		})
+		if err != nil {
+			log.Printf("error when creating the registration challenge of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_BPRG_105", http.StatusBadRequest)
+			return
+		}
+
+		// the authenticators refuse to register a second passkey of the same user
+		excluded := []PasskeyCredentialDescriptor{}
+		for _, credential := range credentials {
+			excluded = append(excluded, PasskeyCredentialDescriptor{Type: "public-key", ID: credential.CredentialID})
+		}
+
+		options := PasskeyCreationOptions{
+			Challenge: challenge,
+			RP:        PasskeyRelyingParty{ID: services.WebAuthnRPID, Name: services.WebAuthnRPName},
+			User: PasskeyUser{
+				ID:          passkeyUserHandle(user.ID),
+				Name:        user.Phone,
+				DisplayName: fmt.Sprintf("%s %s", currentMember.FirstName, currentMember.LastName),
+			},
+			PubKeyCredParams: []PasskeyCredentialParameter{
+				{Type: "public-key", Alg: services.COSEAlgES256},
+				{Type: "public-key", Alg: services.COSEAlgEdDSA},
+				{Type: "public-key", Alg: services.COSEAlgRS256},
+			},
+			Timeout:            services.WebAuthnChallengeTTL.Milliseconds(),
+			ExcludeCredentials: excluded,
+			AuthenticatorSelection: PasskeyAuthenticatorSelection{
+				ResidentKey:      "required",
+				UserVerification: "preferred",
+			},
+			Attestation: "none",
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(options); err != nil {
+			log.Println("error when encoding the passkey creation options: ", err)
+			http.Error(w, "ERR_BPRG_106", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func FinishPasskeyRegistration(mux chi.Router, s finishPasskeyRegistration) {
+	mux.Post("/webauthn/register/finish", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_FPRG_101", http.StatusUnauthorized)
+			return
+		}
+
+		var input PasskeyRegistrationRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the passkey registration: ", err)
+			http.Error(w, "ERR_FPRG_102", http.StatusBadRequest)
+			return
+		}
+		clientDataJSON, err := decodeBase64URL(input.Response.ClientDataJSON)
+		if err != nil {
+			http.Error(w, "ERR_FPRG_103", http.StatusBadRequest)
+			return
+		}
+		attestationObject, err := decodeBase64URL(input.Response.AttestationObject)
+		if err != nil {
+			http.Error(w, "ERR_FPRG_103", http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_FPRG_104", http.StatusBadRequest)
+			return
+		}
+
+		needed, err := passkeyNeedsSecondFactor(ctx, s, user.ID)
+		if err != nil {
+			log.Printf("error when getting the two-factor authentication of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_FPRG_112", http.StatusBadRequest)
+			return
+		}
+		if needed {
+			log.Printf("member[%d] has to sign in with its second factor to add a passkey", currentMember.ID)
+			http.Error(w, "ERR_FPRG_113", http.StatusForbidden)
+			return
+		}
+
+		challenge, err := services.WebAuthnChallengeOf(clientDataJSON)
+		if err != nil {
+			log.Println("error when reading the client data: ", err)
+			http.Error(w, "ERR_FPRG_103", http.StatusBadRequest)
+			return
+		}
+		pending, err := s.ConsumeWebAuthnChallenge(ctx, storage.ConsumeWebAuthnChallengeParams{
+			Challenge: challenge,
+			Ceremony:  common.WEBAUTHN_CEREMONY_REGISTRATION,
+		})
+		if err != nil {
+			log.Println("error when consuming the registration challenge: ", err)
+			http.Error(w, "ERR_FPRG_105", http.StatusBadRequest)
+			return
+		}
+		if pending == nil || pending.UserID == nil || *pending.UserID != user.ID {
+			log.Printf("no pending passkey registration for user[%d]", user.ID)
+			http.Error(w, "ERR_FPRG_106", http.StatusBadRequest)
+			return
+		}
+
+		credential, err := services.VerifyWebAuthnRegistration(challenge, clientDataJSON, attestationObject)
+		if err != nil {
+			log.Printf("error when verifying the passkey of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_FPRG_107", http.StatusBadRequest)
+			return
+		}
+
+		credentialID := base64.RawURLEncoding.EncodeToString(credential.ID)
+		existing, err := s.GetWebAuthnCredential(ctx, credentialID)
+		if err != nil {
+			log.Println("error when looking for the passkey: ", err)
+			http.Error(w, "ERR_FPRG_108", http.StatusBadRequest)
+			return
+		}
+		if existing != nil {
+			log.Printf("the passkey of user[%d] is already registered", user.ID)
+			http.Error(w, "ERR_FPRG_109", http.StatusConflict)
+			return
+		}
+
+		name := strings.TrimSpace(input.Name)
+		if name == "" {
+			name = "Passkey"
+		}
+		passkey, err := s.CreateWebAuthnCredential(ctx, storage.CreateWebAuthnCredentialParams{
+			UserID:       user.ID,
+			CredentialID: credentialID,
+			PublicKey:    credential.PublicKey,
+			Algorithm:    credential.Algorithm,
+			SignCount:    credential.SignCount,
+			Name:         name,
+		})
+		if err != nil {
+			log.Printf("error when saving the passkey of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_FPRG_110", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(passkey); err != nil {
+			log.Println("error when encoding the passkey: ", err)
+			http.Error(w, "ERR_FPRG_111", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func BeginPasskeyLogin(mux chi.Router, s beginPasskeyLogin) {
+	mux.Post("/webauthn/login/begin", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		challenge, err := services.NewWebAuthnChallenge()
+		if err != nil {
+			log.Println("error when generating the webauthn challenge: ", err)
+			http.Error(w, "ERR_BPLG_101", http.StatusInternalServerError)
+			return
+		}
+		_, err = s.CreateWebAuthnChallengeTx(ctx, storage.CreateWebAuthnChallengeParams{
+			Challenge: challenge,
+			Ceremony:  common.WEBAUTHN_CEREMONY_LOGIN,
+			ExpiresAt: time.Now().UTC().Add(services.WebAuthnChallengeTTL),
+		})
+		if err != nil {
+			log.Println("error when creating the login challenge: ", err)
+			http.Error(w, "ERR_BPLG_102", http.StatusBadRequest)
+			return
+		}
+
+		// the passkeys are discoverable, the authenticator offers the ones it has for the app
+		options := PasskeyRequestOptions{
+			Challenge:        challenge,
+			RPID:             services.WebAuthnRPID,
+			Timeout:          services.WebAuthnChallengeTTL.Milliseconds(),
+			AllowCredentials: []PasskeyCredentialDescriptor{},
+			UserVerification: "preferred",
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(options); err != nil {
+			log.Println("error when encoding the passkey request options: ", err)
+			http.Error(w, "ERR_BPLG_103", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func FinishPasskeyLogin(mux chi.Router, s finishPasskeyLogin) {
+	mux.Post("/webauthn/login/finish", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var input PasskeyLoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the passkey login: ", err)
+			http.Error(w, "ERR_FPLG_101", http.StatusBadRequest)
+			return
+		}
+		clientDataJSON, err := decodeBase64URL(input.Response.ClientDataJSON)
+		if err != nil {
+			http.Error(w, "ERR_FPLG_102", http.StatusBadRequest)
+			return
+		}
+		authenticatorData, err := decodeBase64URL(input.Response.AuthenticatorData)
+		if err != nil {
+			http.Error(w, "ERR_FPLG_102", http.StatusBadRequest)
+			return
+		}
+		signature, err := decodeBase64URL(input.Response.Signature)
+		if err != nil {
+			http.Error(w, "ERR_FPLG_102", http.StatusBadRequest)
+			return
+		}
+
+		passkey, err := s.GetWebAuthnCredential(ctx, strings.TrimRight(input.ID, "="))
+		if err != nil {
+			log.Println("error when getting the passkey: ", err)
+			http.Error(w, "ERR_FPLG_103", http.StatusBadRequest)
+			return
+		}
+		if passkey == nil {
+			log.Println("unknown passkey: ", input.ID)
+			http.Error(w, "ERR_FPLG_104", http.StatusBadRequest)
+			return
+		}
+		if input.Response.UserHandle != "" && strings.TrimRight(input.Response.UserHandle, "=") != passkeyUserHandle(passkey.UserID) {
+			log.Printf("the user handle does not match the user of passkey[%d]", passkey.ID)
+			http.Error(w, "ERR_FPLG_104", http.StatusBadRequest)
+			return
+		}
+
+		challenge, err := services.WebAuthnChallengeOf(clientDataJSON)
+		if err != nil {
+			log.Println("error when reading the client data: ", err)
+			http.Error(w, "ERR_FPLG_102", http.StatusBadRequest)
+			return
+		}
+		pending, err := s.ConsumeWebAuthnChallenge(ctx, storage.ConsumeWebAuthnChallengeParams{
+			Challenge: challenge,
+			Ceremony:  common.WEBAUTHN_CEREMONY_LOGIN,
+		})
+		if err != nil {
+			log.Println("error when consuming the login challenge: ", err)
+			http.Error(w, "ERR_FPLG_105", http.StatusBadRequest)
+			return
+		}
+		if pending == nil {
+			log.Println("the login challenge is unknown, used or expired")
+			http.Error(w, "ERR_FPLG_106", http.StatusBadRequest)
+			return
+		}
+
+		assertion, err := services.VerifyWebAuthnAssertion(challenge, passkey.PublicKey, passkey.SignCount, clientDataJSON, authenticatorData, signature)
+		if err != nil {
+			log.Printf("error when verifying the assertion of passkey[%d]: %s", passkey.ID, err)
+			if err == services.ErrWebAuthnSignCount {
+				http.Error(w, "ERR_FPLG_107", http.StatusForbidden)
+			} else {
+				http.Error(w, "ERR_FPLG_108", http.StatusBadRequest)
+			}
+			return
+		}
+
+		used, err := s.UseWebAuthnCredential(ctx, storage.UseWebAuthnCredentialParams{
+			ID:                passkey.ID,
+			PreviousSignCount: passkey.SignCount,
+			SignCount:         assertion.SignCount,
+		})
+		if err != nil || !used {
+			log.Printf("error when updating the sign counter of passkey[%d]: %v", passkey.ID, err)
+			http.Error(w, "ERR_FPLG_107", http.StatusForbidden)
+			return
+		}
+
+		user, err := s.GetUserByID(ctx, passkey.UserID)
+		if err != nil || user == nil {
+			log.Printf("error when getting user[%d]: %s", passkey.UserID, err)
+			http.Error(w, "ERR_FPLG_109", http.StatusBadRequest)
+			return
+		}
+		member, err := s.GetMemberByID(ctx, user.MemberID)
+		if err != nil || member == nil {
+			log.Printf("error when getting member[%d]: %s", user.MemberID, err)
+			http.Error(w, "ERR_FPLG_109", http.StatusBadRequest)
+			return
+		}
+
+		// once a member has an authenticator app, a passkey can only be added on a login which passed
+		// it, so a verified passkey stands in for the app. Without user verification the app is asked for.
+		enrolled, err := hasTwoFactor(ctx, s, user.ID)
+		if err != nil {
+			log.Printf("error when getting the two-factor authentication of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_FPLG_113", http.StatusBadRequest)
+			return
+		}
+		if enrolled && !assertion.UserVerified {
+			if err := writeTwoFactorChallenge(w, member.ID); err != nil {
+				log.Println("error when encoding the two-factor challenge: ", err)
+				http.Error(w, "ERR_FPLG_114", http.StatusBadRequest)
+			}
+			return
+		}
+		twoFactor := enrolled && assertion.UserVerified
+
+		var signInResult SignInResult
+		signInResult.Name = fmt.Sprintf("%s %s", member.FirstName, member.LastName)
+		signInResult.Email = member.Email
+		signInResult.Phone = member.Phone
+		signInResult.ID = member.ID
+		signInResult.EmailVerified = user.EmailVerifiedAt != nil
+		signInResult.TwoFactor = twoFactor
+
+		channel := common.CLIENT_TYPE_WEB
+		if services.UsesBearerToken(r) {
+			channel = common.CLIENT_TYPE_MOBILE
+		}
+		login, refreshToken, err := openLogin(ctx, s, r, member.ID, channel, twoFactor)
+		if err != nil {
+			log.Println("error when recording the login ", err)
+			http.Error(w, "ERR_FPLG_110", http.StatusBadRequest)
+			return
+		}
+
+		tokenString, err := services.GenerateJWTToken(structs.Map(signInResult), login.ID)
+		if err != nil {
+			log.Println("error when generating jwt token ", err)
+			http.Error(w, "ERR_FPLG_111", http.StatusBadRequest)
+			return
+		}
+		setAuthTokens(w, r, &signInResult, tokenString, refreshToken)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(signInResult); err != nil {
+			log.Println("error when encoding auth result: ", err)
+			http.Error(w, "ERR_FPLG_112", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func ListPasskeys(mux chi.Router, s listPasskeys) {
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_LPSK_101", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_LPSK_102", http.StatusBadRequest)
+			return
+		}
+
+		passkeys, err := s.ListWebAuthnCredentialsOfUser(ctx, user.ID)
+		if err != nil {
+			log.Printf("error when listing the passkeys of user[%d]: %s", user.ID, err)
+			http.Error(w, "ERR_LPSK_103", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(passkeys); err != nil {
+			log.Println("error when encoding the passkeys: ", err)
+			http.Error(w, "ERR_LPSK_104", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func RenamePasskey(mux chi.Router, s renamePasskey) {
+	mux.Patch("/{passkeyID}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_UPSK_101", http.StatusUnauthorized)
+			return
+		}
+
+		passkeyID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "passkeyID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the passkey id: ", err)
+			http.Error(w, "ERR_UPSK_102", http.StatusBadRequest)
+			return
+		}
+
+		var input RenamePasskeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			log.Println("error when decoding the passkey name: ", err)
+			http.Error(w, "ERR_UPSK_103", http.StatusBadRequest)
+			return
+		}
+		input.Name = strings.TrimSpace(input.Name)
+		if input.Name == "" {
+			http.Error(w, "ERR_UPSK_104", http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_UPSK_105", http.StatusBadRequest)
+			return
+		}
+
+		passkey, err := s.RenameWebAuthnCredential(ctx, storage.RenameWebAuthnCredentialParams{
+			ID:     passkeyID,
+			UserID: user.ID,
+			Name:   input.Name,
+		})
+		if err != nil {
+			log.Printf("error when renaming passkey[%d] of user[%d]: %s", passkeyID, user.ID, err)
+			http.Error(w, "ERR_UPSK_106", http.StatusBadRequest)
+			return
+		}
+		if passkey == nil {
+			http.Error(w, "ERR_UPSK_107", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(passkey); err != nil {
+			log.Println("error when encoding the passkey: ", err)
+			http.Error(w, "ERR_UPSK_108", http.StatusBadRequest)
+			return
+		}
+	})
+}
+
+func DeletePasskey(mux chi.Router, s deletePasskey) {
+	mux.Delete("/{passkeyID}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		currentMember := GetCurrentMember(r)
+		if currentMember == nil {
+			log.Println("no current member")
+			http.Error(w, "ERR_DPSK_101", http.StatusUnauthorized)
+			return
+		}
+
+		passkeyID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "passkeyID"), 10, 64)
+		if err != nil {
+			log.Println("error when parsing the passkey id: ", err)
+			http.Error(w, "ERR_DPSK_102", http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.GetUserByMemberID(ctx, currentMember.ID)
+		if err != nil || user == nil {
+			log.Printf("error when getting the user of member[%d]: %s", currentMember.ID, err)
+			http.Error(w, "ERR_DPSK_103", http.StatusBadRequest)
+			return
+		}
+
+		passkey, err := s.DeleteWebAuthnCredential(ctx, storage.DeleteWebAuthnCredentialParams{
+			ID:     passkeyID,
+			UserID: user.ID,
+		})
+		if err != nil {
+			log.Printf("error when deleting passkey[%d] of user[%d]: %s", passkeyID, user.ID, err)
+			http.Error(w, "ERR_DPSK_104", http.StatusBadRequest)
+			return
+		}
+		if passkey == nil {
+			http.Error(w, "ERR_DPSK_105", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
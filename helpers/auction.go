@@ -0,0 +1,70 @@
+package helpers
+
+import "tschwaa.com/api/models"
+
+// AuctionWinner returns the winning bid among the bids, in the order they were placed.
+// Only the latest bid of each member counts; the highest discount wins and, on a tie,
+// the member who bid it first.
+func AuctionWinner(bids []*models.AuctionBid) *models.AuctionBid {
+	latest := map[uint64]*models.AuctionBid{}
+	for _, bid := range bids {
+		latest[bid.MembershipID] = bid
+	}
+
+	var winner *models.AuctionBid
+	for _, bid := range bids {
+		if latest[bid.MembershipID] != bid {
+			continue
+		}
+		if winner == nil || bid.Discount > winner.Discount {
+			winner = bid
+		}
+	}
+
+	return winner
+}
+
+// SplitAuctionDiscount shares the discount among count members as evenly as it can,
+// the first members getting one more unit each when it does not divide
+func SplitAuctionDiscount(discount int64, count int) []int64 {
+	if count <= 0 {
+		return []int64{}
+	}
+
+	shares := make([]int64, count)
+	share, rest := discount/int64(count), discount%int64(count)
+	for i := range shares {
+		shares[i] = share
+		if int64(i) < rest {
+			shares[i]++
+		}
+	}
+
+	return shares
+}
+
+// AuctionDividends shares the discount of the winner by hand among the members of the session,
+// the winner keeping a share for each of their other hands. When the winner holds the only hand,
+// there is nobody to share with and the discount goes back to the winner.
+func AuctionDividends(members []*models.MembersOfSession, winnerMembershipID uint64, discount int64) map[uint64]int64 {
+	hands := []uint64{}
+	for _, member := range members {
+		shares := member.Shares
+		if member.MembershipID == winnerMembershipID {
+			shares--
+		}
+		for i := 0; i < shares; i++ {
+			hands = append(hands, member.MembershipID)
+		}
+	}
+	if len(hands) == 0 {
+		return map[uint64]int64{winnerMembershipID: discount}
+	}
+
+	dividends := map[uint64]int64{}
+	for i, share := range SplitAuctionDiscount(discount, len(hands)) {
+		dividends[hands[i]] += share
+	}
+
+	return dividends
+}
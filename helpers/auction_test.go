@@ -0,0 +1,71 @@
+package helpers_test
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+)
+
+func TestAuctionWinner(t *testing.T) {
+	t.Run("has no winner without bids", func(t *testing.T) {
+		is := is.New(t)
+		is.True(helpers.AuctionWinner(nil) == nil)
+	})
+
+	t.Run("takes the highest discount, the first one on a tie", func(t *testing.T) {
+		is := is.New(t)
+		bids := []*models.AuctionBid{
+			{ID: 1, MembershipID: 11, Discount: 5000},
+			{ID: 2, MembershipID: 12, Discount: 8000},
+			{ID: 3, MembershipID: 13, Discount: 8000},
+		}
+		is.Equal(helpers.AuctionWinner(bids).ID, uint64(2))
+	})
+
+	t.Run("only counts the latest bid of a member", func(t *testing.T) {
+		is := is.New(t)
+		bids := []*models.AuctionBid{
+			{ID: 1, MembershipID: 11, Discount: 9000},
+			{ID: 2, MembershipID: 12, Discount: 6000},
+			{ID: 3, MembershipID: 11, Discount: 4000},
+		}
+		is.Equal(helpers.AuctionWinner(bids).ID, uint64(2))
+	})
+}
+
+func TestSplitAuctionDiscount(t *testing.T) {
+	t.Run("shares the discount evenly", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.SplitAuctionDiscount(9000, 3), []int64{3000, 3000, 3000})
+	})
+
+	t.Run("hands the remainder out one unit at a time", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.SplitAuctionDiscount(10, 4), []int64{3, 3, 2, 2})
+	})
+
+	t.Run("has nobody to share with", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.SplitAuctionDiscount(10, 0), []int64{})
+	})
+}
+
+func TestAuctionDividends(t *testing.T) {
+	t.Run("shares the discount by hand, the winner keeping their other hands", func(t *testing.T) {
+		is := is.New(t)
+		members := []*models.MembersOfSession{
+			{MembershipID: 11, Shares: 2},
+			{MembershipID: 12, Shares: 1},
+			{MembershipID: 13, Shares: 2},
+		}
+		is.Equal(helpers.AuctionDividends(members, 11, 8000), map[uint64]int64{11: 2000, 12: 2000, 13: 4000})
+	})
+
+	t.Run("gives the discount back to a winner holding the only hand", func(t *testing.T) {
+		is := is.New(t)
+		members := []*models.MembersOfSession{{MembershipID: 11, Shares: 1}}
+		is.Equal(helpers.AuctionDividends(members, 11, 8000), map[uint64]int64{11: 8000})
+	})
+}
@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"time"
+
+	"tschwaa.com/api/common"
+)
+
+// ContributionDueCount returns how many times a contribution of the frequency has been due
+// between the start of the session and asOf, the first one being due on the first day.
+// Nothing is due after the end of the session.
+func ContributionDueCount(frequency string, start, end, asOf time.Time) int {
+	if end.Before(asOf) {
+		asOf = end
+	}
+	if asOf.Before(start) {
+		return 0
+	}
+
+	switch frequency {
+	case common.CONTRIBUTION_FREQUENCY_ONCE:
+		return 1
+	case common.CONTRIBUTION_FREQUENCY_WEEKLY:
+		return int(asOf.Sub(start)/(7*24*time.Hour)) + 1
+	case common.CONTRIBUTION_FREQUENCY_BIWEEKLY:
+		return int(asOf.Sub(start)/(14*24*time.Hour)) + 1
+	case common.CONTRIBUTION_FREQUENCY_MONTHLY:
+		count := 0
+		for due := start; !due.After(asOf); due = monthlyDueDate(start, count) {
+			count++
+		}
+		return count
+	}
+
+	return 0
+}
+
+// monthlyDueDate returns the due date months after start, on the same day of the month
+// or on the last day of shorter months, like the monthly meetings
+func monthlyDueDate(start time.Time, months int) time.Time {
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(months), 1,
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	day := start.Day()
+	if last := daysInMonth(firstOfMonth); day > last {
+		day = last
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,47 @@
+package helpers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/helpers"
+)
+
+func TestContributionDueCount(t *testing.T) {
+	start := time.Date(2023, time.January, 8, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("is due on the first day of the session", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_WEEKLY, start, end, start), 1)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_MONTHLY, start, end, start), 1)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_WEEKLY, start, end, start.AddDate(0, 0, -1)), 0)
+	})
+
+	t.Run("counts the due dates up to now", func(t *testing.T) {
+		is := is.New(t)
+		asOf := start.AddDate(0, 0, 29)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_WEEKLY, start, end, asOf), 5)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_BIWEEKLY, start, end, asOf), 3)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_MONTHLY, start, end, asOf), 1)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_MONTHLY, start, end, start.AddDate(0, 1, 0)), 2)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_ONCE, start, end, asOf), 1)
+	})
+
+	t.Run("stops at the end of the session", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_MONTHLY, start, end, end.AddDate(1, 0, 0)), 12)
+	})
+
+	t.Run("is due at the end of the shorter months", func(t *testing.T) {
+		is := is.New(t)
+		start := time.Date(2023, time.January, 31, 0, 0, 0, 0, time.UTC)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_MONTHLY, start, end, time.Date(2023, time.February, 27, 0, 0, 0, 0, time.UTC)), 1)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_MONTHLY, start, end, time.Date(2023, time.February, 28, 0, 0, 0, 0, time.UTC)), 2)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_MONTHLY, start, end, time.Date(2023, time.March, 30, 0, 0, 0, 0, time.UTC)), 2)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_MONTHLY, start, end, time.Date(2023, time.April, 30, 0, 0, 0, 0, time.UTC)), 4)
+		is.Equal(helpers.ContributionDueCount(common.CONTRIBUTION_FREQUENCY_MONTHLY, start, end, end), 12)
+	})
+}
@@ -0,0 +1,90 @@
+package helpers
+
+import (
+	"log"
+	"net"
+	"os"
+	"strings"
+)
+
+// TrustedProxies are the networks of the proxies whose forwarded headers are believed,
+// from the comma separated addresses and CIDRs of TRUSTED_PROXIES. Without any, the address
+// of the client is the one of the connection.
+var TrustedProxies []*net.IPNet
+
+func init() {
+	TrustedProxies = parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+}
+
+func parseTrustedProxies(value string) []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("ignoring the invalid trusted proxy %q: %s", entry, err)
+			continue
+		}
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+func isTrustedProxy(ip string, trusted []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP returns the address of the client of a connection from remoteAddr. The forwarded
+// headers are only read when the connection comes from a trusted proxy, and X-Forwarded-For is
+// read from the right, skipping the trusted proxies, since the client can write anything on its left.
+func ClientIP(remoteAddr, forwardedFor, realIP string, trusted []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		ip = remoteAddr
+	}
+	if !isTrustedProxy(ip, trusted) {
+		return ip
+	}
+
+	if forwardedFor != "" {
+		hops := strings.Split(forwardedFor, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				continue
+			}
+			ip = hop
+			if !isTrustedProxy(hop, trusted) {
+				return hop
+			}
+		}
+		return ip
+	}
+	if realIP = strings.TrimSpace(realIP); realIP != "" {
+		return realIP
+	}
+
+	return ip
+}
@@ -0,0 +1,30 @@
+package helpers_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/helpers"
+)
+
+func TestClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	trusted := []*net.IPNet{proxies}
+
+	t.Run("ignores the forwarded headers without a trusted proxy", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.ClientIP("203.0.113.7:5000", "198.51.100.1", "198.51.100.2", nil), "203.0.113.7")
+		is.Equal(helpers.ClientIP("203.0.113.7:5000", "198.51.100.1", "", trusted), "203.0.113.7")
+	})
+
+	t.Run("reads the first untrusted hop from the right behind a trusted proxy", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.ClientIP("10.0.0.2:5000", "1.2.3.4, 203.0.113.7, 10.0.0.3", "", trusted), "203.0.113.7")
+	})
+
+	t.Run("falls back on X-Real-Ip behind a trusted proxy", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.ClientIP("10.0.0.2:5000", "", "203.0.113.7", trusted), "203.0.113.7")
+	})
+}
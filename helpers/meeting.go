@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"time"
+
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/models"
+)
+
+// MeetingDates lists the dates of the meetings the recurrence sets between the start and
+// the end of the session, both included
+func MeetingDates(recurrence *models.MeetingRecurrence, start, end time.Time) []time.Time {
+	dates := []time.Time{}
+	if end.Before(start) {
+		return dates
+	}
+
+	weekday := start.Weekday()
+	if recurrence.Weekday != nil {
+		weekday = time.Weekday(*recurrence.Weekday)
+	}
+
+	switch recurrence.Frequency {
+	case common.MEETING_FREQUENCY_WEEKLY, common.MEETING_FREQUENCY_BIWEEKLY:
+		step := 7
+		if recurrence.Frequency == common.MEETING_FREQUENCY_BIWEEKLY {
+			step = 14
+		}
+		first := start.AddDate(0, 0, (int(weekday)-int(start.Weekday())+7)%7)
+		for date := first; !date.After(end); date = date.AddDate(0, 0, step) {
+			dates = append(dates, date)
+		}
+	case common.MEETING_FREQUENCY_MONTHLY:
+		for month := 0; ; month++ {
+			firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(month), 1, 0, 0, 0, 0, start.Location())
+			if firstOfMonth.After(end) {
+				break
+			}
+
+			var date time.Time
+			if recurrence.WeekOfMonth != nil {
+				date = nthWeekdayOfMonth(firstOfMonth, weekday, *recurrence.WeekOfMonth)
+			} else {
+				day := start.Day()
+				if last := daysInMonth(firstOfMonth); day > last {
+					day = last
+				}
+				date = firstOfMonth.AddDate(0, 0, day-1)
+			}
+
+			if !date.Before(start) && !date.After(end) {
+				dates = append(dates, date)
+			}
+		}
+	}
+
+	return dates
+}
+
+// nthWeekdayOfMonth returns the nth weekday of the month, the last one when n is -1
+func nthWeekdayOfMonth(firstOfMonth time.Time, weekday time.Weekday, n int) time.Time {
+	first := firstOfMonth.AddDate(0, 0, (int(weekday)-int(firstOfMonth.Weekday())+7)%7)
+	if n > 0 {
+		return first.AddDate(0, 0, 7*(n-1))
+	}
+
+	last := first
+	for next := first.AddDate(0, 0, 7); next.Month() == firstOfMonth.Month(); next = next.AddDate(0, 0, 7) {
+		last = next
+	}
+	return last
+}
+
+func daysInMonth(firstOfMonth time.Time) int {
+	return firstOfMonth.AddDate(0, 1, -1).Day()
+}
@@ -0,0 +1,94 @@
+package helpers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMeetingDates(t *testing.T) {
+	// a session from Wednesday the 4th of January to the 30th of April 2023
+	start := date(2023, time.January, 4)
+	end := date(2023, time.April, 30)
+	sunday := int(time.Sunday)
+
+	t.Run("meets every week on the weekday", func(t *testing.T) {
+		is := is.New(t)
+		dates := helpers.MeetingDates(&models.MeetingRecurrence{
+			Frequency: common.MEETING_FREQUENCY_WEEKLY,
+			Weekday:   &sunday,
+		}, start, date(2023, time.January, 31))
+		is.Equal(dates, []time.Time{
+			date(2023, time.January, 8),
+			date(2023, time.January, 15),
+			date(2023, time.January, 22),
+			date(2023, time.January, 29),
+		})
+	})
+
+	t.Run("meets every two weeks from the start of the session", func(t *testing.T) {
+		is := is.New(t)
+		dates := helpers.MeetingDates(&models.MeetingRecurrence{
+			Frequency: common.MEETING_FREQUENCY_BIWEEKLY,
+		}, start, date(2023, time.February, 15))
+		is.Equal(dates, []time.Time{
+			date(2023, time.January, 4),
+			date(2023, time.January, 18),
+			date(2023, time.February, 1),
+			date(2023, time.February, 15),
+		})
+	})
+
+	t.Run("meets every second Sunday of the month", func(t *testing.T) {
+		is := is.New(t)
+		second := 2
+		dates := helpers.MeetingDates(&models.MeetingRecurrence{
+			Frequency:   common.MEETING_FREQUENCY_MONTHLY,
+			Weekday:     &sunday,
+			WeekOfMonth: &second,
+		}, start, end)
+		is.Equal(dates, []time.Time{
+			date(2023, time.January, 8),
+			date(2023, time.February, 12),
+			date(2023, time.March, 12),
+			date(2023, time.April, 9),
+		})
+	})
+
+	t.Run("meets the last Sunday of the month", func(t *testing.T) {
+		is := is.New(t)
+		last := -1
+		dates := helpers.MeetingDates(&models.MeetingRecurrence{
+			Frequency:   common.MEETING_FREQUENCY_MONTHLY,
+			Weekday:     &sunday,
+			WeekOfMonth: &last,
+		}, start, end)
+		is.Equal(dates, []time.Time{
+			date(2023, time.January, 29),
+			date(2023, time.February, 26),
+			date(2023, time.March, 26),
+			date(2023, time.April, 30),
+		})
+	})
+
+	t.Run("meets on the day of the month, the last day for the shorter months", func(t *testing.T) {
+		is := is.New(t)
+		dates := helpers.MeetingDates(&models.MeetingRecurrence{
+			Frequency: common.MEETING_FREQUENCY_MONTHLY,
+		}, date(2023, time.January, 31), end)
+		is.Equal(dates, []time.Time{
+			date(2023, time.January, 31),
+			date(2023, time.February, 28),
+			date(2023, time.March, 31),
+			date(2023, time.April, 30),
+		})
+	})
+}
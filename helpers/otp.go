@@ -1,30 +1,118 @@
 package helpers
 
 import (
-	"math/rand"
+	"crypto/rand"
+	"math/big"
+	"os"
+	"strconv"
 	"time"
 )
 
-func createSeed(now time.Time) *rand.Rand {
-	return rand.New(
-		rand.NewSource(
-			now.UnixNano(),
-		),
-	)
+// OtpLength is the number of digits of a generated pin code
+var OtpLength int
+
+// OtpTTL is how long a pin code can be used after it has been sent
+var OtpTTL time.Duration
+
+// OtpMaxAttempts is the number of wrong pin codes after which an OTP is locked
+var OtpMaxAttempts int
+
+// OtpSendCooldown is the minimum delay between two pin codes sent to the same phone
+var OtpSendCooldown time.Duration
+
+// OtpDailyLimit is the number of pin codes that can be sent to the same phone over 24 hours
+var OtpDailyLimit int
+
+// PhoneChangeTTL is how long a member has to confirm both numbers of a phone change
+var PhoneChangeTTL time.Duration
+
+// PhoneRecoveryTTL is how long a phone change approved by an admin waits for the member
+// once its delay is over
+var PhoneRecoveryTTL time.Duration
+
+// PhoneRecoveryDelay is how long a phone change approved by an admin is held back, while
+// the old number is warned and can cancel it
+var PhoneRecoveryDelay time.Duration
+
+// AccountDeletionGracePeriod is how long a member can cancel the deletion of its account
+var AccountDeletionGracePeriod time.Duration
+
+func init() {
+	OtpLength = getIntOrDefault("OTP_LENGTH", 4)
+	OtpTTL = getDurationOrDefault("OTP_TTL", 5*time.Minute)
+	OtpMaxAttempts = getIntOrDefault("OTP_MAX_ATTEMPTS", 5)
+	OtpSendCooldown = getDurationOrDefault("OTP_SEND_COOLDOWN", time.Minute)
+	OtpDailyLimit = getIntOrDefault("OTP_DAILY_LIMIT", 5)
+	PhoneChangeTTL = getDurationOrDefault("PHONE_CHANGE_TTL", 15*time.Minute)
+	PhoneRecoveryTTL = getDurationOrDefault("PHONE_RECOVERY_TTL", 24*time.Hour)
+	PhoneRecoveryDelay = getDurationOrDefault("PHONE_RECOVERY_DELAY", 48*time.Hour)
+	AccountDeletionGracePeriod = getDurationOrDefault("ACCOUNT_DELETION_GRACE_PERIOD", 14*24*time.Hour)
 }
 
-func stringWithCharset(now time.Time, charset string, length int) string {
-	seed := createSeed(now)
+func stringWithCharset(charset string, length int) (string, error) {
+	max := big.NewInt(int64(len(charset)))
 
 	b := make([]byte, length)
 	for i := range b {
-		b[i] = charset[seed.Intn(len(charset))]
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[n.Int64()]
 	}
 
-	return string(b)
+	return string(b), nil
 }
 
-func GeneratePinCode(now time.Time) string {
+// GeneratePinCode returns a pin code of OtpLength digits drawn from a cryptographic source
+func GeneratePinCode() (string, error) {
 	charset := "0123456789"
-	return stringWithCharset(now, charset, 4)
+	return stringWithCharset(charset, OtpLength)
+}
+
+// OtpCooldownRemaining returns how long to wait before sending another pin code
+// to a phone whose last pin code was sent at lastSentAt
+func OtpCooldownRemaining(lastSentAt, now time.Time) time.Duration {
+	remaining := lastSentAt.Add(OtpSendCooldown).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// NextOtpSendAt returns when a new pin code can be sent to a phone which received
+// sentInWindow pin codes over the last 24 hours, the oldest at oldestInWindow and the last at lastSentAt
+func NextOtpSendAt(lastSentAt time.Time, sentInWindow int, oldestInWindow time.Time) time.Time {
+	next := lastSentAt.Add(OtpSendCooldown)
+	if sentInWindow >= OtpDailyLimit {
+		if windowEnd := oldestInWindow.Add(24 * time.Hour); windowEnd.After(next) {
+			next = windowEnd
+		}
+	}
+	return next
+}
+
+func getIntOrDefault(name string, defaultV int) int {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultV
+	}
+
+	vAsInt, err := strconv.Atoi(v)
+	if err != nil {
+		return defaultV
+	}
+	return vAsInt
+}
+
+func getDurationOrDefault(name string, defaultV time.Duration) time.Duration {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultV
+	}
+	vAsDuration, err := time.ParseDuration(v)
+	if err != nil {
+		return defaultV
+	}
+	return vAsDuration
 }
@@ -0,0 +1,52 @@
+package helpers_test
+
+import (
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/helpers"
+)
+
+func TestGeneratePinCode(t *testing.T) {
+	t.Run("generates digits of the configured length", func(t *testing.T) {
+		is := is.New(t)
+
+		pinCode, err := helpers.GeneratePinCode()
+		is.NoErr(err)
+		is.Equal(helpers.OtpLength, len(pinCode))
+		for _, r := range pinCode {
+			is.True(unicode.IsDigit(r))
+		}
+	})
+}
+
+func TestOtpCooldownRemaining(t *testing.T) {
+	now := time.Now()
+
+	t.Run("waits for the cooldown after a recent send", func(t *testing.T) {
+		is := is.New(t)
+		is.True(helpers.OtpCooldownRemaining(now, now) > 0)
+	})
+
+	t.Run("allows sending once the cooldown is over", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(time.Duration(0), helpers.OtpCooldownRemaining(now.Add(-helpers.OtpSendCooldown), now))
+	})
+}
+
+func TestNextOtpSendAt(t *testing.T) {
+	now := time.Now()
+
+	t.Run("waits for the cooldown under the daily limit", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(now.Add(helpers.OtpSendCooldown), helpers.NextOtpSendAt(now, 1, now))
+	})
+
+	t.Run("waits for the oldest pin code to leave the window at the daily limit", func(t *testing.T) {
+		is := is.New(t)
+		oldest := now.Add(-time.Hour)
+		is.Equal(oldest.Add(24*time.Hour), helpers.NextOtpSendAt(now, helpers.OtpDailyLimit, oldest))
+	})
+}
@@ -0,0 +1,188 @@
+package helpers
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrPasswordTooShort    = errors.New("the password is too short")
+	ErrPasswordTooLong     = errors.New("the password is too long")
+	ErrPasswordBreached    = errors.New("the password is too common, it appears in known data breaches")
+	ErrUnknownPasswordHash = errors.New("unknown password hash format")
+)
+
+const (
+	argon2idPrefix   = "$argon2id$"
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// PasswordMinLength is the minimum number of characters of a password
+var PasswordMinLength int
+
+// PasswordMaxLength caps the length of a password, so that hashing it stays cheap
+var PasswordMaxLength int
+
+// PasswordArgon2Time is the number of passes of argon2id over the memory
+var PasswordArgon2Time int
+
+// PasswordArgon2Memory is the memory used by argon2id, in KiB
+var PasswordArgon2Memory int
+
+// PasswordArgon2Threads is the parallelism of argon2id
+var PasswordArgon2Threads int
+
+//go:embed breached_passwords.txt
+var breachedPasswordList string
+
+var breachedPasswords map[string]bool
+
+// dummyPasswordHash is compared against when the username does not exist,
+// so that the response takes as long as for a wrong password
+var dummyPasswordHash string
+
+func init() {
+	PasswordMinLength = getIntOrDefault("PASSWORD_MIN_LENGTH", 8)
+	PasswordMaxLength = getIntOrDefault("PASSWORD_MAX_LENGTH", 128)
+	PasswordArgon2Time = getIntOrDefault("PASSWORD_ARGON2_TIME", 3)
+	PasswordArgon2Memory = getIntOrDefault("PASSWORD_ARGON2_MEMORY", 64*1024)
+	PasswordArgon2Threads = getIntOrDefault("PASSWORD_ARGON2_THREADS", 2)
+
+	breachedPasswords = map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(breachedPasswordList))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breachedPasswords[line] = true
+	}
+
+	dummyPasswordHash, _ = HashPassword("tschwaa")
+}
+
+// argon2Params are the parameters a password has been hashed with, stored along with the hash
+type argon2Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+func currentArgon2Params() argon2Params {
+	return argon2Params{
+		Time:    uint32(PasswordArgon2Time),
+		Memory:  uint32(PasswordArgon2Memory),
+		Threads: uint8(PasswordArgon2Threads),
+	}
+}
+
+// ValidatePassword tells whether the password follows the password policy
+func ValidatePassword(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < PasswordMinLength {
+		return ErrPasswordTooShort
+	}
+	if length > PasswordMaxLength {
+		return ErrPasswordTooLong
+	}
+	if breachedPasswords[strings.ToLower(password)] {
+		return ErrPasswordBreached
+	}
+
+	return nil
+}
+
+// HashPassword hashes the password with argon2id, in the PHC string format
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<hash>
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	params := currentArgon2Params()
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, argon2KeyLength)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, params.Memory, params.Time, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// IsPasswordMatched checks the password against its hash, either argon2id or the bcrypt
+// of the accounts created before it
+func IsPasswordMatched(hashedPassword, password string) bool {
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		err := bcrypt.CompareHashAndPassword(
+			[]byte(hashedPassword),
+			[]byte(password),
+		)
+		return err == nil
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hashedPassword)
+	if err != nil {
+		return false
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+// PasswordNeedsRehash tells whether the hash should be replaced, once the password is known,
+// because it is a legacy bcrypt one or because the argon2id parameters have been tuned since
+func PasswordNeedsRehash(hashedPassword string) bool {
+	params, _, _, err := decodeArgon2Hash(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return params != currentArgon2Params()
+}
+
+// WastePasswordCheck takes as long as IsPasswordMatched does, for the usernames that do not exist
+func WastePasswordCheck(password string) {
+	_ = IsPasswordMatched(dummyPasswordHash, password)
+}
+
+func decodeArgon2Hash(hashedPassword string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,75 @@
+package helpers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"golang.org/x/crypto/bcrypt"
+	"tschwaa.com/api/helpers"
+)
+
+func TestHashPassword(t *testing.T) {
+	t.Run("hashes with argon2id and verifies the password", func(t *testing.T) {
+		is := is.New(t)
+
+		hashedPassword, err := helpers.HashPassword("correct horse battery")
+		is.NoErr(err)
+		is.True(strings.HasPrefix(hashedPassword, "$argon2id$v=19$"))
+		is.True(helpers.IsPasswordMatched(hashedPassword, "correct horse battery"))
+		is.True(!helpers.IsPasswordMatched(hashedPassword, "correct horse"))
+		is.True(!helpers.PasswordNeedsRehash(hashedPassword))
+
+		other, err := helpers.HashPassword("correct horse battery")
+		is.NoErr(err)
+		is.True(other != hashedPassword)
+	})
+
+	t.Run("still verifies the legacy bcrypt hashes and asks to rehash them", func(t *testing.T) {
+		is := is.New(t)
+
+		legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse battery"), bcrypt.MinCost)
+		is.NoErr(err)
+		is.True(helpers.IsPasswordMatched(string(legacy), "correct horse battery"))
+		is.True(!helpers.IsPasswordMatched(string(legacy), "wrong"))
+		is.True(helpers.PasswordNeedsRehash(string(legacy)))
+	})
+
+	t.Run("asks to rehash once the parameters are tuned", func(t *testing.T) {
+		is := is.New(t)
+
+		hashedPassword, err := helpers.HashPassword("correct horse battery")
+		is.NoErr(err)
+
+		time := helpers.PasswordArgon2Time
+		helpers.PasswordArgon2Time++
+		defer func() { helpers.PasswordArgon2Time = time }()
+
+		is.True(helpers.PasswordNeedsRehash(hashedPassword))
+		is.True(helpers.IsPasswordMatched(hashedPassword, "correct horse battery"))
+	})
+
+	t.Run("rejects a malformed hash", func(t *testing.T) {
+		is := is.New(t)
+		is.True(!helpers.IsPasswordMatched("$argon2id$v=19$m=1,t=1,p=1$bad", "password"))
+	})
+}
+
+func TestValidatePassword(t *testing.T) {
+	t.Run("enforces the length", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.ValidatePassword(strings.Repeat("x", helpers.PasswordMinLength-1)), helpers.ErrPasswordTooShort)
+		is.Equal(helpers.ValidatePassword(strings.Repeat("x", helpers.PasswordMaxLength+1)), helpers.ErrPasswordTooLong)
+	})
+
+	t.Run("refuses the breached passwords whatever their case", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.ValidatePassword("Password123"), helpers.ErrPasswordBreached)
+		is.Equal(helpers.ValidatePassword("QWERTYUIOP"), helpers.ErrPasswordBreached)
+	})
+
+	t.Run("accepts a good password", func(t *testing.T) {
+		is := is.New(t)
+		is.NoErr(helpers.ValidatePassword("njangi of the 3rd sunday"))
+	})
+}
@@ -0,0 +1,108 @@
+package helpers
+
+import (
+	"math/rand"
+	"sort"
+
+	"tschwaa.com/api/models"
+)
+
+// BeneficiaryHands lists the memberships once per hand they hold in the session, going
+// round the members as many times as the most hands held, so that a member with several
+// hands does not take the pot twice in a row. The members are taken by membership id,
+// the order they joined the organization in, so that the same members always give the
+// same hands, and a seed the same draw.
+func BeneficiaryHands(members []*models.MembersOfSession) []uint64 {
+	sorted := make([]*models.MembersOfSession, len(members))
+	copy(sorted, members)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].MembershipID < sorted[j].MembershipID
+	})
+
+	hands := []uint64{}
+	for round := 1; ; round++ {
+		added := false
+		for _, member := range sorted {
+			if member.Shares >= round {
+				hands = append(hands, member.MembershipID)
+				added = true
+			}
+		}
+		if !added {
+			return hands
+		}
+	}
+}
+
+// DrawBeneficiaryOrder shuffles the memberships with the seed, drawing the same seed
+// over the same memberships always gives the same order
+func DrawBeneficiaryOrder(membershipIDs []uint64, seed int64) []uint64 {
+	order := make([]uint64, len(membershipIDs))
+	copy(order, membershipIDs)
+
+	random := rand.New(rand.NewSource(seed))
+	random.Shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+
+	return order
+}
+
+// CurrentAndNextBeneficiary returns the first two slots, in the order, whose pot
+// has not been handed over yet. Either is nil once the rotation comes to an end.
+func CurrentAndNextBeneficiary(slots []*models.BeneficiarySlot) (*models.BeneficiarySlot, *models.BeneficiarySlot) {
+	var current, next *models.BeneficiarySlot
+	for _, slot := range slots {
+		if slot.ServedAt != nil {
+			continue
+		}
+		if current == nil || slot.Position < current.Position {
+			current, next = slot, current
+		} else if next == nil || slot.Position < next.Position {
+			next = slot
+		}
+	}
+
+	return current, next
+}
+
+// ResizeBeneficiarySlots matches the slots of the order with the hands the members hold now.
+// It returns the slots to drop, the last turns to come of the members holding fewer hands,
+// and the memberships to add at the end of the order for the members holding more, going
+// round the members like BeneficiaryHands. Served slots are always kept.
+func ResizeBeneficiarySlots(slots []*models.BeneficiarySlot, members []*models.MembersOfSession) ([]uint64, []uint64) {
+	shares := map[uint64]int{}
+	for _, member := range members {
+		shares[member.MembershipID] = member.Shares
+	}
+
+	held := map[uint64]int{}
+	for _, slot := range slots {
+		held[slot.MembershipID]++
+	}
+
+	removed := []uint64{}
+	for i := len(slots) - 1; i >= 0; i-- {
+		slot := slots[i]
+		if slot.ServedAt != nil || held[slot.MembershipID] <= shares[slot.MembershipID] {
+			continue
+		}
+		removed = append(removed, slot.ID)
+		held[slot.MembershipID]--
+	}
+
+	added := []uint64{}
+	for {
+		more := false
+		for _, member := range members {
+			if held[member.MembershipID] < member.Shares {
+				added = append(added, member.MembershipID)
+				held[member.MembershipID]++
+				more = true
+			}
+		}
+		if !more {
+			return removed, added
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package helpers_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+)
+
+func TestBeneficiaryHands(t *testing.T) {
+	t.Run("goes round the members once per hand", func(t *testing.T) {
+		is := is.New(t)
+		members := []*models.MembersOfSession{
+			{MembershipID: 11, Shares: 1},
+			{MembershipID: 12, Shares: 3},
+			{MembershipID: 13, Shares: 2},
+		}
+		is.Equal(helpers.BeneficiaryHands(members), []uint64{11, 12, 13, 12, 13, 12})
+	})
+
+	t.Run("has no hands without members", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.BeneficiaryHands(nil), []uint64{})
+	})
+
+	t.Run("takes the members by membership id whatever their order", func(t *testing.T) {
+		is := is.New(t)
+		members := []*models.MembersOfSession{
+			{MembershipID: 13, Shares: 1},
+			{MembershipID: 11, Shares: 2},
+			{MembershipID: 12, Shares: 1},
+		}
+		is.Equal(helpers.BeneficiaryHands(members), []uint64{11, 12, 13, 11})
+	})
+}
+
+func TestDrawBeneficiaryOrder(t *testing.T) {
+	memberships := []uint64{11, 12, 13, 14, 15, 16, 17, 18}
+
+	t.Run("draws the same order with the same seed", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.DrawBeneficiaryOrder(memberships, 42), helpers.DrawBeneficiaryOrder(memberships, 42))
+	})
+
+	t.Run("draws every membership once, leaving the input alone", func(t *testing.T) {
+		is := is.New(t)
+		order := helpers.DrawBeneficiaryOrder(memberships, 7)
+		is.Equal(memberships, []uint64{11, 12, 13, 14, 15, 16, 17, 18})
+
+		sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+		is.Equal(order, memberships)
+	})
+}
+
+func TestCurrentAndNextBeneficiary(t *testing.T) {
+	servedAt := time.Date(2023, time.March, 5, 0, 0, 0, 0, time.UTC)
+	slots := []*models.BeneficiarySlot{
+		{ID: 1, Position: 1, ServedAt: &servedAt},
+		{ID: 2, Position: 2},
+		{ID: 3, Position: 3},
+	}
+
+	t.Run("skips the slots already served", func(t *testing.T) {
+		is := is.New(t)
+		current, next := helpers.CurrentAndNextBeneficiary(slots)
+		is.Equal(current.ID, uint64(2))
+		is.Equal(next.ID, uint64(3))
+	})
+
+	t.Run("has no next beneficiary on the last turn", func(t *testing.T) {
+		is := is.New(t)
+		current, next := helpers.CurrentAndNextBeneficiary(slots[2:])
+		is.Equal(current.ID, uint64(3))
+		is.True(next == nil)
+	})
+}
+
+func TestResizeBeneficiarySlots(t *testing.T) {
+	served := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("drops the last turns to come of a member holding fewer hands", func(t *testing.T) {
+		is := is.New(t)
+		slots := []*models.BeneficiarySlot{
+			{ID: 1, Position: 1, MembershipID: 11, ServedAt: &served},
+			{ID: 2, Position: 2, MembershipID: 12},
+			{ID: 3, Position: 3, MembershipID: 11},
+			{ID: 4, Position: 4, MembershipID: 11},
+		}
+		members := []*models.MembersOfSession{
+			{MembershipID: 11, Shares: 2},
+			{MembershipID: 12, Shares: 1},
+		}
+		removed, added := helpers.ResizeBeneficiarySlots(slots, members)
+		is.Equal(removed, []uint64{4})
+		is.Equal(added, []uint64{})
+	})
+
+	t.Run("keeps the served turns of a member holding fewer hands", func(t *testing.T) {
+		is := is.New(t)
+		slots := []*models.BeneficiarySlot{
+			{ID: 1, Position: 1, MembershipID: 11, ServedAt: &served},
+			{ID: 2, Position: 2, MembershipID: 11, ServedAt: &served},
+			{ID: 3, Position: 3, MembershipID: 11},
+		}
+		members := []*models.MembersOfSession{{MembershipID: 11, Shares: 1}}
+		removed, added := helpers.ResizeBeneficiarySlots(slots, members)
+		is.Equal(removed, []uint64{3})
+		is.Equal(added, []uint64{})
+	})
+
+	t.Run("adds the new hands at the end, going round the members", func(t *testing.T) {
+		is := is.New(t)
+		slots := []*models.BeneficiarySlot{
+			{ID: 1, Position: 1, MembershipID: 11},
+			{ID: 2, Position: 2, MembershipID: 12},
+		}
+		members := []*models.MembersOfSession{
+			{MembershipID: 11, Shares: 3},
+			{MembershipID: 12, Shares: 2},
+			{MembershipID: 13, Shares: 1},
+		}
+		removed, added := helpers.ResizeBeneficiarySlots(slots, members)
+		is.Equal(removed, []uint64{})
+		is.Equal(added, []uint64{11, 12, 13, 11})
+	})
+}
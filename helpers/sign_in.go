@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"strings"
+	"time"
+)
+
+// SignInFreeFailures is the number of failed sign-ins allowed before they get delayed
+var SignInFreeFailures int
+
+// SignInBaseDelay is the delay after the first failure past the free ones, doubled at each new one
+var SignInBaseDelay time.Duration
+
+// SignInMaxDelay caps the delay between two sign-in attempts
+var SignInMaxDelay time.Duration
+
+// SignInMaxFailures is the number of failed sign-ins of a username that locks it
+var SignInMaxFailures int
+
+// SignInIPMaxFailures is the number of failed sign-ins from an IP address that locks it
+var SignInIPMaxFailures int
+
+// SignInLockoutDuration is how long a username or an IP address stays locked
+var SignInLockoutDuration time.Duration
+
+// SignInFailureWindow is how long a failure is remembered after the last one
+var SignInFailureWindow time.Duration
+
+func init() {
+	SignInFreeFailures = getIntOrDefault("SIGN_IN_FREE_FAILURES", 3)
+	SignInBaseDelay = getDurationOrDefault("SIGN_IN_BASE_DELAY", time.Second)
+	SignInMaxDelay = getDurationOrDefault("SIGN_IN_MAX_DELAY", time.Minute)
+	SignInMaxFailures = getIntOrDefault("SIGN_IN_MAX_FAILURES", 10)
+	SignInIPMaxFailures = getIntOrDefault("SIGN_IN_IP_MAX_FAILURES", 100)
+	SignInLockoutDuration = getDurationOrDefault("SIGN_IN_LOCKOUT_DURATION", 15*time.Minute)
+	SignInFailureWindow = getDurationOrDefault("SIGN_IN_FAILURE_WINDOW", time.Hour)
+}
+
+// UsernameThrottleKey returns the key the failed sign-ins of a username are counted under
+func UsernameThrottleKey(username string) string {
+	return "username:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+// SignInDelay returns the delay to wait after the last of failures failed sign-ins
+func SignInDelay(failures int) time.Duration {
+	if failures <= SignInFreeFailures {
+		return 0
+	}
+
+	delay := SignInBaseDelay
+	for i := SignInFreeFailures + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= SignInMaxDelay {
+			return SignInMaxDelay
+		}
+	}
+	if delay > SignInMaxDelay {
+		return SignInMaxDelay
+	}
+	return delay
+}
+
+// SignInWaitRemaining returns how long to wait before the next sign-in attempt,
+// given the failures so far, when the last one happened and until when it is locked
+func SignInWaitRemaining(failures int, lastFailedAt time.Time, lockedUntil *time.Time, now time.Time) time.Duration {
+	remaining := lastFailedAt.Add(SignInDelay(failures)).Sub(now)
+	if lockedUntil != nil {
+		if locked := lockedUntil.Sub(now); locked > remaining {
+			remaining = locked
+		}
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
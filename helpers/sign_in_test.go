@@ -0,0 +1,49 @@
+package helpers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/helpers"
+)
+
+func TestSignInDelay(t *testing.T) {
+	t.Run("lets the first failures through", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(time.Duration(0), helpers.SignInDelay(helpers.SignInFreeFailures))
+	})
+
+	t.Run("doubles the delay at each failure", func(t *testing.T) {
+		is := is.New(t)
+		first := helpers.SignInDelay(helpers.SignInFreeFailures + 1)
+		is.Equal(first, helpers.SignInBaseDelay)
+		is.Equal(helpers.SignInDelay(helpers.SignInFreeFailures+2), 2*first)
+	})
+
+	t.Run("caps the delay", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(helpers.SignInDelay(helpers.SignInFreeFailures+100), helpers.SignInMaxDelay)
+	})
+}
+
+func TestSignInWaitRemaining(t *testing.T) {
+	now := time.Now()
+
+	t.Run("waits for the delay after the last failure", func(t *testing.T) {
+		is := is.New(t)
+		is.True(helpers.SignInWaitRemaining(helpers.SignInFreeFailures+1, now, nil, now) > 0)
+		is.Equal(time.Duration(0), helpers.SignInWaitRemaining(helpers.SignInFreeFailures, now, nil, now))
+	})
+
+	t.Run("waits for the end of the lockout", func(t *testing.T) {
+		is := is.New(t)
+		lockedUntil := now.Add(helpers.SignInLockoutDuration)
+		is.Equal(helpers.SignInWaitRemaining(1, now, &lockedUntil, now), helpers.SignInLockoutDuration)
+	})
+}
+
+func TestUsernameThrottleKey(t *testing.T) {
+	is := is.New(t)
+	is.Equal(helpers.UsernameThrottleKey(" Jane@Example.com "), helpers.UsernameThrottleKey("jane@example.com"))
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AccountDeletion is the request of a member to leave the platform, carried out once its grace period is over
+type AccountDeletion struct {
+	ID           uint64     `json:"id"`
+	MemberID     uint64     `json:"member_id"`
+	ScheduledFor time.Time  `json:"scheduled_for"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	Language     string     `json:"language"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// APIKey lets an integration read the data of one organization, within its permissions
+type APIKey struct {
+	ID             uint64     `json:"id"`
+	OrganizationID uint64     `json:"organization_id"`
+	CreatedBy      *uint64    `json:"created_by,omitempty"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"`
+	KeyHash        string     `json:"-"`
+	Permissions    []string   `json:"permissions"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+func (k *APIKey) HasPermission(permission string) bool {
+	for _, p := range k.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
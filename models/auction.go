@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// AuctionMode is how the pot of the meetings of a session is auctioned, in the smallest
+// unit of the currency
+type AuctionMode struct {
+	ID        uint64  `json:"id"`
+	SessionID uint64  `json:"session_id"`
+	Bidding   string  `json:"bidding"`
+	Pot       int64   `json:"pot"`
+	Currency  string  `json:"currency"`
+	CreatedBy *uint64 `json:"created_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Auction is the bidding for the pot of a meeting, the highest discount wins it
+type Auction struct {
+	ID                 uint64     `json:"id"`
+	SessionID          uint64     `json:"session_id"`
+	MeetingDate        time.Time  `json:"meeting_date"`
+	Bidding            string     `json:"bidding"`
+	Pot                int64      `json:"pot"`
+	Currency           string     `json:"currency"`
+	Status             string     `json:"status"`
+	WinnerMembershipID *uint64    `json:"winner_membership_id,omitempty"`
+	WinningDiscount    *int64     `json:"winning_discount,omitempty"`
+	CreatedBy          *uint64    `json:"created_by,omitempty"`
+	ClosedAt           *time.Time `json:"closed_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// AuctionBid is the discount a member agrees to give up to take the pot. A member may bid
+// several times, their latest bid is the one that counts.
+type AuctionBid struct {
+	ID           uint64 `json:"id"`
+	AuctionID    uint64 `json:"auction_id"`
+	MembershipID uint64 `json:"membership_id"`
+	Discount     int64  `json:"discount"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// AuctionPayout is what a member receives once the auction is closed, the pot less the
+// discount for the winner and a share of the discount for the others
+type AuctionPayout struct {
+	ID           uint64 `json:"id"`
+	AuctionID    uint64 `json:"auction_id"`
+	MembershipID uint64 `json:"membership_id"`
+	Kind         string `json:"kind"`
+	Amount       int64  `json:"amount"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// ContributionPlan is what every member of a session owes at each due date, in the smallest
+// unit of the currency
+type ContributionPlan struct {
+	ID        uint64  `json:"id"`
+	SessionID uint64  `json:"session_id"`
+	Name      string  `json:"name"`
+	Amount    int64   `json:"amount"`
+	Currency  string  `json:"currency"`
+	Frequency string  `json:"frequency"`
+	CreatedBy *uint64 `json:"created_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Contribution is a payment of a member to a plan at a meeting, a member can pay
+// the amount of a meeting in several times
+type Contribution struct {
+	ID           uint64    `json:"id"`
+	PlanID       uint64    `json:"plan_id"`
+	MembershipID uint64    `json:"membership_id"`
+	MeetingDate  time.Time `json:"meeting_date"`
+	Amount       int64     `json:"amount"`
+	Note         string    `json:"note"`
+	RecordedBy   *uint64   `json:"recorded_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// ContributionTotal is how much a member has paid to a plan so far
+type ContributionTotal struct {
+	PlanID       uint64 `json:"plan_id"`
+	MembershipID uint64 `json:"membership_id"`
+	Paid         int64  `json:"paid"`
+}
+
+// ContributionBalance is what a member still owes to a plan, negative when paid in advance
+type ContributionBalance struct {
+	MembershipID uint64 `json:"membership_id"`
+	MemberID     uint64 `json:"member_id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Shares       int    `json:"shares"`
+	Expected     int64  `json:"expected"`
+	Paid         int64  `json:"paid"`
+	Outstanding  int64  `json:"outstanding"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Login is a sign in of a member from a device, it lives as long as its refresh token family
+type Login struct {
+	ID         uint64     `json:"id"`
+	MemberID   uint64     `json:"member_id"`
+	FamilyID   string     `json:"-"`
+	Channel    string     `json:"channel"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// TwoFactorAt is set when the member passed the second step of the sign in
+	TwoFactorAt *time.Time `json:"two_factor_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
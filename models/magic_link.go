@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// MagicLink logs a member in once, from the device that asked for it
+type MagicLink struct {
+	ID         uint64     `json:"id"`
+	MemberID   uint64     `json:"member_id"`
+	Phone      string     `json:"phone"`
+	DeviceHash string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// MeetingRecurrence is the rule the meetings of a session follow, like every second Sunday
+// of the month at 15:00
+type MeetingRecurrence struct {
+	ID          uint64  `json:"id"`
+	SessionID   uint64  `json:"session_id"`
+	Frequency   string  `json:"frequency"`
+	Weekday     *int    `json:"weekday,omitempty"`
+	WeekOfMonth *int    `json:"week_of_month,omitempty"`
+	StartTime   string  `json:"start_time"`
+	Place       string  `json:"place"`
+	CreatedBy   *uint64 `json:"created_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Meeting is an occurrence of the recurrence of a session, it keeps the date it was set on
+// when it is moved to another one
+type Meeting struct {
+	ID            uint64     `json:"id"`
+	SessionID     uint64     `json:"session_id"`
+	OriginalDate  time.Time  `json:"original_date"`
+	Date          time.Time  `json:"date"`
+	StartTime     string     `json:"start_time"`
+	Place         string     `json:"place"`
+	Status        string     `json:"status"`
+	Note          string     `json:"note"`
+	RescheduledAt *time.Time `json:"rescheduled_at,omitempty"`
+	CancelledAt   *time.Time `json:"cancelled_at,omitempty"`
+	UpdatedBy     *uint64    `json:"updated_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
@@ -1,9 +1,19 @@
 package models
 
+import "time"
+
 type Otp struct {
-	ID          uint64 `json:"id,omitempty"`
-	WaMessageID string `json:"wa_message_id,omitempty"`
-	Phone       string `json:"phone,omitempty"`
-	PinCode     string `json:"pin_code,omitempty"`
-	Active      bool   `json:"active,omitempty"`
+	ID        uint64    `json:"id,omitempty"`
+	MessageID string    `json:"message_id,omitempty"`
+	Channel   string    `json:"channel,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	PinCode   string    `json:"pin_code,omitempty"`
+	Active    bool      `json:"active,omitempty"`
+	Attempts  int       `json:"attempts,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	// Purpose is what the pin code was sent for, it cannot be used for anything else
+	Purpose string `json:"purpose,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
 }
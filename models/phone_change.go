@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PhoneChange moves a member to a new phone number once both numbers are confirmed,
+// or only the new one when an admin of an organization approved it. An approved change
+// waits until AvailableAt, so that the old number has time to object to it.
+type PhoneChange struct {
+	ID          uint64    `json:"id"`
+	MemberID    uint64    `json:"member_id"`
+	OldPhone    string    `json:"old_phone"`
+	NewPhone    string    `json:"new_phone"`
+	ApprovedBy  *uint64   `json:"approved_by,omitempty"`
+	Status      string    `json:"status"`
+	AvailableAt time.Time `json:"available_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
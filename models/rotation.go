@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// BeneficiaryRotation is how the order in which the members of a session take the pot was set
+type BeneficiaryRotation struct {
+	ID        uint64  `json:"id"`
+	SessionID uint64  `json:"session_id"`
+	Method    string  `json:"method"`
+	Seed      *int64  `json:"seed,omitempty"`
+	CreatedBy *uint64 `json:"created_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// BeneficiarySlot is the turn of a member to take the pot, served once it has been handed over
+type BeneficiarySlot struct {
+	ID           uint64     `json:"id"`
+	SessionID    uint64     `json:"session_id"`
+	Position     int        `json:"position"`
+	MembershipID uint64     `json:"membership_id"`
+	MemberID     uint64     `json:"member_id"`
+	FirstName    string     `json:"first_name"`
+	LastName     string     `json:"last_name"`
+	ServedAt     *time.Time `json:"served_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// BeneficiarySwap is the request of a member to exchange their turn with another one
+type BeneficiarySwap struct {
+	ID          uint64     `json:"id"`
+	SessionID   uint64     `json:"session_id"`
+	FromSlotID  uint64     `json:"from_slot_id"`
+	ToSlotID    uint64     `json:"to_slot_id"`
+	RequestedBy uint64     `json:"requested_by"`
+	Status      string     `json:"status"`
+	DecidedBy   *uint64    `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
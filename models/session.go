@@ -43,6 +43,8 @@ type MembersOfSession struct {
 	Status       string     `json:"status"`
 	Joined       bool       `json:"joined"`
 	JoinedAt     *time.Time `json:"joined_at"`
+	// Shares is the number of hands held in the session, none when out of it
+	Shares int `json:"shares"`
 }
 
 type SessionPlace struct {
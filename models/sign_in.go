@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SignInThrottle counts the failed sign-ins of a username or of an IP address
+type SignInThrottle struct {
+	Key          string     `json:"key"`
+	Failures     int        `json:"failures"`
+	LastFailedAt time.Time  `json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
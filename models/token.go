@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type RefreshToken struct {
+	ID         uint64     `json:"id"`
+	MemberID   uint64     `json:"member_id"`
+	FamilyID   string     `json:"family_id"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	ReplacedBy *uint64    `json:"replaced_by,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// TwoFactor is the authenticator app of a user, it only protects the sign in once confirmed
+type TwoFactor struct {
+	ID     uint64 `json:"id"`
+	UserID uint64 `json:"user_id"`
+	// Secret is encrypted, see services.EncryptTOTPSecret
+	Secret       string     `json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep int64      `json:"-"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
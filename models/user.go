@@ -30,6 +30,8 @@ type User struct {
 	MemberID uint64 `json:"id,omitempty"`
 	Member   Member `json:"member,omitempty"`
 
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+
 	CreatedAt time.Time `json:"created_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
 }
@@ -77,10 +79,17 @@ func (u *Member) IsValid() bool {
 		return false
 	}
 
-	var phone string = u.Phone
+	return IsValidPhone(u.Phone)
+}
+
+// IsValidPhone tells whether phone is made of digits, with an optional leading +
+func IsValidPhone(phone string) bool {
 	if strings.HasPrefix(phone, "+") {
 		phone = phone[1:len(phone)]
 	}
+	if phone == "" || len(phone) > 15 {
+		return false
+	}
 	for _, r := range phone {
 		if !unicode.IsDigit(r) {
 			return false
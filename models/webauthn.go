@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// WebAuthnCredential is a passkey the user signs in with
+type WebAuthnCredential struct {
+	ID           uint64     `json:"id"`
+	UserID       uint64     `json:"user_id"`
+	CredentialID string     `json:"credential_id"`
+	PublicKey    []byte     `json:"-"`
+	Algorithm    int        `json:"algorithm"`
+	SignCount    uint32     `json:"sign_count"`
+	Name         string     `json:"name"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// WebAuthnChallenge is the challenge of a registration or login ceremony, it can only be answered once
+type WebAuthnChallenge struct {
+	ID        uint64    `json:"id"`
+	Challenge string    `json:"challenge"`
+	Ceremony  string    `json:"ceremony"`
+	UserID    *uint64   `json:"user_id,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
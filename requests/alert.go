@@ -0,0 +1,68 @@
+package requests
+
+import (
+	"log"
+	"time"
+)
+
+// LockoutNotifier warns the owner of an account that its sign-in has been locked
+type LockoutNotifier interface {
+	NotifySignInLockout(phone, language string, lockedUntil time.Time) error
+}
+
+type WhatsappLockoutNotifier struct{}
+
+func (n WhatsappLockoutNotifier) NotifySignInLockout(phone, language string, lockedUntil time.Time) error {
+	_, err := SendSignInLockedAlert(phone, language, lockedUntil)
+	return err
+}
+
+// LogLockoutNotifier only logs the alert, for development
+type LogLockoutNotifier struct{}
+
+func (n LogLockoutNotifier) NotifySignInLockout(phone, language string, lockedUntil time.Time) error {
+	log.Printf("sign-in of %s locked until %s", phone, lockedUntil.Format(time.RFC3339))
+	return nil
+}
+
+// PhoneRecoveryNotifier warns the old number of a member that an admin approved moving
+// its account to another number
+type PhoneRecoveryNotifier interface {
+	NotifyPhoneRecovery(phone, language string, availableAt time.Time) error
+}
+
+type WhatsappPhoneRecoveryNotifier struct{}
+
+func (n WhatsappPhoneRecoveryNotifier) NotifyPhoneRecovery(phone, language string, availableAt time.Time) error {
+	_, err := SendPhoneRecoveryAlert(phone, language, availableAt)
+	return err
+}
+
+// LogPhoneRecoveryNotifier only logs the alert, for development
+type LogPhoneRecoveryNotifier struct{}
+
+func (n LogPhoneRecoveryNotifier) NotifyPhoneRecovery(phone, language string, availableAt time.Time) error {
+	log.Printf("phone recovery of %s approved, effective from %s", phone, availableAt.Format(time.RFC3339))
+	return nil
+}
+
+// AccountDeletionNotifier tells a member that the scheduled deletion of its account was cancelled
+// because it became the last admin of an organization
+type AccountDeletionNotifier interface {
+	NotifyAccountDeletionCancelled(phone, language string) error
+}
+
+type WhatsappAccountDeletionNotifier struct{}
+
+func (n WhatsappAccountDeletionNotifier) NotifyAccountDeletionCancelled(phone, language string) error {
+	_, err := SendAccountDeletionCancelledAlert(phone, language)
+	return err
+}
+
+// LogAccountDeletionNotifier only logs the alert, for development
+type LogAccountDeletionNotifier struct{}
+
+func (n LogAccountDeletionNotifier) NotifyAccountDeletionCancelled(phone, language string) error {
+	log.Printf("account deletion of %s cancelled, it is the last admin of an organization", phone)
+	return nil
+}
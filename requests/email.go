@@ -0,0 +1,129 @@
+package requests
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"tschwaa.com/api/utils"
+)
+
+// Mailer sends plain text emails
+type Mailer interface {
+	SendEmail(to, subject, body string) error
+}
+
+// SMTPMailer sends emails through the SMTP server configured by the SMTP_* variables
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailerFromEnv() *SMTPMailer {
+	return &SMTPMailer{
+		host:     os.Getenv("SMTP_HOST"),
+		port:     os.Getenv("SMTP_PORT"),
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+func (m *SMTPMailer) SendEmail(to, subject, body string) error {
+	if m.host == "" {
+		return fmt.Errorf("no smtp server configured")
+	}
+
+	message := strings.Join([]string{
+		fmt.Sprintf("From: %s", m.from),
+		fmt.Sprintf("To: %s", to),
+		fmt.Sprintf("Subject: %s", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+		"",
+		body,
+	}, "\r\n")
+
+	auth := smtp.PlainAuth("", m.username, m.password, m.host)
+	err := smtp.SendMail(fmt.Sprintf("%s:%s", m.host, m.port), auth, m.from, []string{to}, []byte(message))
+	return utils.Fail(fmt.Sprintf("error when sending email to %s", to), "ERR_SEML_01", err)
+}
+
+// FileMailer writes the emails into a directory instead of sending them, it stands in for SMTP in development
+type FileMailer struct {
+	Dir string
+}
+
+func (m *FileMailer) SendEmail(to, subject, body string) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return utils.Fail(fmt.Sprintf("error when creating the mail directory %s", m.Dir), "ERR_SEML_02", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(to))
+	message := fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", to, subject, body)
+	err := os.WriteFile(filepath.Join(m.Dir, name), []byte(message), 0o644)
+	return utils.Fail(fmt.Sprintf("error when writing email to %s", to), "ERR_SEML_03", err)
+}
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// MemoryMailer keeps the emails in memory instead of sending them
+type MemoryMailer struct {
+	mu     sync.Mutex
+	emails []Email
+}
+
+func (m *MemoryMailer) SendEmail(to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.emails = append(m.emails, Email{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+// Emails returns the emails sent so far
+func (m *MemoryMailer) Emails() []Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Email(nil), m.emails...)
+}
+
+// NewMailerFromEnv picks the mailer named by MAILER (smtp, file or memory).
+// Without it, emails go through SMTP and SMTP_HOST must be set: the file and memory mailers
+// never deliver anything, so they are only used when asked for.
+func NewMailerFromEnv() (Mailer, error) {
+	mailer := os.Getenv("MAILER")
+	if mailer == "" {
+		mailer = "smtp"
+	}
+
+	switch mailer {
+	case "smtp":
+		if os.Getenv("SMTP_HOST") == "" {
+			return nil, fmt.Errorf("SMTP_HOST must be set, or MAILER set to file or memory")
+		}
+		return NewSMTPMailerFromEnv(), nil
+	case "memory":
+		return &MemoryMailer{}, nil
+	case "file":
+		dir := os.Getenv("MAILER_DIR")
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "tschwaa-mails")
+		}
+		return &FileMailer{Dir: dir}, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer: %s", mailer)
+	}
+}
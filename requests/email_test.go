@@ -0,0 +1,65 @@
+package requests_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/requests"
+)
+
+func TestFileMailer(t *testing.T) {
+	is := is.New(t)
+	m := &requests.FileMailer{Dir: t.TempDir()}
+
+	err := m.SendEmail("jane@example.com", "Hello", "the body")
+	is.NoErr(err)
+
+	entries, err := os.ReadDir(m.Dir)
+	is.NoErr(err)
+	is.Equal(len(entries), 1)
+
+	content, err := os.ReadFile(m.Dir + "/" + entries[0].Name())
+	is.NoErr(err)
+	is.True(strings.Contains(string(content), "To: jane@example.com"))
+	is.True(strings.Contains(string(content), "the body"))
+}
+
+func TestMemoryMailer(t *testing.T) {
+	is := is.New(t)
+	m := &requests.MemoryMailer{}
+
+	is.NoErr(m.SendEmail("jane@example.com", "Hello", "the body"))
+	is.Equal(m.Emails(), []requests.Email{{To: "jane@example.com", Subject: "Hello", Body: "the body"}})
+}
+
+func TestNewMailerFromEnv(t *testing.T) {
+	t.Run("refuses to start without an smtp server or an explicit mailer", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("MAILER", "")
+		t.Setenv("SMTP_HOST", "")
+
+		_, err := requests.NewMailerFromEnv()
+		is.True(err != nil)
+	})
+
+	t.Run("uses the file mailer only when asked for", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("MAILER", "file")
+		t.Setenv("MAILER_DIR", t.TempDir())
+
+		m, err := requests.NewMailerFromEnv()
+		is.NoErr(err)
+		_, ok := m.(*requests.FileMailer)
+		is.True(ok)
+	})
+
+	t.Run("rejects an unknown mailer", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("MAILER", "pigeon")
+
+		_, err := requests.NewMailerFromEnv()
+		is.True(err != nil)
+	})
+}
@@ -0,0 +1,161 @@
+package requests
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"tschwaa.com/api/common"
+)
+
+type OtpRecipient struct {
+	Phone string
+	Email string
+}
+
+// OtpSender delivers a pin code over one channel and returns the id of the message sent
+type OtpSender interface {
+	Channel() string
+	SendOtp(to OtpRecipient, language, pinCode string) (string, error)
+}
+
+func otpMessage(language, pinCode string) string {
+	if language == "fr" {
+		return fmt.Sprintf("Votre code Tschwaa est %s", pinCode)
+	}
+	return fmt.Sprintf("Your Tschwaa code is %s", pinCode)
+}
+
+type WhatsappOtpSender struct{}
+
+func (s WhatsappOtpSender) Channel() string {
+	return common.OTP_CHANNEL_WHATSAPP
+}
+
+func (s WhatsappOtpSender) SendOtp(to OtpRecipient, language, pinCode string) (string, error) {
+	res, err := SendTschwaaOtp(to.Phone, language, pinCode)
+	if err != nil {
+		return "", err
+	}
+	if len(res.Messages) == 0 {
+		return "", fmt.Errorf("no whatsapp message sent")
+	}
+
+	return res.Messages[0].ID, nil
+}
+
+type SmsOtpSender struct{}
+
+func (s SmsOtpSender) Channel() string {
+	return common.OTP_CHANNEL_SMS
+}
+
+func (s SmsOtpSender) SendOtp(to OtpRecipient, language, pinCode string) (string, error) {
+	res, err := SendSms(to.Phone, otpMessage(language, pinCode))
+	if err != nil {
+		return "", err
+	}
+
+	return res.MessageID, nil
+}
+
+type EmailOtpSender struct {
+	Mailer Mailer
+}
+
+func (s EmailOtpSender) Channel() string {
+	return common.OTP_CHANNEL_EMAIL
+}
+
+func (s EmailOtpSender) SendOtp(to OtpRecipient, language, pinCode string) (string, error) {
+	if to.Email == "" {
+		return "", fmt.Errorf("no email address to send the otp to")
+	}
+
+	return "", s.Mailer.SendEmail(to.Email, "Tschwaa", otpMessage(language, pinCode))
+}
+
+// LogOtpSender only logs the pin code; it stands in for the real channels during development
+type LogOtpSender struct{}
+
+func (s LogOtpSender) Channel() string {
+	return common.OTP_CHANNEL_LOG
+}
+
+func (s LogOtpSender) SendOtp(to OtpRecipient, language, pinCode string) (string, error) {
+	log.Printf("otp for %s (%s): %s", to.Phone, to.Email, pinCode)
+	return "", nil
+}
+
+type OtpDeliveryResult struct {
+	Channel   string
+	MessageID string
+}
+
+// OtpDelivery sends pin codes over an ordered list of channels
+type OtpDelivery struct {
+	senders []OtpSender
+}
+
+func NewOtpDelivery(senders ...OtpSender) *OtpDelivery {
+	return &OtpDelivery{senders: senders}
+}
+
+// NewOtpDeliveryFromEnv builds the delivery from the comma-separated OTP_CHANNELS variable,
+// the email channel sends through mailer
+func NewOtpDeliveryFromEnv(mailer Mailer) *OtpDelivery {
+	channels, ok := os.LookupEnv("OTP_CHANNELS")
+	if !ok {
+		channels = common.OTP_CHANNEL_WHATSAPP
+	}
+
+	var senders []OtpSender
+	for _, channel := range strings.Split(channels, ",") {
+		switch strings.TrimSpace(channel) {
+		case common.OTP_CHANNEL_WHATSAPP:
+			senders = append(senders, WhatsappOtpSender{})
+		case common.OTP_CHANNEL_SMS:
+			senders = append(senders, SmsOtpSender{})
+		case common.OTP_CHANNEL_EMAIL:
+			senders = append(senders, EmailOtpSender{Mailer: mailer})
+		case common.OTP_CHANNEL_LOG:
+			senders = append(senders, LogOtpSender{})
+		default:
+			log.Println("unknown otp channel: ", channel)
+		}
+	}
+
+	return NewOtpDelivery(senders...)
+}
+
+// Send tries the preferred channel first, then falls back on the other channels in order
+func (d *OtpDelivery) Send(preferred string, to OtpRecipient, language, pinCode string) (*OtpDeliveryResult, error) {
+	senders := make([]OtpSender, 0, len(d.senders))
+	for _, sender := range d.senders {
+		if sender.Channel() == preferred {
+			senders = append(senders, sender)
+		}
+	}
+	for _, sender := range d.senders {
+		if sender.Channel() != preferred {
+			senders = append(senders, sender)
+		}
+	}
+
+	err := fmt.Errorf("no otp channel configured")
+	for _, sender := range senders {
+		var messageID string
+		messageID, err = sender.SendOtp(to, language, pinCode)
+		if err == nil {
+			return &OtpDeliveryResult{
+				Channel:   sender.Channel(),
+				MessageID: messageID,
+			}, nil
+		}
+
+		log.Printf("error when sending the otp over %s: %s", sender.Channel(), err)
+	}
+
+	return nil, err
+}
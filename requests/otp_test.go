@@ -0,0 +1,72 @@
+package requests_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/requests"
+)
+
+type fakeSender struct {
+	channel string
+	err     error
+	calls   *[]string
+}
+
+func (s fakeSender) Channel() string {
+	return s.channel
+}
+
+func (s fakeSender) SendOtp(to requests.OtpRecipient, language, pinCode string) (string, error) {
+	*s.calls = append(*s.calls, s.channel)
+	if s.err != nil {
+		return "", s.err
+	}
+	return s.channel + "-id", nil
+}
+
+func TestOtpDeliverySend(t *testing.T) {
+	t.Run("uses the preferred channel first", func(t *testing.T) {
+		is := is.New(t)
+		var calls []string
+		d := requests.NewOtpDelivery(
+			fakeSender{channel: "whatsapp", calls: &calls},
+			fakeSender{channel: "sms", calls: &calls},
+		)
+
+		res, err := d.Send("sms", requests.OtpRecipient{Phone: "237690000000"}, "en", "1234")
+		is.NoErr(err)
+		is.Equal(res.Channel, "sms")
+		is.Equal(res.MessageID, "sms-id")
+		is.Equal(calls, []string{"sms"})
+	})
+
+	t.Run("falls back on the next channel in order", func(t *testing.T) {
+		is := is.New(t)
+		var calls []string
+		d := requests.NewOtpDelivery(
+			fakeSender{channel: "whatsapp", calls: &calls},
+			fakeSender{channel: "sms", err: errors.New("down"), calls: &calls},
+			fakeSender{channel: "email", calls: &calls},
+		)
+
+		res, err := d.Send("sms", requests.OtpRecipient{Phone: "237690000000"}, "en", "1234")
+		is.NoErr(err)
+		is.Equal(res.Channel, "whatsapp")
+		is.Equal(calls, []string{"sms", "whatsapp"})
+	})
+
+	t.Run("fails when every channel fails", func(t *testing.T) {
+		is := is.New(t)
+		var calls []string
+		d := requests.NewOtpDelivery(
+			fakeSender{channel: "whatsapp", err: errors.New("down"), calls: &calls},
+			fakeSender{channel: "sms", err: errors.New("down"), calls: &calls},
+		)
+
+		_, err := d.Send("", requests.OtpRecipient{Phone: "237690000000"}, "en", "1234")
+		is.True(err != nil)
+		is.Equal(calls, []string{"whatsapp", "sms"})
+	})
+}
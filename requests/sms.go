@@ -0,0 +1,75 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+
+	"tschwaa.com/api/utils"
+)
+
+type SmsSendMessageRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+type SmsSendMessageResponse struct {
+	MessageID string `json:"message_id"`
+}
+
+// SendSms sends a text message through the SMS gateway configured by the SMS_* variables
+func SendSms(to, text string) (*SmsSendMessageResponse, error) {
+	requestUrl := os.Getenv("SMS_API_URL")
+	if requestUrl == "" {
+		return nil, fmt.Errorf("no sms gateway configured")
+	}
+
+	jsonBody, err := json.Marshal(SmsSendMessageRequest{
+		From: os.Getenv("SMS_SENDER"),
+		To:   to,
+		Text: text,
+	})
+	if err != nil {
+		return nil, utils.Fail("client: could not encode sms", "ERR_SSMS_01", err)
+	}
+
+	req, err := http.NewRequest(
+		http.MethodPost,
+		requestUrl,
+		bytes.NewReader(jsonBody),
+	)
+	if err != nil {
+		return nil, utils.Fail("client: could not create request", "ERR_SSMS_02", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", os.Getenv("SMS_API_KEY")))
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, utils.Fail("client: error making http request", "ERR_SSMS_03", err)
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, utils.Fail("client: could not read response body", "ERR_SSMS_04", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		log.Println("error when sending sms: ", string(resBody))
+		return nil, fmt.Errorf("unable to send sms")
+	}
+
+	var data SmsSendMessageResponse
+	err = json.Unmarshal(resBody, &data)
+	if err != nil {
+		return nil, utils.Fail("error when unmarshalling response body", "ERR_SSMS_05", err)
+	}
+
+	return &data, nil
+}
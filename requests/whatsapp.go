@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"tschwaa.com/api/models"
 	"tschwaa.com/api/utils"
@@ -56,14 +57,14 @@ func SendMessageText(to, message string) (*WhatsappSendMessageResponse, error) {
 	}
 
 	log.Println("Client: got response!")
-	log.Println("client: status code: %d", res.StatusCode)
+	log.Printf("client: status code: %d\n", res.StatusCode)
 
 	resBody, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, fmt.Errorf("client: could not read response body: %s", err)
 	}
 
-	log.Println("client: response body: %s", string(resBody))
+	log.Printf("client: response body: %s\n", string(resBody))
 
 	var data WhatsappSendMessageResponse
 	err = json.Unmarshal(resBody, &data)
@@ -117,14 +118,14 @@ func sendMessageTextFromTemplate(to, template, language, components string) (*Wh
 	}
 
 	log.Println("client: got response!")
-	log.Println("client: status code: %d", res.StatusCode)
+	log.Printf("client: status code: %d\n", res.StatusCode)
 
 	resBody, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, utils.Fail("client: could not read response body", "ERR_SMSG_TPL_02", err)
 	}
 
-	log.Println("client: response body: %s", string(resBody))
+	log.Printf("client: response body: %s\n", string(resBody))
 
 	if res.StatusCode != http.StatusOK {
 		log.Println("\nError: \n\n", string(resBody))
@@ -215,3 +216,71 @@ func SendInvitationToJoinOrganization(member models.Member, organizationName, jo
 	]`, getMemberName(member, language), organizationName, linkToJoin, organizationReps)
 	return sendMessageTextFromTemplate(member.Phone, template, language, parameters)
 }
+
+func SendSignInLockedAlert(to, language string, lockedUntil time.Time) (*WhatsappSendMessageResponse, error) {
+	parameters := fmt.Sprintf(`[
+		{
+			"type": "body",
+			"parameters": [
+				{
+					"type": "text",
+					"text": "%s"
+				}
+			]
+		}
+	]`, lockedUntil.UTC().Format("15:04 MST"))
+	template := "tschwaa_sign_in_locked"
+
+	return sendMessageTextFromTemplate(to, template, language, parameters)
+}
+
+func SendPhoneRecoveryAlert(to, language string, availableAt time.Time) (*WhatsappSendMessageResponse, error) {
+	parameters := fmt.Sprintf(`[
+		{
+			"type": "body",
+			"parameters": [
+				{
+					"type": "text",
+					"text": "%s"
+				}
+			]
+		}
+	]`, availableAt.UTC().Format("2006-01-02 15:04 MST"))
+	template := "tschwaa_phone_recovery"
+
+	return sendMessageTextFromTemplate(to, template, language, parameters)
+}
+
+func SendAccountDeletionCancelledAlert(to, language string) (*WhatsappSendMessageResponse, error) {
+	template := "tschwaa_account_deletion_cancelled"
+
+	return sendMessageTextFromTemplate(to, template, language, "[]")
+}
+
+func SendMagicLink(to, language, link, token string) (*WhatsappSendMessageResponse, error) {
+	parameters := fmt.Sprintf(`[
+		{
+			"type": "body",
+			"parameters": [
+				{
+					"type": "text",
+					"text": "%s"
+				}
+			]
+		},
+		{
+			"type": "button",
+			"sub_type": "url",
+			"index": 0,
+			"parameters": [
+				{
+					"type": "text",
+					"text": "%s"
+				}
+			]
+		}
+	]`, link, token)
+	template := "tschwaa_magic_link"
+
+	return sendMessageTextFromTemplate(to, template, language, parameters)
+}
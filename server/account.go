@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/storage"
+)
+
+// deleteDueAccounts carries out every account deletion whose grace period is over
+func (s *Server) deleteDueAccounts(ctx context.Context) {
+	deletions, err := s.database.Storage.ListDueAccountDeletions(ctx, time.Now().UTC())
+	if err != nil {
+		s.log.Info("Error listing due account deletions", zap.Error(err))
+		return
+	}
+
+	for _, deletion := range deletions {
+		member, err := s.database.Storage.GetMemberByID(ctx, deletion.MemberID)
+		if err != nil || member == nil {
+			s.log.Info("Error getting member of account deletion",
+				zap.Uint64("deletion", deletion.ID), zap.Uint64("member", deletion.MemberID), zap.Error(err))
+			continue
+		}
+
+		err = s.database.Storage.DeleteAccountTx(ctx, storage.DeleteAccountParams{
+			AccountDeletionID: deletion.ID,
+			MemberID:          member.ID,
+			Phone:             member.Phone,
+			Email:             member.Email,
+		})
+		if errors.Is(err, storage.ErrLastAdmin) {
+			// the member became the last admin of an organization after scheduling, the deletion
+			// would fail on every tick: it is cancelled and the member is told to schedule it again
+			s.cancelAccountDeletion(ctx, deletion, member.Phone)
+			continue
+		}
+		if err != nil {
+			s.log.Info("Error deleting account",
+				zap.Uint64("deletion", deletion.ID), zap.Uint64("member", deletion.MemberID), zap.Error(err))
+			continue
+		}
+
+		s.log.Info("Deleted account", zap.Uint64("member", deletion.MemberID))
+	}
+}
+
+// cancelAccountDeletion cancels a deletion that cannot be carried out and tells the member
+func (s *Server) cancelAccountDeletion(ctx context.Context, deletion *models.AccountDeletion, phone string) {
+	if err := s.database.Storage.CancelAccountDeletion(ctx, deletion.ID); err != nil {
+		s.log.Info("Error cancelling account deletion",
+			zap.Uint64("deletion", deletion.ID), zap.Uint64("member", deletion.MemberID), zap.Error(err))
+		return
+	}
+	s.log.Info("Cancelled account deletion of the last admin of an organization",
+		zap.Uint64("deletion", deletion.ID), zap.Uint64("member", deletion.MemberID))
+
+	if err := s.accountDeletionNotifier.NotifyAccountDeletionCancelled(phone, deletion.Language); err != nil {
+		s.log.Info("Error notifying the cancelled account deletion",
+			zap.Uint64("member", deletion.MemberID), zap.Error(err))
+	}
+}
+
+// runAccountDeletions checks for due account deletions on every tick until the context is done
+func (s *Server) runAccountDeletions(ctx context.Context) {
+	ticker := time.NewTicker(s.accountDeletionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.deleteDueAccounts(ctx)
+		}
+	}
+}
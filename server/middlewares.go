@@ -2,12 +2,20 @@ package server
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/handlers"
 	"tschwaa.com/api/services"
+	"tschwaa.com/api/storage"
 )
 
 func (s *Server) requestLoggerMiddleware(next http.Handler) http.Handler {
@@ -42,10 +50,7 @@ func (s *Server) convertJWTTokenToMember(next http.Handler) http.Handler {
 			next.ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
-		data := claims.(map[string]interface{})
-		email := data["Email"].(string)
-
-		user, err := s.database.Storage.GetMemberByEmail(ctx, email)
+		user, err := services.GetMemberFromClaims(ctx, s.database.Storage, claims)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
@@ -60,3 +65,202 @@ func (s *Server) convertJWTTokenToMember(next http.Handler) http.Handler {
 		next.ServeHTTP(w, req.WithContext(ctx))
 	})
 }
+
+// requireMembership loads the membership of the current member in the organization of the route
+// and turns away whoever has not joined it
+func (s *Server) requireMembership(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		// an API key has no membership, it belongs to the organization itself
+		if apiKey := handlers.GetCurrentAPIKey(req); apiKey != nil {
+			orgID, _ := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+			if apiKey.OrganizationID != orgID {
+				log.Printf("api key[%d] does not belong to organization[%d]", apiKey.ID, orgID)
+				http.Error(w, "ERR_RBAC_110", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		member := handlers.GetCurrentMember(req)
+		if member == nil {
+			http.Error(w, "ERR_RBAC_101", http.StatusUnauthorized)
+			return
+		}
+
+		orgID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		if err != nil {
+			http.Error(w, "ERR_RBAC_102", http.StatusBadRequest)
+			return
+		}
+
+		membership, err := s.database.Storage.DoesMembershipExist(ctx, storage.DoesMembershipExistParams{
+			MemberID:       member.ID,
+			OrganizationID: orgID,
+		})
+		if err != nil {
+			log.Printf("error when getting membership of member[%d] in organization[%d]: %s", member.ID, orgID, err)
+			http.Error(w, "ERR_RBAC_103", http.StatusBadRequest)
+			return
+		}
+		if membership == nil || !membership.Joined {
+			log.Printf("member[%d] is not a member of organization[%d]", member.ID, orgID)
+			http.Error(w, "ERR_RBAC_104", http.StatusForbidden)
+			return
+		}
+
+		// the officers and the admins of an organization requiring it must have signed in with a second factor
+		if membership.Role != common.MEMBERSHIP_ROLE_MEMBER {
+			required, err := s.database.Storage.IsTwoFactorRequired(ctx, orgID)
+			if err != nil {
+				log.Printf("error when getting the two-factor policy of organization[%d]: %s", orgID, err)
+				http.Error(w, "ERR_RBAC_113", http.StatusBadRequest)
+				return
+			}
+			if info, _ := services.GetTokenInfo(ctx); required && !info.TwoFactor {
+				log.Printf("member[%d] has not signed in with a second factor for organization[%d]", member.ID, orgID)
+				http.Error(w, "ERR_RBAC_112", http.StatusForbidden)
+				return
+			}
+		}
+
+		ctx = context.WithValue(ctx, services.JWTMembershipKey, membership)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// requireRole only lets through the members whose role in the organization is one of roles.
+// It runs after requireMembership.
+func (s *Server) requireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			membership := handlers.GetCurrentMembership(req)
+			if membership == nil {
+				http.Error(w, "ERR_RBAC_105", http.StatusForbidden)
+				return
+			}
+
+			for _, role := range roles {
+				if membership.Role == role {
+					next.ServeHTTP(w, req)
+					return
+				}
+			}
+
+			log.Printf("membership[%d] with the role %s can not access %s %s", membership.ID, membership.Role, req.Method, req.URL.Path)
+			http.Error(w, "ERR_RBAC_106", http.StatusForbidden)
+		})
+	}
+}
+
+// requirePermission only lets through the API keys granted the permission. Members are
+// not concerned, and API keys never get past requireRole since they have no membership.
+func (s *Server) requirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			apiKey := handlers.GetCurrentAPIKey(req)
+			if apiKey != nil && !apiKey.HasPermission(permission) {
+				log.Printf("api key[%d] is not granted %s to access %s %s", apiKey.ID, permission, req.Method, req.URL.Path)
+				http.Error(w, "ERR_RBAC_111", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// authenticateAPIKey lets an organization integration in with its API key instead of a token.
+// A key only reads, and only the routes of its own organization.
+func (s *Server) authenticateAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		key := req.Header.Get(services.APIKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		apiKey, err := s.database.Storage.GetAPIKeyByHash(ctx, services.HashOpaqueToken(key))
+		if err != nil {
+			log.Println("error when getting the api key: ", err)
+			http.Error(w, "ERR_APIK_101", http.StatusUnauthorized)
+			return
+		}
+		if apiKey == nil {
+			http.Error(w, "ERR_APIK_102", http.StatusUnauthorized)
+			return
+		}
+
+		if req.Method != http.MethodGet {
+			http.Error(w, "ERR_APIK_103", http.StatusForbidden)
+			return
+		}
+		orgPath := fmt.Sprintf("/orgs/%d", apiKey.OrganizationID)
+		if req.URL.Path != orgPath && !strings.HasPrefix(req.URL.Path, orgPath+"/") {
+			log.Printf("api key[%d] can not access %s", apiKey.ID, req.URL.Path)
+			http.Error(w, "ERR_APIK_104", http.StatusForbidden)
+			return
+		}
+
+		if err := s.database.Storage.TouchAPIKey(ctx, apiKey.ID); err != nil {
+			log.Printf("error when touching api key[%d]: %s", apiKey.ID, err)
+		}
+
+		// the key stands in for the token, whatever the token of the request was
+		ctx = context.WithValue(ctx, services.JWTAPIKeyKey, apiKey)
+		ctx = context.WithValue(ctx, services.JWTClaimsKey, nil)
+		ctx = context.WithValue(ctx, services.JWTErrorKey, nil)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// requireSessionOfOrganization turns away the requests on a session of another organization
+func (s *Server) requireSessionOfOrganization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		orgID, _ := strconv.ParseUint(chi.URLParamFromCtx(ctx, "orgID"), 10, 64)
+		sessionID, err := strconv.ParseUint(chi.URLParamFromCtx(ctx, "sessionID"), 10, 64)
+		if err != nil {
+			http.Error(w, "ERR_RBAC_107", http.StatusBadRequest)
+			return
+		}
+
+		_, err = s.database.Storage.GetSession(ctx, storage.GetSessionParams{
+			OrganizationID: orgID,
+			SessionID:      sessionID,
+		})
+		if err == sql.ErrNoRows {
+			http.Error(w, "ERR_RBAC_108", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("error when getting session[%d] of organization[%d]: %s", sessionID, orgID, err)
+			http.Error(w, "ERR_RBAC_109", http.StatusBadRequest)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// requireVerifiedEmail turns away the members whose token says their email address is not verified yet.
+// Tokens issued before the verification was introduced say nothing and go through, and so do the
+// members without an email address, who joined through an invitation and have nothing to verify.
+func (s *Server) requireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		claims, _ := req.Context().Value(services.JWTClaimsKey).(map[string]interface{})
+		email, _ := claims["Email"].(string)
+		if verified, ok := claims["EmailVerified"].(bool); ok && !verified && email != "" {
+			http.Error(w, "ERR_EMVF_101", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
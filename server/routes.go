@@ -5,6 +5,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
+	"tschwaa.com/api/common"
 	"tschwaa.com/api/handlers"
 	"tschwaa.com/api/services"
 )
@@ -34,54 +35,159 @@ func (s *Server) setupRoutes() {
 	s.mux.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:5173", "http://www.tschwaa.local"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", services.ClientTypeHeader, services.APIKeyHeader},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
+	services.UseTokenRevocationStore(s.database.Storage)
 	s.mux.Use(services.Verifier)
 	s.mux.Use(services.ParseJWTToken)
 
 	// Protected Routes
 	s.mux.Group(func(r chi.Router) {
+		r.Use(s.authenticateAPIKey)
 		r.Use(services.Authenticator)
 		r.Use(s.convertJWTTokenToMember)
 
 		handlers.GetCurrentUser(r)
+		handlers.ExportAccount(r, s.database.Storage)
+		handlers.ScheduleAccountDeletion(r, s.database.Storage)
+
+		r.Route("/user/deletion", func(r chi.Router) {
+			handlers.GetAccountDeletion(r, s.database.Storage)
+			handlers.CancelAccountDeletion(r, s.database.Storage)
+		})
+
+		r.Route("/user/sessions", func(r chi.Router) {
+			handlers.ListLogins(r, s.database.Storage)
+			handlers.RevokeLogin(r, s.database.Storage)
+		})
+
+		r.Route("/user/passkeys", func(r chi.Router) {
+			handlers.ListPasskeys(r, s.database.Storage)
+			handlers.RenamePasskey(r, s.database.Storage)
+			handlers.DeletePasskey(r, s.database.Storage)
+		})
+
+		r.Route("/user/2fa", func(r chi.Router) {
+			handlers.GetTwoFactorStatus(r, s.database.Storage)
+			handlers.EnrollTwoFactor(r, s.database.Storage)
+			handlers.ConfirmTwoFactor(r, s.database.Storage)
+			handlers.RegenerateRecoveryCodes(r, s.database.Storage)
+			handlers.DisableTwoFactor(r, s.database.Storage)
+		})
+
+		r.Route("/user/email", func(r chi.Router) {
+			handlers.ResendEmailVerification(r, s.database.Storage, s.mailer)
+		})
+
+		r.Route("/user/phone", func(r chi.Router) {
+			handlers.RequestPhoneChange(r, s.database.Storage, s.otpDelivery)
+			handlers.ConfirmPhoneChange(r, s.database.Storage)
+			handlers.CancelPhoneChange(r, s.database.Storage)
+		})
 
 		// Organization
 		r.Route("/orgs", func(r chi.Router) {
+			// members with an unverified email address only get to their own account
+			r.Use(s.requireVerifiedEmail)
+
 			handlers.CreateOrganization(r, s.database.Storage)
 			handlers.ListOrganizations(r, s.database.Storage)
 
 			r.Route("/{orgID}", func(r chi.Router) {
+				// Every route of an organization is for its members only, some of them
+				// only for the officers or the admins. The API keys of the organization
+				// read the routes whose permission they have been granted.
+				r.Use(s.requireMembership)
+				officers := s.requireRole(common.MEMBERSHIP_ROLE_ADMIN, common.MEMBERSHIP_ROLE_OFFICER)
+				admins := s.requireRole(common.MEMBERSHIP_ROLE_ADMIN)
+				members := s.requireRole(common.MEMBERSHIP_ROLE_ADMIN, common.MEMBERSHIP_ROLE_OFFICER, common.MEMBERSHIP_ROLE_MEMBER)
+				readOrganization := s.requirePermission(common.API_KEY_PERMISSION_READ_ORGANIZATION)
+				readMembers := s.requirePermission(common.API_KEY_PERMISSION_READ_MEMBERS)
+				readSessions := s.requirePermission(common.API_KEY_PERMISSION_READ_SESSIONS)
+				readContributions := s.requirePermission(common.API_KEY_PERMISSION_READ_CONTRIBUTIONS)
 
 				r.Route("/sessions", func(r chi.Router) {
-					handlers.CreateSession(r, s.database.Storage)
-					handlers.GetCurrentSession(r, s.database.Storage)
+					handlers.CreateSession(r.With(officers), s.database.Storage)
+					handlers.GetCurrentSession(r.With(readSessions), s.database.Storage)
+					handlers.GetMeetingsOfCurrentSession(r.With(readSessions), s.database.Storage)
 
 					r.Route("/{sessionID}", func(r chi.Router) {
+						r.Use(s.requireSessionOfOrganization)
 
 						r.Route("/members", func(r chi.Router) {
-							handlers.GetMembersOfSession(r, s.database.Storage)
-							handlers.AddMemberToSession(r, s.database.Storage)
-							handlers.UpdateSessionMembers(r, s.database.Storage)
-							handlers.RemoveMemberFromSession(r, s.database.Storage)
+							handlers.GetMembersOfSession(r.With(readSessions), s.database.Storage)
+							handlers.AddMemberToSession(r.With(officers), s.database.Storage)
+							handlers.UpdateSessionMembers(r.With(officers), s.database.Storage)
+							handlers.RemoveMemberFromSession(r.With(officers), s.database.Storage)
 						})
 
 						r.Route("/place", func(r chi.Router) {
-							handlers.GetPlaceOfSession(r, s.database.Storage)
-							handlers.UpdatePlaceOfSession(r, s.database.Storage)
-							handlers.ChangePlaceOfSession(r, s.database.Storage)
+							handlers.GetPlaceOfSession(r.With(readSessions), s.database.Storage)
+							handlers.UpdatePlaceOfSession(r.With(officers), s.database.Storage)
+							handlers.ChangePlaceOfSession(r.With(officers), s.database.Storage)
+						})
+
+						r.Route("/contributions", func(r chi.Router) {
+							handlers.ListContributionPlans(r.With(readContributions), s.database.Storage)
+							handlers.CreateContributionPlan(r.With(officers), s.database.Storage)
+							handlers.UpdateContributionPlan(r.With(officers), s.database.Storage)
+							handlers.ListContributions(r.With(readContributions), s.database.Storage)
+							handlers.RecordContribution(r.With(officers), s.database.Storage)
+							handlers.DeleteContribution(r.With(officers), s.database.Storage)
+							handlers.GetContributionBalances(r.With(readContributions), s.database.Storage)
+						})
+
+						r.Route("/rotation", func(r chi.Router) {
+							handlers.GetBeneficiaryRotation(r.With(readSessions), s.database.Storage)
+							handlers.GenerateBeneficiaryRotation(r.With(officers), s.database.Storage)
+							handlers.GetCurrentBeneficiary(r.With(readSessions), s.database.Storage)
+							handlers.PayBeneficiary(r.With(officers), s.database.Storage)
+							handlers.ListBeneficiarySwaps(r.With(readSessions), s.database.Storage)
+							handlers.RequestBeneficiarySwap(r.With(members), s.database.Storage)
+							handlers.ApproveBeneficiarySwap(r.With(members), s.database.Storage)
+							handlers.RejectBeneficiarySwap(r.With(members), s.database.Storage)
+						})
+
+						r.Route("/auctions", func(r chi.Router) {
+							handlers.GetAuctionMode(r.With(readSessions), s.database.Storage)
+							handlers.UpdateAuctionMode(r.With(officers), s.database.Storage)
+							handlers.ListAuctions(r.With(readSessions), s.database.Storage)
+							handlers.OpenAuction(r.With(officers), s.database.Storage)
+							handlers.GetAuction(r.With(readSessions), s.database.Storage)
+							handlers.PlaceAuctionBid(r.With(members), s.database.Storage)
+							handlers.CloseAuction(r.With(officers), s.database.Storage)
+						})
+
+						r.Route("/meetings", func(r chi.Router) {
+							handlers.GetMeetingRecurrence(r.With(readSessions), s.database.Storage)
+							handlers.SetMeetingRecurrence(r.With(officers), s.database.Storage)
+							handlers.ListMeetings(r.With(readSessions), s.database.Storage)
+							handlers.CancelMeeting(r.With(officers), s.database.Storage)
+							handlers.RescheduleMeeting(r.With(officers), s.database.Storage)
 						})
 
 					})
 				})
 
-				handlers.GetOrganization(r, s.database.Storage)
-				handlers.GetOrganizationMembers(r, s.database.Storage)
-				handlers.InviteMembersIntoOrganization(r, s.database.Storage)
+				handlers.GetOrganization(r.With(readOrganization), s.database.Storage)
+				handlers.GetOrganizationMembers(r.With(readMembers), s.database.Storage)
+				handlers.InviteMembersIntoOrganization(r.With(officers), s.database.Storage)
+				handlers.SignOutMemberEverywhere(r.With(admins), s.database.Storage)
+				handlers.ApprovePhoneRecovery(r.With(admins), s.database.Storage, s.phoneRecoveryNotifier)
+				handlers.GetTwoFactorPolicy(r.With(readOrganization), s.database.Storage)
+				handlers.UpdateTwoFactorPolicy(r.With(admins), s.database.Storage)
+
+				r.Route("/api-keys", func(r chi.Router) {
+					r.Use(admins)
+
+					handlers.CreateAPIKey(r, s.database.Storage)
+					handlers.ListAPIKeys(r, s.database.Storage)
+					handlers.RevokeAPIKey(r, s.database.Storage)
+				})
 			})
 		})
 	})
@@ -91,23 +197,37 @@ func (s *Server) setupRoutes() {
 		r.Use(s.convertJWTTokenToMember)
 
 		handlers.Health(s.mux)
+		handlers.JWKS(s.mux)
 
 		r.Route("/auth/", func(r chi.Router) {
-			handlers.SignUp(r, s.database.Storage)
-			handlers.SignIn(r, s.database.Storage)
-			handlers.GetOtp(r, s.database.Storage)
+			handlers.SignUp(r, s.database.Storage, s.mailer)
+			handlers.SignIn(r, s.database.Storage, s.lockoutNotifier)
+			handlers.SignOut(r, s.database.Storage)
+			handlers.GetOtp(r, s.database.Storage, s.otpDelivery)
 			handlers.CheckOtp(r, s.database.Storage)
-			handlers.ResendOtp(r, s.database.Storage)
+			handlers.ResendOtp(r, s.database.Storage, s.otpDelivery)
+			handlers.ForgotPassword(r, s.database.Storage, s.otpDelivery)
+			handlers.ResetPassword(r, s.database.Storage)
+			handlers.VerifyEmail(r, s.database.Storage)
+			handlers.GetPhoneRecoveryOtp(r, s.database.Storage, s.otpDelivery)
+			handlers.RecoverPhone(r, s.database.Storage)
+			handlers.RequestMagicLink(r, s.database.Storage)
+			handlers.VerifyMagicLink(r, s.database.Storage)
+			handlers.BeginPasskeyRegistration(r, s.database.Storage)
+			handlers.FinishPasskeyRegistration(r, s.database.Storage)
+			handlers.BeginPasskeyLogin(r, s.database.Storage)
+			handlers.FinishPasskeyLogin(r, s.database.Storage)
+			handlers.VerifyTwoFactor(r, s.database.Storage)
 		})
 
 		r.Route("/token", func(r chi.Router) {
-			handlers.Refresh(r)
-			handlers.IsTokenValid(r)
+			handlers.Refresh(r, s.database.Storage)
+			handlers.IsTokenValid(r, s.database.Storage)
 		})
 
 		r.Route("/join/", func(r chi.Router) {
 			handlers.GetInvitation(r, s.database.Storage)
-			handlers.JoinOrganization(r, s.database.Storage)
+			handlers.JoinOrganization(r, s.database.Storage, s.mailer)
 		})
 
 	})
@@ -11,37 +11,74 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
+	"tschwaa.com/api/requests"
 	"tschwaa.com/api/storage"
 )
 
 type Server struct {
-	address  string
-	database *storage.Database
-	log      *zap.Logger
-	mux      chi.Router
-	server   *http.Server
+	accountDeletionInterval time.Duration
+	accountDeletionNotifier requests.AccountDeletionNotifier
+	address                 string
+	database                *storage.Database
+	lockoutNotifier         requests.LockoutNotifier
+	log                     *zap.Logger
+	mailer                  requests.Mailer
+	mux                     chi.Router
+	otpDelivery             *requests.OtpDelivery
+	phoneRecoveryNotifier   requests.PhoneRecoveryNotifier
+	server                  *http.Server
+	stopJobs                context.CancelFunc
 }
 
+// Options of the server, Mailer is required: main builds it from the environment and refuses
+// to start when no mailer is configured
 type Options struct {
-	Database *storage.Database
-	Host     string
-	Log      *zap.Logger
-	Port     int
+	AccountDeletionInterval time.Duration
+	AccountDeletionNotifier requests.AccountDeletionNotifier
+	Database                *storage.Database
+	Host                    string
+	LockoutNotifier         requests.LockoutNotifier
+	Log                     *zap.Logger
+	Mailer                  requests.Mailer
+	OtpDelivery             *requests.OtpDelivery
+	PhoneRecoveryNotifier   requests.PhoneRecoveryNotifier
+	Port                    int
 }
 
 func New(opts Options) *Server {
 	if opts.Log == nil {
 		opts.Log = zap.NewNop()
 	}
+	if opts.OtpDelivery == nil {
+		opts.OtpDelivery = requests.NewOtpDeliveryFromEnv(opts.Mailer)
+	}
+	if opts.LockoutNotifier == nil {
+		opts.LockoutNotifier = requests.WhatsappLockoutNotifier{}
+	}
+	if opts.PhoneRecoveryNotifier == nil {
+		opts.PhoneRecoveryNotifier = requests.WhatsappPhoneRecoveryNotifier{}
+	}
+	if opts.AccountDeletionNotifier == nil {
+		opts.AccountDeletionNotifier = requests.WhatsappAccountDeletionNotifier{}
+	}
+	if opts.AccountDeletionInterval == 0 {
+		opts.AccountDeletionInterval = time.Hour
+	}
 
 	address := net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port))
 	mux := chi.NewMux()
 
 	return &Server{
-		address:  address,
-		database: opts.Database,
-		log:      opts.Log,
-		mux:      mux,
+		accountDeletionInterval: opts.AccountDeletionInterval,
+		accountDeletionNotifier: opts.AccountDeletionNotifier,
+		address:                 address,
+		database:                opts.Database,
+		lockoutNotifier:         opts.LockoutNotifier,
+		log:                     opts.Log,
+		mailer:                  opts.Mailer,
+		mux:                     mux,
+		otpDelivery:             opts.OtpDelivery,
+		phoneRecoveryNotifier:   opts.PhoneRecoveryNotifier,
 		server: &http.Server{
 			Addr:              address,
 			Handler:           mux,
@@ -61,6 +98,10 @@ func (s *Server) Start() error {
 
 	s.setupRoutes()
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	s.stopJobs = stopJobs
+	go s.runAccountDeletions(jobsCtx)
+
 	s.log.Info("Starting on", zap.String("address", s.address))
 	if err := s.server.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("error starting server: %w", err)
@@ -72,6 +113,10 @@ func (s *Server) Start() error {
 func (s *Server) Stop() error {
 	s.log.Info("Stopping")
 
+	if s.stopJobs != nil {
+		s.stopJobs()
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var ErrMalformedCBOR = errors.New("malformed cbor")
+
+// cborMaxDepth bounds the nesting of the CBOR items sent by authenticators
+const cborMaxDepth = 8
+
+// cborDecode decodes the first CBOR item of data and returns it with the bytes left after it.
+// It only knows the definite-length items WebAuthn uses: integers, byte and text strings,
+// arrays, maps, booleans and null. Integers come out as int64, maps as map[interface{}]interface{}.
+func cborDecode(data []byte) (interface{}, []byte, error) {
+	return cborDecodeItem(data, 0)
+}
+
+func cborDecodeItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > cborMaxDepth || len(data) == 0 {
+		return nil, nil, ErrMalformedCBOR
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22:
+			return nil, data, nil
+		default:
+			return nil, nil, ErrMalformedCBOR
+		}
+	}
+
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info == 24 && len(data) >= 1:
+		arg, data = uint64(data[0]), data[1:]
+	case info == 25 && len(data) >= 2:
+		arg, data = uint64(binary.BigEndian.Uint16(data)), data[2:]
+	case info == 26 && len(data) >= 4:
+		arg, data = uint64(binary.BigEndian.Uint32(data)), data[4:]
+	case info == 27 && len(data) >= 8:
+		arg, data = binary.BigEndian.Uint64(data), data[8:]
+	default:
+		return nil, nil, ErrMalformedCBOR
+	}
+
+	switch major {
+	case 0:
+		if arg > 1<<63-1 {
+			return nil, nil, ErrMalformedCBOR
+		}
+		return int64(arg), data, nil
+	case 1:
+		if arg > 1<<63-1 {
+			return nil, nil, ErrMalformedCBOR
+		}
+		return -1 - int64(arg), data, nil
+	case 2, 3:
+		if arg > uint64(len(data)) {
+			return nil, nil, ErrMalformedCBOR
+		}
+		b := make([]byte, arg)
+		copy(b, data[:arg])
+		if major == 3 {
+			return string(b), data[arg:], nil
+		}
+		return b, data[arg:], nil
+	case 4:
+		if arg > uint64(len(data)) {
+			return nil, nil, ErrMalformedCBOR
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item interface{}
+			var err error
+			item, data, err = cborDecodeItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if arg > uint64(len(data)) {
+			return nil, nil, ErrMalformedCBOR
+		}
+		items := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value interface{}
+			var err error
+			key, data, err = cborDecodeItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, ErrMalformedCBOR
+			}
+			value, data, err = cborDecodeItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items[key] = value
+		}
+		return items, data, nil
+	}
+
+	return nil, nil, ErrMalformedCBOR
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
@@ -10,17 +12,58 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/storage"
 )
 
 type contextKey struct {
 	name string
 }
 
+// ClientTypeHeader lets a client ask for its tokens in the response body instead of cookies
+const ClientTypeHeader = "X-Client-Type"
+
+// APIKeyHeader carries the API key of an organization integration
+const APIKeyHeader = "X-API-Key"
+
 var JWTMemberKey *contextKey
 var JWTClaimsKey *contextKey
 var JWTTokenKey *contextKey
 var JWTErrorKey *contextKey
-var jwtSecretKey []byte // (os.Getenv("JWT_SECRET"))
+var JWTInfoKey *contextKey
+var JWTMembershipKey *contextKey
+var JWTAPIKeyKey *contextKey
+
+// AccessTokenTTL is the lifetime of the JWT access token
+var AccessTokenTTL time.Duration
+
+// RefreshTokenTTL is the lifetime of the refresh token exchanged at /token/refresh
+var RefreshTokenTTL time.Duration
+
+// TokenInfo holds the registered claims of the token sent with the request
+type TokenInfo struct {
+	ID        string
+	MemberID  uint64
+	LoginID   uint64
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// TwoFactor tells whether the member passed a second factor when signing in
+	TwoFactor bool
+}
+
+// TokenRevocationStore tells whether a token has been revoked, either on its own,
+// with its login, or because all the tokens of its member issued before a given time were
+type TokenRevocationStore interface {
+	IsTokenRevoked(ctx context.Context, arg storage.IsTokenRevokedParams) (bool, error)
+}
+
+var revocationStore TokenRevocationStore
+
+// UseTokenRevocationStore sets the store ParseJWTToken checks revoked tokens against
+func UseTokenRevocationStore(s TokenRevocationStore) {
+	revocationStore = s
+}
 
 type TWAJWTClaims struct {
 	*jwt.RegisteredClaims
@@ -36,22 +79,36 @@ func init() {
 	JWTClaimsKey = &contextKey{"Claims"}
 	JWTTokenKey = &contextKey{"Token"}
 	JWTErrorKey = &contextKey{"Error"}
-	jwtSecretKey = []byte(os.Getenv("JWT_SECRET"))
+	JWTInfoKey = &contextKey{"Info"}
+	JWTMembershipKey = &contextKey{"Membership"}
+	JWTAPIKeyKey = &contextKey{"APIKey"}
+	keySet = NewKeySet(NewHMACKey("", []byte(os.Getenv("JWT_SECRET"))))
+	AccessTokenTTL = getDurationOrDefault("JWT_ACCESS_TOKEN_TTL", 15*time.Minute)
+	RefreshTokenTTL = getDurationOrDefault("JWT_REFRESH_TOKEN_TTL", 30*24*time.Hour)
 	// TokenAuth = jwtauth.New("HS256", []byte(os.Getenv("JWT_SECRET")), "s-tschwaa")
 }
 
-func GenerateJWTToken(data map[string]interface{}) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+// GenerateJWTToken signs an access token for the login, carrying data as its user claims
+func GenerateJWTToken(data map[string]interface{}, loginID uint64) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
 
 	now := time.Now().UTC()
-	claims := token.Claims.(jwt.MapClaims)
-	claims["exp"] = now.Add(60 * time.Hour).Unix()
+	claims := jwt.MapClaims{}
+	claims["exp"] = now.Add(AccessTokenTTL).Unix()
 	claims["iat"] = now.Unix()
+	// iat only has the precision of a second, too coarse to tell the tokens issued
+	// right after a revocation of all the tokens of the member from the ones before it
+	claims["iat_ms"] = now.UnixMilli()
 	claims["nbf"] = now.Unix()
+	claims["jti"] = jti
+	claims["sid"] = loginID
 	// claims["authorized"] = true
 	claims["user"] = data
 
-	tokenString, err := token.SignedString(jwtSecretKey)
+	tokenString, err := keySet.Sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -92,9 +149,24 @@ func TokenFromHeader(r *http.Request) (string, error) {
 	return tokenString, nil
 }
 
+// TokenFromRequest reads the token from the Authorization header when there is one,
+// and from the jwt cookie otherwise. A malformed header does not fall back on the cookie.
+func TokenFromRequest(r *http.Request) (string, error) {
+	if r.Header.Get("Authorization") != "" {
+		return TokenFromHeader(r)
+	}
+
+	return TokenFromCookie(r)
+}
+
+// UsesBearerToken tells whether the client keeps its tokens itself rather than in cookies
+func UsesBearerToken(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(ClientTypeHeader), common.CLIENT_TYPE_MOBILE)
+}
+
 func Verifier(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenString, err := TokenFromCookie(r)
+		tokenString, err := TokenFromRequest(r)
 
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, JWTTokenKey, tokenString)
@@ -103,6 +175,43 @@ func Verifier(next http.Handler) http.Handler {
 	})
 }
 
+// ValidateJWTToken runs the signature, expiry and revocation checks on the token
+// and returns its claims when it is valid
+func ValidateJWTToken(ctx context.Context, tokenString string) (jwt.MapClaims, TokenInfo, error) {
+	token, err := jwt.Parse(tokenString, keySet.Keyfunc)
+
+	if err != nil {
+		return nil, TokenInfo{}, fmt.Errorf("invalidate token: %v", err)
+	}
+
+	if token == nil || !token.Valid {
+		return nil, TokenInfo{}, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, TokenInfo{}, errors.New("invalid token claims")
+	}
+
+	info := tokenInfoFromClaims(claims)
+	if revocationStore != nil {
+		revoked, err := revocationStore.IsTokenRevoked(ctx, storage.IsTokenRevokedParams{
+			JTI:      info.ID,
+			MemberID: info.MemberID,
+			IssuedAt: info.IssuedAt,
+			LoginID:  info.LoginID,
+		})
+		if err != nil {
+			return nil, info, fmt.Errorf("error when checking token revocation: %v", err)
+		}
+		if revoked {
+			return nil, info, errors.New("revoked token")
+		}
+	}
+
+	return claims, info, nil
+}
+
 func ParseJWTToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -114,38 +223,39 @@ func ParseJWTToken(next http.Handler) http.Handler {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-
-			return jwtSecretKey, nil
-		})
-
+		claims, info, err := ValidateJWTToken(ctx, tokenString)
 		if err != nil {
-			ctx = context.WithValue(ctx, JWTErrorKey, fmt.Errorf("invalidate token: %v", err))
-			next.ServeHTTP(w, r.WithContext(ctx))
-			return
-		}
-
-		if token == nil || !token.Valid {
-			ctx = context.WithValue(ctx, JWTErrorKey, errors.New("invalid token"))
-			next.ServeHTTP(w, r.WithContext(ctx))
-			return
-		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			ctx = context.WithValue(ctx, JWTErrorKey, errors.New("invalid token claims"))
+			ctx = context.WithValue(ctx, JWTErrorKey, err)
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
 
 		ctx = context.WithValue(ctx, JWTClaimsKey, claims["user"])
+		ctx = context.WithValue(ctx, JWTInfoKey, info)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// MemberFinder looks a member up from the identity stored in the token claims
+type MemberFinder interface {
+	GetMemberByID(ctx context.Context, id uint64) (*models.Member, error)
+}
+
+// GetMemberFromClaims returns the member the "user" claims of a token resolve to. The member
+// is found by its id, which every login puts in the claims, whereas its email or phone may be empty.
+func GetMemberFromClaims(ctx context.Context, s MemberFinder, claims interface{}) (*models.Member, error) {
+	data, ok := claims.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid user claims")
+	}
+	id, _ := data["ID"].(float64)
+	if id <= 0 {
+		return nil, errors.New("no member id in the user claims")
+	}
+
+	return s.GetMemberByID(ctx, uint64(id))
+}
+
 func Authenticator(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -159,3 +269,43 @@ func Authenticator(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func tokenInfoFromClaims(claims jwt.MapClaims) TokenInfo {
+	var info TokenInfo
+
+	info.ID, _ = claims["jti"].(string)
+	if sid, ok := claims["sid"].(float64); ok {
+		info.LoginID = uint64(sid)
+	}
+	if iat, ok := claims["iat_ms"].(float64); ok {
+		info.IssuedAt = time.UnixMilli(int64(iat)).UTC()
+	} else if iat, ok := claims["iat"].(float64); ok {
+		info.IssuedAt = time.Unix(int64(iat), 0).UTC()
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		info.ExpiresAt = time.Unix(int64(exp), 0).UTC()
+	}
+	if user, ok := claims["user"].(map[string]interface{}); ok {
+		if id, ok := user["ID"].(float64); ok {
+			info.MemberID = uint64(id)
+		}
+		info.TwoFactor, _ = user["TwoFactor"].(bool)
+	}
+
+	return info
+}
+
+// GetTokenInfo returns the registered claims of the valid token sent with the request
+func GetTokenInfo(ctx context.Context) (TokenInfo, bool) {
+	info, ok := ctx.Value(JWTInfoKey).(TokenInfo)
+	return info, ok
+}
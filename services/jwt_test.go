@@ -0,0 +1,174 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/services"
+)
+
+func TestTokenFromRequest(t *testing.T) {
+	t.Run("prefers the authorization header over the cookie", func(t *testing.T) {
+		is := is.New(t)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer header-token")
+		r.AddCookie(&http.Cookie{Name: "jwt", Value: "cookie-token"})
+
+		token, err := services.TokenFromRequest(r)
+		is.NoErr(err)
+		is.Equal(token, "header-token")
+	})
+
+	t.Run("falls back on the cookie without a header", func(t *testing.T) {
+		is := is.New(t)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "jwt", Value: "cookie-token"})
+
+		token, err := services.TokenFromRequest(r)
+		is.NoErr(err)
+		is.Equal(token, "cookie-token")
+	})
+
+	t.Run("rejects a malformed header even with a cookie", func(t *testing.T) {
+		is := is.New(t)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Basic abc")
+		r.AddCookie(&http.Cookie{Name: "jwt", Value: "cookie-token"})
+
+		_, err := services.TokenFromRequest(r)
+		is.True(err != nil)
+	})
+}
+
+func TestUsesBearerToken(t *testing.T) {
+	is := is.New(t)
+	r := httptest.NewRequest(http.MethodPost, "/auth/sign-in", nil)
+	is.True(!services.UsesBearerToken(r))
+
+	r.Header.Set(services.ClientTypeHeader, "mobile")
+	is.True(services.UsesBearerToken(r))
+}
+
+func TestValidateJWTToken(t *testing.T) {
+	is := is.New(t)
+
+	before := time.Now().UTC().Truncate(time.Millisecond)
+	tokenString, err := services.GenerateJWTToken(map[string]interface{}{"ID": 7}, 42)
+	is.NoErr(err)
+	after := time.Now().UTC()
+
+	_, info, err := services.ValidateJWTToken(context.Background(), tokenString)
+	is.NoErr(err)
+	is.Equal(info.MemberID, uint64(7))
+	is.Equal(info.LoginID, uint64(42))
+	is.True(info.ID != "")
+	// the issue time keeps the milliseconds to be compared with a revocation
+	is.True(!info.IssuedAt.Before(before) && !info.IssuedAt.After(after))
+}
+
+func TestEmailVerificationToken(t *testing.T) {
+	t.Run("returns the signed member and email", func(t *testing.T) {
+		is := is.New(t)
+
+		token := services.GenerateEmailVerificationToken(3, "jane@example.com")
+		memberID, email, err := services.ParseEmailVerificationToken(token)
+		is.NoErr(err)
+		is.Equal(memberID, uint64(3))
+		is.Equal(email, "jane@example.com")
+	})
+
+	t.Run("rejects a tampered token", func(t *testing.T) {
+		is := is.New(t)
+
+		token := services.GenerateEmailVerificationToken(3, "jane@example.com")
+		forged := services.GenerateEmailVerificationToken(4, "jane@example.com")
+		_, _, err := services.ParseEmailVerificationToken(forged[:strings.Index(forged, ".")] + token[strings.Index(token, "."):])
+		is.Equal(err, services.ErrInvalidVerificationToken)
+	})
+
+	t.Run("can not be used as an access token", func(t *testing.T) {
+		is := is.New(t)
+
+		token := services.GenerateEmailVerificationToken(3, "jane@example.com")
+		_, _, err := services.ValidateJWTToken(context.Background(), token)
+		is.True(err != nil)
+	})
+}
+
+func TestMagicLinkToken(t *testing.T) {
+	t.Run("returns the signed link and phone", func(t *testing.T) {
+		is := is.New(t)
+
+		token := services.GenerateMagicLinkToken(5, "237690000000", time.Now().Add(time.Minute))
+		linkID, phone, err := services.ParseMagicLinkToken(token)
+		is.NoErr(err)
+		is.Equal(linkID, uint64(5))
+		is.Equal(phone, "237690000000")
+	})
+
+	t.Run("rejects a token bound to another phone", func(t *testing.T) {
+		is := is.New(t)
+
+		token := services.GenerateMagicLinkToken(5, "237690000000", time.Now().Add(time.Minute))
+		other := services.GenerateMagicLinkToken(5, "237691111111", time.Now().Add(time.Minute))
+		forged := strings.Split(other, ".")[0] + "." + strings.Split(token, ".")[1]
+
+		_, _, err := services.ParseMagicLinkToken(forged)
+		is.Equal(err, services.ErrInvalidMagicLink)
+	})
+
+	t.Run("rejects an expired token", func(t *testing.T) {
+		is := is.New(t)
+
+		token := services.GenerateMagicLinkToken(5, "237690000000", time.Now().Add(-time.Minute))
+		_, _, err := services.ParseMagicLinkToken(token)
+		is.Equal(err, services.ErrExpiredMagicLink)
+	})
+
+	t.Run("can not pass for an email verification token", func(t *testing.T) {
+		is := is.New(t)
+
+		token := services.GenerateMagicLinkToken(5, "237690000000", time.Now().Add(time.Minute))
+		_, _, err := services.ParseEmailVerificationToken(token)
+		is.Equal(err, services.ErrInvalidVerificationToken)
+	})
+}
+
+type memberFinder map[uint64]*models.Member
+
+func (f memberFinder) GetMemberByID(ctx context.Context, id uint64) (*models.Member, error) {
+	return f[id], nil
+}
+
+func TestGetMemberFromClaims(t *testing.T) {
+	finder := memberFinder{
+		7: {ID: 7, Phone: "237690000000"},
+		8: {ID: 8, Email: "someone@tschwaa.com"},
+	}
+
+	t.Run("resolves the member by its id even without an email", func(t *testing.T) {
+		is := is.New(t)
+
+		tokenString, err := services.GenerateJWTToken(map[string]interface{}{"ID": 7, "Email": ""}, 42)
+		is.NoErr(err)
+		claims, _, err := services.ValidateJWTToken(context.Background(), tokenString)
+		is.NoErr(err)
+
+		member, err := services.GetMemberFromClaims(context.Background(), finder, claims["user"])
+		is.NoErr(err)
+		is.Equal(member.ID, uint64(7))
+	})
+
+	t.Run("rejects claims without a member id", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := services.GetMemberFromClaims(context.Background(), finder, map[string]interface{}{"Email": ""})
+		is.True(err != nil)
+	})
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrNoSigningKey  = errors.New("no signing key available")
+	ErrUnknownKey    = errors.New("unknown signing key")
+	ErrKeyAlgorithm  = errors.New("unexpected signing method for the key")
+	ErrKeyNotAllowed = errors.New("signing key no longer accepted")
+)
+
+// SigningKey is one key of the key set, named by the kid header of the tokens it signs.
+// A key signs tokens from NotBefore until RetiresAt, then keeps verifying them
+// until the last token it signed has expired.
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	NotBefore time.Time
+	RetiresAt time.Time
+
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey returns an HS256 key. Being symmetric, it is never published in the JWKS.
+func NewHMACKey(id string, secret []byte) *SigningKey {
+	return &SigningKey{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// NewRSAKey returns an RS256 key
+func NewRSAKey(id string, key *rsa.PrivateKey) *SigningKey {
+	return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, signKey: key, verifyKey: &key.PublicKey}
+}
+
+// NewEd25519Key returns an EdDSA key
+func NewEd25519Key(id string, key ed25519.PrivateKey) *SigningKey {
+	return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, signKey: key, verifyKey: key.Public()}
+}
+
+func (k *SigningKey) canSign(now time.Time) bool {
+	return !now.Before(k.NotBefore) && (k.RetiresAt.IsZero() || now.Before(k.RetiresAt))
+}
+
+func (k *SigningKey) canVerify(now time.Time) bool {
+	return k.RetiresAt.IsZero() || now.Before(k.RetiresAt.Add(AccessTokenTTL))
+}
+
+// KeySet holds the keys used to sign and verify access tokens
+type KeySet struct {
+	mu   sync.RWMutex
+	keys []*SigningKey
+}
+
+func NewKeySet(keys ...*SigningKey) *KeySet {
+	return &KeySet{keys: keys}
+}
+
+// Add puts a key in the set, replacing the key with the same id if there is one
+func (ks *KeySet) Add(key *SigningKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	for i, k := range ks.keys {
+		if k.ID == key.ID {
+			ks.keys[i] = key
+			return
+		}
+	}
+	ks.keys = append(ks.keys, key)
+}
+
+// SigningKey returns the most recent key allowed to sign at the given time. The legacy key
+// without kid loses the ties, so a key of the keys file without not_before replaces it.
+func (ks *KeySet) SigningKey(now time.Time) (*SigningKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	var current *SigningKey
+	for _, k := range ks.keys {
+		if !k.canSign(now) {
+			continue
+		}
+		if current == nil || k.NotBefore.After(current.NotBefore) ||
+			(k.NotBefore.Equal(current.NotBefore) && current.ID == "" && k.ID != "") {
+			current = k
+		}
+	}
+	if current == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	return current, nil
+}
+
+// VerificationKey returns the key named kid as long as the tokens it signed can still be valid
+func (ks *KeySet) VerificationKey(kid string, now time.Time) (*SigningKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	for _, k := range ks.keys {
+		if k.ID != kid {
+			continue
+		}
+		if !k.canVerify(now) {
+			return nil, ErrKeyNotAllowed
+		}
+		return k, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// Keyfunc resolves the verification key of a token from its kid header and checks
+// that the token has been signed with the algorithm of that key
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := ks.VerificationKey(kid, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("%w: %v", ErrKeyAlgorithm, token.Header["alg"])
+	}
+
+	return key.verifyKey, nil
+}
+
+// Sign signs the claims with the current signing key and names it in the kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key, err := ks.SigningKey(time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	return token.SignedString(key.signKey)
+}
+
+// JSONWebKey is the public part of an asymmetric key, as published in the JWKS
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys that can verify tokens, including the ones not signing yet
+// so that the other services know them before the rotation happens
+func (ks *KeySet) JWKS(now time.Time) JSONWebKeySet {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, k := range ks.keys {
+		if !k.canVerify(now) {
+			continue
+		}
+
+		switch pub := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				Kty: "RSA",
+				Kid: k.ID,
+				Use: "sig",
+				Alg: k.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				Kty: "OKP",
+				Kid: k.ID,
+				Use: "sig",
+				Alg: k.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+var keySet *KeySet
+
+// UseKeySet sets the keys GenerateJWTToken signs with and ValidateJWTToken verifies against
+func UseKeySet(ks *KeySet) {
+	keySet = ks
+}
+
+// GetKeySet returns the key set in use
+func GetKeySet() *KeySet {
+	return keySet
+}
+
+// keyConfig describes a key of the file named by JWT_KEYS_FILE
+type keyConfig struct {
+	ID             string    `json:"kid"`
+	Algorithm      string    `json:"alg"`
+	Secret         string    `json:"secret"`
+	PrivateKeyFile string    `json:"private_key_file"`
+	NotBefore      time.Time `json:"not_before"`
+	RetiresAt      time.Time `json:"retires_at"`
+}
+
+// LoadKeySetFromEnv builds the key set from the keys listed in the JSON file JWT_KEYS_FILE.
+// JWT_SECRET, when set, remains an HS256 key without kid so that the tokens signed
+// before the rotation keep verifying; it only signs when no other key can.
+// Without either of them the tokens would be signed with an empty secret, so it fails.
+func LoadKeySetFromEnv() (*KeySet, error) {
+	secret := os.Getenv("JWT_SECRET")
+	path := os.Getenv("JWT_KEYS_FILE")
+	if secret == "" && path == "" {
+		return nil, errors.New("JWT_SECRET or JWT_KEYS_FILE must be set")
+	}
+
+	ks := NewKeySet()
+	if secret != "" {
+		ks.Add(NewHMACKey("", []byte(secret)))
+	}
+	if path == "" {
+		return ks, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error when reading the keys file: %w", err)
+	}
+
+	var configs []keyConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("error when decoding the keys file: %w", err)
+	}
+
+	for _, config := range configs {
+		key, err := config.signingKey()
+		if err != nil {
+			return nil, fmt.Errorf("error when loading key %q: %w", config.ID, err)
+		}
+		ks.Add(key)
+	}
+
+	return ks, nil
+}
+
+func (c keyConfig) signingKey() (*SigningKey, error) {
+	if c.ID == "" {
+		return nil, errors.New("missing kid")
+	}
+
+	var key *SigningKey
+	switch c.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		if c.Secret == "" {
+			return nil, errors.New("missing secret")
+		}
+		key = NewHMACKey(c.ID, []byte(c.Secret))
+	case jwt.SigningMethodRS256.Alg():
+		pem, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		key = NewRSAKey(c.ID, privateKey)
+	case jwt.SigningMethodEdDSA.Alg():
+		pem, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		edKey, ok := privateKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("not an ed25519 key")
+		}
+		key = NewEd25519Key(c.ID, edKey)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", c.Algorithm)
+	}
+
+	key.NotBefore = c.NotBefore
+	key.RetiresAt = c.RetiresAt
+	return key, nil
+}
@@ -0,0 +1,146 @@
+package services_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/matryer/is"
+	"tschwaa.com/api/services"
+)
+
+func useKeySet(t *testing.T, ks *services.KeySet) {
+	previous := services.GetKeySet()
+	services.UseKeySet(ks)
+	t.Cleanup(func() { services.UseKeySet(previous) })
+}
+
+func TestKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("signs with the kid of the most recent key", func(t *testing.T) {
+		is := is.New(t)
+		now := time.Now().UTC()
+		old := services.NewRSAKey("old", rsaKey)
+		old.NotBefore = now.Add(-time.Hour)
+		current := services.NewEd25519Key("current", edKey)
+		current.NotBefore = now.Add(-time.Minute)
+		useKeySet(t, services.NewKeySet(old, current))
+
+		tokenString, err := services.GenerateJWTToken(map[string]interface{}{"ID": 7}, 42)
+		is.NoErr(err)
+
+		token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+		is.NoErr(err)
+		is.Equal(token.Header["kid"], "current")
+		is.Equal(token.Header["alg"], "EdDSA")
+
+		_, info, err := services.ValidateJWTToken(context.Background(), tokenString)
+		is.NoErr(err)
+		is.Equal(info.MemberID, uint64(7))
+	})
+
+	t.Run("keeps verifying a retired key until its tokens expire", func(t *testing.T) {
+		is := is.New(t)
+		old := services.NewRSAKey("old", rsaKey)
+		useKeySet(t, services.NewKeySet(old))
+
+		tokenString, err := services.GenerateJWTToken(map[string]interface{}{"ID": 7}, 42)
+		is.NoErr(err)
+
+		now := time.Now().UTC()
+		old.RetiresAt = now
+		services.GetKeySet().Add(services.NewEd25519Key("new", edKey))
+
+		_, _, err = services.ValidateJWTToken(context.Background(), tokenString)
+		is.NoErr(err)
+
+		_, err = services.GetKeySet().VerificationKey("old", now.Add(services.AccessTokenTTL+time.Second))
+		is.Equal(err, services.ErrKeyNotAllowed)
+	})
+
+	t.Run("rejects a token signed with another algorithm than its key", func(t *testing.T) {
+		is := is.New(t)
+		useKeySet(t, services.NewKeySet(services.NewRSAKey("rsa", rsaKey)))
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": time.Now().Add(time.Minute).Unix()})
+		token.Header["kid"] = "rsa"
+		tokenString, err := token.SignedString([]byte("guessed"))
+		is.NoErr(err)
+
+		_, _, err = services.ValidateJWTToken(context.Background(), tokenString)
+		is.True(err != nil)
+	})
+
+	t.Run("prefers a named key to the legacy key starting at the same time", func(t *testing.T) {
+		is := is.New(t)
+		ks := services.NewKeySet(
+			services.NewEd25519Key("ed", edKey),
+			services.NewHMACKey("", []byte("legacy")),
+		)
+
+		key, err := ks.SigningKey(time.Now().UTC())
+		is.NoErr(err)
+		is.Equal(key.ID, "ed")
+	})
+
+	t.Run("publishes only the asymmetric keys", func(t *testing.T) {
+		is := is.New(t)
+		ks := services.NewKeySet(
+			services.NewHMACKey("", []byte("secret")),
+			services.NewRSAKey("rsa", rsaKey),
+			services.NewEd25519Key("ed", edKey),
+		)
+
+		jwks := ks.JWKS(time.Now().UTC())
+		is.Equal(len(jwks.Keys), 2)
+		is.Equal(jwks.Keys[0].Kid, "ed")
+		is.Equal(jwks.Keys[0].Kty, "OKP")
+		is.Equal(jwks.Keys[1].Kid, "rsa")
+		is.Equal(jwks.Keys[1].E, "AQAB")
+		is.True(!strings.Contains(jwks.Keys[1].N, "="))
+	})
+}
+
+func TestLoadKeySetFromEnv(t *testing.T) {
+	t.Run("fails without any key", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("JWT_SECRET", "")
+		t.Setenv("JWT_KEYS_FILE", "")
+
+		_, err := services.LoadKeySetFromEnv()
+		is.True(err != nil)
+	})
+
+	t.Run("signs with the keys file rather than the legacy secret", func(t *testing.T) {
+		is := is.New(t)
+		path := filepath.Join(t.TempDir(), "keys.json")
+		err := os.WriteFile(path, []byte(`[{"kid": "hs-1", "alg": "HS256", "secret": "rotated"}]`), 0o600)
+		is.NoErr(err)
+		t.Setenv("JWT_SECRET", "legacy")
+		t.Setenv("JWT_KEYS_FILE", path)
+
+		ks, err := services.LoadKeySetFromEnv()
+		is.NoErr(err)
+		key, err := ks.SigningKey(time.Now().UTC())
+		is.NoErr(err)
+		is.Equal(key.ID, "hs-1")
+
+		_, err = ks.VerificationKey("", time.Now().UTC())
+		is.NoErr(err)
+	})
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidMagicLink = errors.New("invalid magic link")
+	ErrExpiredMagicLink = errors.New("expired magic link")
+)
+
+// MagicLinkTTL is how long a magic link sent over WhatsApp can be opened
+var MagicLinkTTL time.Duration
+
+// MagicLinkURL is the page of the web app the magic links point to
+var MagicLinkURL string
+
+var magicLinkKey []byte
+
+func init() {
+	MagicLinkTTL = getDurationOrDefault("MAGIC_LINK_TTL", 10*time.Minute)
+	MagicLinkURL = os.Getenv("MAGIC_LINK_URL")
+	if MagicLinkURL == "" {
+		MagicLinkURL = "http://localhost:5173/magic-link"
+	}
+	magicLinkKey = deriveKey("MAGIC_LINK_SECRET", "magic-link")
+}
+
+// GenerateMagicLinkToken signs the magic link and the phone number it has been sent to
+func GenerateMagicLinkToken(linkID uint64, phone string, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d|%d|%s", linkID, expiresAt.UTC().Unix(), phone)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + signPayload(magicLinkKey, payload)
+}
+
+// ParseMagicLinkToken returns the magic link and the phone number signed in the token
+func ParseMagicLinkToken(token string) (uint64, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return 0, "", ErrInvalidMagicLink
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return 0, "", ErrInvalidMagicLink
+	}
+	if !hmac.Equal([]byte(signPayload(magicLinkKey, string(payload))), []byte(parts[1])) {
+		return 0, "", ErrInvalidMagicLink
+	}
+
+	fields := strings.SplitN(string(payload), "|", 3)
+	if len(fields) != 3 {
+		return 0, "", ErrInvalidMagicLink
+	}
+	linkID, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidMagicLink
+	}
+	expiresAt, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidMagicLink
+	}
+	if time.Now().UTC().Unix() > expiresAt {
+		return 0, "", ErrExpiredMagicLink
+	}
+
+	return linkID, fields[2], nil
+}
+
+// MagicLink returns the link of the web app completing the login
+func MagicLink(token string) string {
+	return fmt.Sprintf("%s?token=%s", MagicLinkURL, token)
+}
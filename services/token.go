@@ -0,0 +1,54 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"time"
+)
+
+// GenerateOpaqueToken returns a random url-safe token along with the hash to store in its place
+func GenerateOpaqueToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the hex-encoded SHA-256 of the token
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix starts every API key so that a leaked one is easy to recognize
+const APIKeyPrefix = "tsk_"
+
+// GenerateAPIKey returns a new API key, the beginning of it shown to tell keys apart,
+// and the hash to store in its place
+func GenerateAPIKey() (string, string, string, error) {
+	token, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key := APIKeyPrefix + token
+	return key, key[:len(APIKeyPrefix)+6], HashOpaqueToken(key), nil
+}
+
+func getDurationOrDefault(name string, defaultV time.Duration) time.Duration {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultV
+	}
+	vAsDuration, err := time.ParseDuration(v)
+	if err != nil {
+		return defaultV
+	}
+	return vAsDuration
+}
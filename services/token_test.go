@@ -0,0 +1,24 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/services"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	is := is.New(t)
+
+	key, prefix, keyHash, err := services.GenerateAPIKey()
+	is.NoErr(err)
+	is.True(strings.HasPrefix(key, services.APIKeyPrefix))
+	is.True(strings.HasPrefix(key, prefix))
+	is.True(len(prefix) < len(key))
+	is.Equal(keyHash, services.HashOpaqueToken(key))
+
+	other, _, _, err := services.GenerateAPIKey()
+	is.NoErr(err)
+	is.True(other != key)
+}
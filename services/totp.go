@@ -0,0 +1,248 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidTOTPSecret     = errors.New("invalid totp secret")
+	ErrInvalidTwoFactorToken = errors.New("invalid two-factor token")
+	ErrExpiredTwoFactorToken = errors.New("expired two-factor token")
+)
+
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	// totpSkew is how many periods before and after now a code is still accepted,
+	// for the clocks of the phones that drift a little
+	totpSkew = 1
+
+	// RecoveryCodeCount is how many recovery codes a member gets with the two-factor authentication
+	RecoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+)
+
+// TOTPIssuer is the name of the account shown by the authenticator apps
+var TOTPIssuer string
+
+// TwoFactorTTL is how long a member has to enter the code after the password
+var TwoFactorTTL time.Duration
+
+var totpEncryptionKey []byte
+var twoFactorKey []byte
+
+func init() {
+	TOTPIssuer = os.Getenv("TOTP_ISSUER")
+	if TOTPIssuer == "" {
+		TOTPIssuer = "Tschwaa"
+	}
+	TwoFactorTTL = getDurationOrDefault("TWO_FACTOR_TTL", 5*time.Minute)
+
+	// the secrets of the authenticator apps are stored encrypted, with a key of 32 bytes
+	totpEncryptionKey = deriveKey("TOTP_ENCRYPTION_KEY", "totp-encryption")
+	twoFactorKey = deriveKey("TWO_FACTOR_SECRET", "two-factor")
+}
+
+// GenerateTOTPSecret returns a random secret in the base32 encoding the authenticator apps expect
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI returns the otpauth URI the web app draws as a QR code for the authenticator apps
+func TOTPProvisioningURI(secret, account string) string {
+	label := url.PathEscape(TOTPIssuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", TOTPIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", strconv.Itoa(totpDigits))
+	params.Set("period", strconv.Itoa(totpPeriod))
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+// TOTPCode returns the code of the secret for the time step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil || len(key) == 0 {
+		return "", ErrInvalidTOTPSecret
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// TOTPStep returns the time step of t
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// ValidateTOTP tells whether the code matches the secret around now, and returns the time step
+// it matched. A step should only be used once, so a code seen by someone else can not be replayed.
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := TOTPStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// EncryptTOTPSecret seals the secret for the database
+func EncryptTOTPSecret(secret string) (string, error) {
+	gcm, err := totpCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptTOTPSecret opens a secret sealed by EncryptTOTPSecret
+func DecryptTOTPSecret(encrypted string) (string, error) {
+	gcm, err := totpCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", ErrInvalidTOTPSecret
+	}
+
+	secret, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrInvalidTOTPSecret
+	}
+
+	return string(secret), nil
+}
+
+func totpCipher() (cipher.AEAD, error) {
+	key := totpEncryptionKey
+	if len(key) != 32 {
+		// an explicit key of another length is stretched to the size of AES-256
+		sum := sha256.Sum256(key)
+		key = sum[:]
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// GenerateRecoveryCodes returns RecoveryCodeCount single-use codes, each one as xxxxx-xxxxx
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		var code strings.Builder
+		for j, c := range b {
+			if j == 5 {
+				code.WriteByte('-')
+			}
+			code.WriteByte(recoveryCodeAlphabet[int(c)%len(recoveryCodeAlphabet)])
+		}
+		codes = append(codes, code.String())
+	}
+
+	return codes, nil
+}
+
+// HashRecoveryCode hashes a recovery code the way it is stored, whatever its case and spacing
+func HashRecoveryCode(code string) string {
+	return HashOpaqueToken(strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", "")))
+}
+
+// GenerateTwoFactorToken signs the member who passed the password and still has to enter a code
+func GenerateTwoFactorToken(memberID uint64, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d|%d", memberID, expiresAt.UTC().Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + signPayload(twoFactorKey, payload)
+}
+
+// ParseTwoFactorToken returns the member signed in the token
+func ParseTwoFactorToken(token string) (uint64, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return 0, ErrInvalidTwoFactorToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return 0, ErrInvalidTwoFactorToken
+	}
+	if !hmac.Equal([]byte(signPayload(twoFactorKey, string(payload))), []byte(parts[1])) {
+		return 0, ErrInvalidTwoFactorToken
+	}
+
+	fields := strings.SplitN(string(payload), "|", 2)
+	if len(fields) != 2 {
+		return 0, ErrInvalidTwoFactorToken
+	}
+	memberID, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidTwoFactorToken
+	}
+	expiresAt, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidTwoFactorToken
+	}
+	if time.Now().UTC().Unix() > expiresAt {
+		return 0, ErrExpiredTwoFactorToken
+	}
+
+	return memberID, nil
+}
@@ -0,0 +1,114 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/services"
+)
+
+// rfc6238Secret is the SHA1 secret of the test vectors of RFC 6238, in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	is := is.New(t)
+
+	// the vectors of the RFC have 8 digits, the codes keep their last 6
+	for unix, code := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+	} {
+		got, err := services.TOTPCode(rfc6238Secret, services.TOTPStep(time.Unix(unix, 0)))
+		is.NoErr(err)
+		is.Equal(got, code)
+	}
+
+	_, err := services.TOTPCode("not base32!", 1)
+	is.Equal(err, services.ErrInvalidTOTPSecret)
+}
+
+func TestValidateTOTP(t *testing.T) {
+	is := is.New(t)
+
+	secret, err := services.GenerateTOTPSecret()
+	is.NoErr(err)
+	now := time.Now()
+	current := services.TOTPStep(now)
+
+	code, err := services.TOTPCode(secret, current)
+	is.NoErr(err)
+	step, ok := services.ValidateTOTP(secret, code, now)
+	is.True(ok)
+	is.Equal(step, current)
+
+	// the phone clock may be one period behind
+	code, err = services.TOTPCode(secret, current-1)
+	is.NoErr(err)
+	step, ok = services.ValidateTOTP(secret, code, now)
+	is.True(ok)
+	is.Equal(step, current-1)
+
+	code, err = services.TOTPCode(secret, current-3)
+	is.NoErr(err)
+	_, ok = services.ValidateTOTP(secret, code, now)
+	is.True(!ok)
+
+	_, ok = services.ValidateTOTP(secret, "12345", now)
+	is.True(!ok)
+}
+
+func TestEncryptTOTPSecret(t *testing.T) {
+	is := is.New(t)
+
+	secret, err := services.GenerateTOTPSecret()
+	is.NoErr(err)
+
+	encrypted, err := services.EncryptTOTPSecret(secret)
+	is.NoErr(err)
+	is.True(!strings.Contains(encrypted, secret))
+
+	decrypted, err := services.DecryptTOTPSecret(encrypted)
+	is.NoErr(err)
+	is.Equal(decrypted, secret)
+
+	_, err = services.DecryptTOTPSecret(encrypted[:len(encrypted)-2] + "AA")
+	is.Equal(err, services.ErrInvalidTOTPSecret)
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	is := is.New(t)
+
+	codes, err := services.GenerateRecoveryCodes()
+	is.NoErr(err)
+	is.Equal(len(codes), services.RecoveryCodeCount)
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		is.Equal(len(code), 11)
+		is.Equal(code[5], byte('-'))
+		is.True(!seen[code])
+		seen[code] = true
+	}
+
+	// the hash does not care how the member typed the code
+	is.Equal(services.HashRecoveryCode(strings.ToUpper(codes[0])+" "), services.HashRecoveryCode(codes[0]))
+}
+
+func TestTwoFactorToken(t *testing.T) {
+	is := is.New(t)
+
+	token := services.GenerateTwoFactorToken(42, time.Now().Add(time.Minute))
+	memberID, err := services.ParseTwoFactorToken(token)
+	is.NoErr(err)
+	is.Equal(memberID, uint64(42))
+
+	_, err = services.ParseTwoFactorToken(token + "x")
+	is.Equal(err, services.ErrInvalidTwoFactorToken)
+
+	expired := services.GenerateTwoFactorToken(42, time.Now().Add(-time.Minute))
+	_, err = services.ParseTwoFactorToken(expired)
+	is.Equal(err, services.ErrExpiredTwoFactorToken)
+}
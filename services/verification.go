@@ -0,0 +1,125 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+	ErrExpiredVerificationToken = errors.New("expired verification token")
+)
+
+// EmailVerificationTTL is how long the link sent to confirm an email address stays valid
+var EmailVerificationTTL time.Duration
+
+// EmailVerificationURL is the page of the web app the verification link points to
+var EmailVerificationURL string
+
+var emailVerificationKey []byte
+
+func init() {
+	EmailVerificationTTL = getDurationOrDefault("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	EmailVerificationURL = os.Getenv("EMAIL_VERIFICATION_URL")
+	if EmailVerificationURL == "" {
+		EmailVerificationURL = "http://localhost:5173/verify-email"
+	}
+
+	// the verification links are not signed with the JWT secret itself,
+	// so that they can never pass for an access token
+	emailVerificationKey = deriveKey("EMAIL_VERIFICATION_SECRET", "email-verification")
+}
+
+// GenerateEmailVerificationToken signs the email address of the member, it stops
+// being valid after EmailVerificationTTL or as soon as the address changes
+func GenerateEmailVerificationToken(memberID uint64, email string) string {
+	payload := fmt.Sprintf("%d|%d|%s", memberID, time.Now().UTC().Add(EmailVerificationTTL).Unix(), email)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + signVerificationPayload(payload)
+}
+
+// ParseEmailVerificationToken returns the member and email address signed in the token
+func ParseEmailVerificationToken(token string) (uint64, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return 0, "", ErrInvalidVerificationToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	if !hmac.Equal([]byte(signVerificationPayload(string(payload))), []byte(parts[1])) {
+		return 0, "", ErrInvalidVerificationToken
+	}
+
+	fields := strings.SplitN(string(payload), "|", 3)
+	if len(fields) != 3 {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	memberID, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	expiresAt, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	if time.Now().UTC().Unix() > expiresAt {
+		return 0, "", ErrExpiredVerificationToken
+	}
+
+	return memberID, fields[2], nil
+}
+
+// EmailVerificationLink returns the link of the web app confirming the email address of the member
+func EmailVerificationLink(memberID uint64, email string) string {
+	return fmt.Sprintf("%s?token=%s", EmailVerificationURL, GenerateEmailVerificationToken(memberID, email))
+}
+
+func signVerificationPayload(payload string) string {
+	return signPayload(emailVerificationKey, payload)
+}
+
+func signPayload(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// missingSecrets names the keys derived from an empty secret, see CheckSecrets
+var missingSecrets []string
+
+// deriveKey returns the secret of the env variable name, or else a key derived from
+// KEY_DERIVATION_SECRET for the given purpose, so that a token of one kind never passes for another.
+// The JWT secret is left out of it, it goes away once the signing keys move to JWT_KEYS_FILE.
+func deriveKey(name, purpose string) []byte {
+	if secret := os.Getenv(name); secret != "" {
+		return []byte(secret)
+	}
+
+	secret := os.Getenv("KEY_DERIVATION_SECRET")
+	if secret == "" {
+		missingSecrets = append(missingSecrets, name)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// CheckSecrets fails when a key signing the links and codes, or encrypting the secrets of the
+// authenticator apps, would be derived from an empty secret anyone can derive it from too.
+// The deployments which derived them from JWT_SECRET keep them by giving its value to KEY_DERIVATION_SECRET.
+func CheckSecrets() error {
+	if len(missingSecrets) > 0 {
+		return fmt.Errorf("KEY_DERIVATION_SECRET must be set, or else %s", strings.Join(missingSecrets, ", "))
+	}
+
+	return nil
+}
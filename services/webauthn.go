@@ -0,0 +1,334 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrWebAuthnMalformed      = errors.New("malformed webauthn response")
+	ErrWebAuthnType           = errors.New("unexpected webauthn ceremony")
+	ErrWebAuthnChallenge      = errors.New("webauthn challenge mismatch")
+	ErrWebAuthnOrigin         = errors.New("webauthn origin not allowed")
+	ErrWebAuthnRPID           = errors.New("webauthn relying party mismatch")
+	ErrWebAuthnUserPresence   = errors.New("webauthn user not present")
+	ErrWebAuthnUnsupportedKey = errors.New("unsupported webauthn public key")
+	ErrWebAuthnSignature      = errors.New("invalid webauthn signature")
+	ErrWebAuthnSignCount      = errors.New("webauthn sign counter did not increase")
+)
+
+// COSE algorithms of the public keys accepted for the passkeys
+const (
+	COSEAlgES256 = -7
+	COSEAlgEdDSA = -8
+	COSEAlgRS256 = -257
+)
+
+const (
+	webAuthnCreate = "webauthn.create"
+	webAuthnGet    = "webauthn.get"
+
+	authDataUserPresent  = 0x01
+	authDataUserVerified = 0x04
+	authDataAttested     = 0x40
+)
+
+// WebAuthnRPID is the domain the passkeys are scoped to
+var WebAuthnRPID string
+
+// WebAuthnRPName is the name of the app shown by the authenticators
+var WebAuthnRPName string
+
+// WebAuthnOrigins are the origins of the web app allowed to run the ceremonies
+var WebAuthnOrigins []string
+
+// WebAuthnChallengeTTL is how long a registration or login ceremony can take
+var WebAuthnChallengeTTL time.Duration
+
+func init() {
+	WebAuthnRPID = os.Getenv("WEBAUTHN_RP_ID")
+	if WebAuthnRPID == "" {
+		WebAuthnRPID = "localhost"
+	}
+	WebAuthnRPName = os.Getenv("WEBAUTHN_RP_NAME")
+	if WebAuthnRPName == "" {
+		WebAuthnRPName = "Tschwaa"
+	}
+	WebAuthnOrigins = []string{"http://localhost:5173"}
+	if origins := os.Getenv("WEBAUTHN_ORIGINS"); origins != "" {
+		WebAuthnOrigins = strings.Split(origins, ",")
+	}
+	WebAuthnChallengeTTL = getDurationOrDefault("WEBAUTHN_CHALLENGE_TTL", 5*time.Minute)
+}
+
+// WebAuthnCredential is a public key credential created by an authenticator
+type WebAuthnCredential struct {
+	ID        []byte
+	PublicKey []byte
+	Algorithm int
+	SignCount uint32
+}
+
+// WebAuthnAssertion is the outcome of a verified login with a passkey
+type WebAuthnAssertion struct {
+	SignCount uint32
+	// UserVerified tells whether the authenticator checked the user with a PIN or biometrics,
+	// which makes the passkey a second factor on its own
+	UserVerified bool
+}
+
+type webAuthnClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type webAuthnAuthData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// NewWebAuthnChallenge returns a random challenge encoded the way it comes back in the client data
+func NewWebAuthnChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// WebAuthnChallengeOf returns the challenge the client data has been signed for
+func WebAuthnChallengeOf(clientDataJSON []byte) (string, error) {
+	var clientData webAuthnClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil || clientData.Challenge == "" {
+		return "", ErrWebAuthnMalformed
+	}
+
+	return clientData.Challenge, nil
+}
+
+// VerifyWebAuthnRegistration checks the response of navigator.credentials.create() and
+// returns the credential to store. The attestation statement is not verified since the
+// ceremonies ask the authenticators for no attestation.
+func VerifyWebAuthnRegistration(challenge string, clientDataJSON, attestationObject []byte) (*WebAuthnCredential, error) {
+	if err := verifyWebAuthnClientData(clientDataJSON, webAuthnCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := cborDecode(attestationObject)
+	if err != nil {
+		return nil, ErrWebAuthnMalformed
+	}
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrWebAuthnMalformed
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, ErrWebAuthnMalformed
+	}
+
+	authData, err := parseWebAuthnAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.Flags&authDataAttested == 0 {
+		return nil, ErrWebAuthnMalformed
+	}
+
+	_, alg, err := parseCOSEKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnCredential{
+		ID:        authData.CredentialID,
+		PublicKey: authData.PublicKey,
+		Algorithm: alg,
+		SignCount: authData.SignCount,
+	}, nil
+}
+
+// VerifyWebAuthnAssertion checks the response of navigator.credentials.get() against the
+// stored public key and returns the new sign counter of the credential. A counter which
+// does not go up, while the authenticator keeps one, means the credential may have been cloned.
+func VerifyWebAuthnAssertion(challenge string, publicKey []byte, signCount uint32, clientDataJSON, authenticatorData, signature []byte) (*WebAuthnAssertion, error) {
+	if err := verifyWebAuthnClientData(clientDataJSON, webAuthnGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := parseWebAuthnAuthData(authenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, alg, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, authenticatorData...), clientDataHash[:]...)
+	if !verifyCOSESignature(key, alg, signed, signature) {
+		return nil, ErrWebAuthnSignature
+	}
+
+	if (authData.SignCount != 0 || signCount != 0) && authData.SignCount <= signCount {
+		return nil, ErrWebAuthnSignCount
+	}
+
+	return &WebAuthnAssertion{
+		SignCount:    authData.SignCount,
+		UserVerified: authData.Flags&authDataUserVerified != 0,
+	}, nil
+}
+
+func verifyWebAuthnClientData(clientDataJSON []byte, ceremony, challenge string) error {
+	var clientData webAuthnClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return ErrWebAuthnMalformed
+	}
+
+	if clientData.Type != ceremony {
+		return ErrWebAuthnType
+	}
+	if subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return ErrWebAuthnChallenge
+	}
+	for _, origin := range WebAuthnOrigins {
+		if clientData.Origin == origin {
+			return nil
+		}
+	}
+
+	return ErrWebAuthnOrigin
+}
+
+func parseWebAuthnAuthData(data []byte) (*webAuthnAuthData, error) {
+	if len(data) < 37 {
+		return nil, ErrWebAuthnMalformed
+	}
+
+	authData := webAuthnAuthData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rpIDHash := sha256.Sum256([]byte(WebAuthnRPID))
+	if subtle.ConstantTimeCompare(authData.RPIDHash, rpIDHash[:]) != 1 {
+		return nil, ErrWebAuthnRPID
+	}
+	if authData.Flags&authDataUserPresent == 0 {
+		return nil, ErrWebAuthnUserPresence
+	}
+
+	if authData.Flags&authDataAttested != 0 {
+		// aaguid (16 bytes), length of the credential id (2 bytes), credential id, COSE key
+		rest := data[37:]
+		if len(rest) < 18 {
+			return nil, ErrWebAuthnMalformed
+		}
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength == 0 || len(rest) < idLength {
+			return nil, ErrWebAuthnMalformed
+		}
+		authData.CredentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		_, extensions, err := cborDecode(rest)
+		if err != nil {
+			return nil, ErrWebAuthnMalformed
+		}
+		authData.PublicKey = rest[:len(rest)-len(extensions)]
+	}
+
+	return &authData, nil
+}
+
+// parseCOSEKey returns the public key encoded in COSE and its algorithm
+func parseCOSEKey(data []byte) (crypto.PublicKey, int, error) {
+	decoded, _, err := cborDecode(data)
+	if err != nil {
+		return nil, 0, ErrWebAuthnMalformed
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, ErrWebAuthnMalformed
+	}
+
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+
+	switch {
+	case kty == 2 && alg == COSEAlgES256:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, ErrWebAuthnUnsupportedKey
+		}
+		pub := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, ErrWebAuthnUnsupportedKey
+		}
+		return pub, COSEAlgES256, nil
+	case kty == 1 && alg == COSEAlgEdDSA:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, ErrWebAuthnUnsupportedKey
+		}
+		return ed25519.PublicKey(x), COSEAlgEdDSA, nil
+	case kty == 3 && alg == COSEAlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, ErrWebAuthnUnsupportedKey
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, COSEAlgRS256, nil
+	}
+
+	return nil, 0, ErrWebAuthnUnsupportedKey
+}
+
+func verifyCOSESignature(key crypto.PublicKey, alg int, data, signature []byte) bool {
+	switch alg {
+	case COSEAlgES256:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key.(*ecdsa.PublicKey), digest[:], signature)
+	case COSEAlgEdDSA:
+		return ed25519.Verify(key.(ed25519.PublicKey), data, signature)
+	case COSEAlgRS256:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	}
+
+	return false
+}
@@ -0,0 +1,213 @@
+package services_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+	"tschwaa.com/api/services"
+)
+
+// cborItem is the small subset of CBOR a software authenticator has to write
+func cborItem(v interface{}) []byte {
+	head := func(major byte, n int) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 256:
+			return []byte{major<<5 | 24, byte(n)}
+		default:
+			return []byte{major<<5 | 25, byte(n >> 8), byte(n)}
+		}
+	}
+
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return head(1, -1-v)
+		}
+		return head(0, v)
+	case string:
+		return append(head(3, len(v)), v...)
+	case []byte:
+		return append(head(2, len(v)), v...)
+	case [][2]interface{}:
+		b := head(5, len(v))
+		for _, kv := range v {
+			b = append(b, cborItem(kv[0])...)
+			b = append(b, cborItem(kv[1])...)
+		}
+		return b
+	}
+
+	panic("unsupported cbor item")
+}
+
+type softwareAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+	rpID         string
+	origin       string
+}
+
+func newSoftwareAuthenticator(t *testing.T) *softwareAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &softwareAuthenticator{
+		key:          key,
+		credentialID: []byte("software-credential"),
+		rpID:         services.WebAuthnRPID,
+		origin:       services.WebAuthnOrigins[0],
+	}
+}
+
+func (a *softwareAuthenticator) clientData(ceremony, challenge string) []byte {
+	b, _ := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": a.origin})
+	return b
+}
+
+func (a *softwareAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	b := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(b[33:], a.signCount)
+	return append(b, attested...)
+}
+
+func (a *softwareAuthenticator) create(challenge string) ([]byte, []byte) {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+	coseKey := cborItem([][2]interface{}{{1, 2}, {3, services.COSEAlgES256}, {-1, 1}, {-2, x}, {-3, y}})
+
+	attested := make([]byte, 16, 18)
+	attested = append(attested, byte(len(a.credentialID)>>8), byte(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, coseKey...)
+
+	attestationObject := cborItem([][2]interface{}{
+		{"fmt", "none"},
+		{"attStmt", [][2]interface{}{}},
+		{"authData", a.authData(0x45, attested)},
+	})
+	return a.clientData("webauthn.create", challenge), attestationObject
+}
+
+func (a *softwareAuthenticator) get(t *testing.T, challenge string) ([]byte, []byte, []byte) {
+	a.signCount++
+	clientDataJSON := a.clientData("webauthn.get", challenge)
+	authData := a.authData(0x05, nil)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return clientDataJSON, authData, signature
+}
+
+func TestWebAuthn(t *testing.T) {
+	register := func(t *testing.T, a *softwareAuthenticator) *services.WebAuthnCredential {
+		challenge, err := services.NewWebAuthnChallenge()
+		if err != nil {
+			t.Fatal(err)
+		}
+		clientDataJSON, attestationObject := a.create(challenge)
+		credential, err := services.VerifyWebAuthnRegistration(challenge, clientDataJSON, attestationObject)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return credential
+	}
+
+	t.Run("registers the credential of the authenticator", func(t *testing.T) {
+		is := is.New(t)
+		a := newSoftwareAuthenticator(t)
+
+		challenge, err := services.NewWebAuthnChallenge()
+		is.NoErr(err)
+		clientDataJSON, attestationObject := a.create(challenge)
+
+		received, err := services.WebAuthnChallengeOf(clientDataJSON)
+		is.NoErr(err)
+		is.Equal(received, challenge)
+
+		credential, err := services.VerifyWebAuthnRegistration(challenge, clientDataJSON, attestationObject)
+		is.NoErr(err)
+		is.Equal(credential.ID, a.credentialID)
+		is.Equal(credential.Algorithm, services.COSEAlgES256)
+		is.Equal(credential.SignCount, uint32(0))
+	})
+
+	t.Run("rejects a registration for another challenge, origin or relying party", func(t *testing.T) {
+		is := is.New(t)
+		a := newSoftwareAuthenticator(t)
+
+		clientDataJSON, attestationObject := a.create("other")
+		_, err := services.VerifyWebAuthnRegistration("expected", clientDataJSON, attestationObject)
+		is.Equal(err, services.ErrWebAuthnChallenge)
+
+		a.origin = "https://evil.example"
+		clientDataJSON, attestationObject = a.create("expected")
+		_, err = services.VerifyWebAuthnRegistration("expected", clientDataJSON, attestationObject)
+		is.Equal(err, services.ErrWebAuthnOrigin)
+
+		a = newSoftwareAuthenticator(t)
+		a.rpID = "evil.example"
+		clientDataJSON, attestationObject = a.create("expected")
+		_, err = services.VerifyWebAuthnRegistration("expected", clientDataJSON, attestationObject)
+		is.Equal(err, services.ErrWebAuthnRPID)
+	})
+
+	t.Run("verifies the assertions and their sign counter", func(t *testing.T) {
+		is := is.New(t)
+		a := newSoftwareAuthenticator(t)
+		credential := register(t, a)
+
+		clientDataJSON, authData, signature := a.get(t, "login")
+		assertion, err := services.VerifyWebAuthnAssertion("login", credential.PublicKey, credential.SignCount, clientDataJSON, authData, signature)
+		is.NoErr(err)
+		is.Equal(assertion.SignCount, uint32(1))
+		is.True(assertion.UserVerified)
+
+		// replaying the same counter looks like a cloned authenticator
+		_, err = services.VerifyWebAuthnAssertion("login", credential.PublicKey, assertion.SignCount, clientDataJSON, authData, signature)
+		is.Equal(err, services.ErrWebAuthnSignCount)
+
+		clientDataJSON, authData, signature = a.get(t, "again")
+		assertion, err = services.VerifyWebAuthnAssertion("again", credential.PublicKey, assertion.SignCount, clientDataJSON, authData, signature)
+		is.NoErr(err)
+		is.Equal(assertion.SignCount, uint32(2))
+	})
+
+	t.Run("rejects an assertion signed by another key", func(t *testing.T) {
+		is := is.New(t)
+		credential := register(t, newSoftwareAuthenticator(t))
+
+		clientDataJSON, authData, signature := newSoftwareAuthenticator(t).get(t, "login")
+		_, err := services.VerifyWebAuthnAssertion("login", credential.PublicKey, credential.SignCount, clientDataJSON, authData, signature)
+		is.Equal(err, services.ErrWebAuthnSignature)
+	})
+
+	t.Run("rejects a registration response used as an assertion", func(t *testing.T) {
+		is := is.New(t)
+		a := newSoftwareAuthenticator(t)
+		credential := register(t, a)
+
+		clientDataJSON, _ := a.create("login")
+		_, authData, signature := a.get(t, "login")
+		_, err := services.VerifyWebAuthnAssertion("login", credential.PublicKey, credential.SignCount, clientDataJSON, authData, signature)
+		is.Equal(err, services.ErrWebAuthnType)
+	})
+}
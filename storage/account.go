@@ -0,0 +1,399 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tschwaa.com/api/models"
+)
+
+const listMembershipsOfMember = `-- name: ListMembershipsOfMember :many
+SELECT id, member_id, organization_id, created_at, updated_at, joined, joined_at, position, status, role
+FROM memberships
+WHERE member_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListMembershipsOfMember(ctx context.Context, memberID uint64) ([]*models.Membership, error) {
+	rows, err := q.db.QueryContext(ctx, listMembershipsOfMember, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.Membership{}
+	for rows.Next() {
+		var i models.Membership
+		if err := rows.Scan(
+			&i.ID,
+			&i.MemberID,
+			&i.OrganizationID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Joined,
+			&i.JoinedAt,
+			&i.Position,
+			&i.Status,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionsOfMember = `-- name: ListSessionsOfMember :many
+SELECT mos.id, mos.session_id, mos.created_at, mos.updated_at,
+  m.id as member_id, m.first_name, m.last_name, m.sex, m.phone,
+  a.id as membership_id, a.position, a.role, a.status, a.joined, a.joined_at, mos.shares
+FROM members_of_session mos
+INNER JOIN memberships a ON a.id = mos.membership_id
+INNER JOIN members m ON m.id = a.member_id
+WHERE a.member_id = $1
+ORDER BY mos.created_at
+`
+
+func (q *Queries) ListSessionsOfMember(ctx context.Context, memberID uint64) ([]*models.MembersOfSession, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionsOfMember, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.MembersOfSession{}
+	for rows.Next() {
+		var i models.MembersOfSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MemberID,
+			&i.FirstName,
+			&i.LastName,
+			&i.Sex,
+			&i.Phone,
+			&i.MembershipID,
+			&i.Position,
+			&i.Role,
+			&i.Status,
+			&i.Joined,
+			&i.JoinedAt,
+			&i.Shares,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInvitationsOfMember = `-- name: ListInvitationsOfMember :many
+SELECT i.id, i.link, i.active, i.created_at, i.updated_at, i.membership_id
+FROM invitations i
+INNER JOIN memberships a ON a.id = i.membership_id
+WHERE a.member_id = $1
+ORDER BY i.created_at
+`
+
+func (q *Queries) ListInvitationsOfMember(ctx context.Context, memberID uint64) ([]*models.Invitation, error) {
+	rows, err := q.db.QueryContext(ctx, listInvitationsOfMember, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.Invitation{}
+	for rows.Next() {
+		var i models.Invitation
+		if err := rows.Scan(
+			&i.ID,
+			&i.Link,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MembershipID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOTPsFromPhone = `-- name: ListOTPsFromPhone :many
+SELECT id, message_id, channel, phone, pin_code, active, attempts, expires_at, created_at, updated_at, purpose
+FROM otps
+WHERE phone = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListOTPsFromPhone(ctx context.Context, phone string) ([]*models.Otp, error) {
+	rows, err := q.db.QueryContext(ctx, listOTPsFromPhone, phone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.Otp{}
+	for rows.Next() {
+		var i models.Otp
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.Channel,
+			&i.Phone,
+			&i.PinCode,
+			&i.Active,
+			&i.Attempts,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Purpose,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createAccountDeletion = `-- name: CreateAccountDeletion :one
+INSERT INTO account_deletions(member_id, scheduled_for, language)
+VALUES ($1, $2, $3)
+RETURNING id, member_id, scheduled_for, cancelled_at, completed_at, created_at, updated_at, language
+`
+
+type CreateAccountDeletionParams struct {
+	MemberID     uint64    `db:"member_id" json:"member_id"`
+	ScheduledFor time.Time `db:"scheduled_for" json:"scheduled_for"`
+	Language     string    `db:"language" json:"language"`
+}
+
+func (q *Queries) CreateAccountDeletion(ctx context.Context, arg CreateAccountDeletionParams) (*models.AccountDeletion, error) {
+	row := q.db.QueryRowContext(ctx, createAccountDeletion, arg.MemberID, arg.ScheduledFor, arg.Language)
+	var i models.AccountDeletion
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.ScheduledFor,
+		&i.CancelledAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Language,
+	)
+	return &i, err
+}
+
+const getPendingAccountDeletion = `-- name: GetPendingAccountDeletion :one
+SELECT id, member_id, scheduled_for, cancelled_at, completed_at, created_at, updated_at, language
+FROM account_deletions
+WHERE member_id = $1 AND cancelled_at IS NULL AND completed_at IS NULL
+`
+
+func (q *Queries) GetPendingAccountDeletion(ctx context.Context, memberID uint64) (*models.AccountDeletion, error) {
+	row := q.db.QueryRowContext(ctx, getPendingAccountDeletion, memberID)
+	var i models.AccountDeletion
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.ScheduledFor,
+		&i.CancelledAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Language,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const cancelAccountDeletion = `-- name: CancelAccountDeletion :exec
+UPDATE account_deletions
+SET cancelled_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) CancelAccountDeletion(ctx context.Context, id uint64) error {
+	_, err := q.db.ExecContext(ctx, cancelAccountDeletion, id)
+	return err
+}
+
+const listDueAccountDeletions = `-- name: ListDueAccountDeletions :many
+SELECT id, member_id, scheduled_for, cancelled_at, completed_at, created_at, updated_at, language
+FROM account_deletions
+WHERE cancelled_at IS NULL AND completed_at IS NULL AND scheduled_for <= $1
+`
+
+func (q *Queries) ListDueAccountDeletions(ctx context.Context, now time.Time) ([]*models.AccountDeletion, error) {
+	rows, err := q.db.QueryContext(ctx, listDueAccountDeletions, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.AccountDeletion{}
+	for rows.Next() {
+		var i models.AccountDeletion
+		if err := rows.Scan(
+			&i.ID,
+			&i.MemberID,
+			&i.ScheduledFor,
+			&i.CancelledAt,
+			&i.CompletedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeAccountDeletion = `-- name: CompleteAccountDeletion :exec
+UPDATE account_deletions
+SET completed_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) CompleteAccountDeletion(ctx context.Context, id uint64) error {
+	_, err := q.db.ExecContext(ctx, completeAccountDeletion, id)
+	return err
+}
+
+const anonymizeMember = `-- name: AnonymizeMember :exec
+UPDATE members
+SET first_name = 'Deleted', last_name = 'Member', sex = '',
+  email = 'deleted-' || id || '@tschwaa.invalid', phone = 'x' || id,
+  user_id = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) AnonymizeMember(ctx context.Context, id uint64) error {
+	_, err := q.db.ExecContext(ctx, anonymizeMember, id)
+	return err
+}
+
+const deleteUserOfMember = `-- name: DeleteUserOfMember :exec
+DELETE FROM users
+WHERE member_id = $1
+`
+
+func (q *Queries) DeleteUserOfMember(ctx context.Context, memberID uint64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserOfMember, memberID)
+	return err
+}
+
+const deleteOTPsFromPhone = `-- name: DeleteOTPsFromPhone :exec
+DELETE FROM otps
+WHERE phone = $1
+`
+
+func (q *Queries) DeleteOTPsFromPhone(ctx context.Context, phone string) error {
+	_, err := q.db.ExecContext(ctx, deleteOTPsFromPhone, phone)
+	return err
+}
+
+const deleteMemberLogins = `-- name: DeleteMemberLogins :exec
+DELETE FROM logins
+WHERE member_id = $1
+`
+
+func (q *Queries) DeleteMemberLogins(ctx context.Context, memberID uint64) error {
+	_, err := q.db.ExecContext(ctx, deleteMemberLogins, memberID)
+	return err
+}
+
+const desactivateInvitationsOfMember = `-- name: DesactivateInvitationsOfMember :exec
+UPDATE invitations
+SET active = FALSE
+WHERE active = TRUE AND membership_id IN (
+  SELECT id FROM memberships WHERE member_id = $1
+)
+`
+
+func (q *Queries) DesactivateInvitationsOfMember(ctx context.Context, memberID uint64) error {
+	_, err := q.db.ExecContext(ctx, desactivateInvitationsOfMember, memberID)
+	return err
+}
+
+const deleteMemberPhoneChanges = `-- name: DeleteMemberPhoneChanges :exec
+DELETE FROM phone_changes
+WHERE member_id = $1
+`
+
+func (q *Queries) DeleteMemberPhoneChanges(ctx context.Context, memberID uint64) error {
+	_, err := q.db.ExecContext(ctx, deleteMemberPhoneChanges, memberID)
+	return err
+}
+
+const listOrganizationsLastAdministeredByMember = `-- name: ListOrganizationsLastAdministeredByMember :many
+SELECT a.organization_id
+FROM memberships a
+WHERE a.member_id = $1 AND a.role = 'admin'
+AND NOT EXISTS (
+  SELECT 1 FROM memberships o
+  WHERE o.organization_id = a.organization_id AND o.id <> a.id AND o.role = 'admin'
+)
+AND EXISTS (
+  SELECT 1 FROM memberships o
+  WHERE o.organization_id = a.organization_id AND o.id <> a.id
+)
+`
+
+// ListOrganizationsLastAdministeredByMember returns the organizations which would be left
+// with members but no admin without the member
+func (q *Queries) ListOrganizationsLastAdministeredByMember(ctx context.Context, memberID uint64) ([]uint64, error) {
+	rows, err := q.db.QueryContext(ctx, listOrganizationsLastAdministeredByMember, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uint64{}
+	for rows.Next() {
+		var organizationID uint64
+		if err := rows.Scan(&organizationID); err != nil {
+			return nil, err
+		}
+		items = append(items, organizationID)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/utils"
+)
+
+var ErrLastAdmin = errors.New("the member is the last admin of an organization")
+
+type DeleteAccountParams struct {
+	AccountDeletionID uint64
+	MemberID          uint64
+	Phone             string
+	Email             string
+}
+
+// DeleteAccountTx removes the personal data of the member: its user, OTPs, logins, phone
+// changes and sign-in throttles go away while the member row is anonymized so that the
+// memberships and sessions of the organizations stay consistent. The account of the last
+// admin of an organization is kept, the organization would have nobody to run it.
+func (store *SQLStorage) DeleteAccountTx(ctx context.Context, arg DeleteAccountParams) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+		organizations, err := q.ListOrganizationsLastAdministeredByMember(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when listing the organizations administered by member[%d]", arg.MemberID),
+				"ERR_DEL_ACC_11", err)
+		}
+		if len(organizations) > 0 {
+			return ErrLastAdmin
+		}
+
+		err = q.RevokeMemberTokens(ctx, RevokeMemberTokensParams{
+			MemberID:  arg.MemberID,
+			RevokedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when revoking tokens of member[%d]", arg.MemberID),
+				"ERR_DEL_ACC_01", err)
+		}
+
+		err = q.RevokeMemberRefreshTokens(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when revoking refresh tokens of member[%d]", arg.MemberID),
+				"ERR_DEL_ACC_02", err)
+		}
+
+		err = q.DeleteMemberLogins(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deleting logins of member[%d]", arg.MemberID),
+				"ERR_DEL_ACC_03", err)
+		}
+
+		err = q.DeleteOTPsFromPhone(ctx, arg.Phone)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deleting otps of member[%d]", arg.MemberID),
+				"ERR_DEL_ACC_04", err)
+		}
+
+		err = q.DeleteMemberPhoneChanges(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deleting phone changes of member[%d]", arg.MemberID),
+				"ERR_DEL_ACC_05", err)
+		}
+
+		err = q.DesactivateInvitationsOfMember(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when desactivating invitations of member[%d]", arg.MemberID),
+				"ERR_DEL_ACC_06", err)
+		}
+
+		err = q.DeleteMemberMagicLinks(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deleting magic links of member[%d]", arg.MemberID),
+				"ERR_DEL_ACC_10", err)
+		}
+
+		for _, username := range []string{arg.Phone, arg.Email} {
+			if username == "" {
+				continue
+			}
+			err = q.ResetSignInThrottle(ctx, helpers.UsernameThrottleKey(username))
+			if err != nil {
+				return utils.Fail(
+					fmt.Sprintf("error when deleting the sign-in throttles of member[%d]", arg.MemberID),
+					"ERR_DEL_ACC_12", err)
+			}
+		}
+
+		err = q.AnonymizeMember(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when anonymizing member[%d]", arg.MemberID),
+				"ERR_DEL_ACC_07", err)
+		}
+
+		err = q.DeleteUserOfMember(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deleting the user of member[%d]", arg.MemberID),
+				"ERR_DEL_ACC_08", err)
+		}
+
+		err = q.CompleteAccountDeletion(ctx, arg.AccountDeletionID)
+		return utils.Fail(
+			fmt.Sprintf("error when completing account deletion %d", arg.AccountDeletionID),
+			"ERR_DEL_ACC_09", err)
+	})
+
+	return err
+}
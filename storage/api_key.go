@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"tschwaa.com/api/models"
+)
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys(organization_id, created_by, name, prefix, key_hash, permissions)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, organization_id, created_by, name, prefix, key_hash, permissions, last_used_at, revoked_at, created_at, updated_at
+`
+
+type CreateAPIKeyParams struct {
+	OrganizationID uint64   `db:"organization_id" json:"organization_id"`
+	CreatedBy      *uint64  `db:"created_by" json:"created_by"`
+	Name           string   `db:"name" json:"name"`
+	Prefix         string   `db:"prefix" json:"prefix"`
+	KeyHash        string   `db:"key_hash" json:"key_hash"`
+	Permissions    []string `db:"permissions" json:"permissions"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (*models.APIKey, error) {
+	row := q.db.QueryRowContext(ctx, createAPIKey,
+		arg.OrganizationID,
+		arg.CreatedBy,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		pq.Array(arg.Permissions),
+	)
+	var i models.APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		pq.Array(&i.Permissions),
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getAPIKeyByHash = `-- name: GetAPIKeyByHash :one
+SELECT id, organization_id, created_by, name, prefix, key_hash, permissions, last_used_at, revoked_at, created_at, updated_at
+FROM api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	row := q.db.QueryRowContext(ctx, getAPIKeyByHash, keyHash)
+	var i models.APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		pq.Array(&i.Permissions),
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const listAPIKeysOfOrganization = `-- name: ListAPIKeysOfOrganization :many
+SELECT id, organization_id, created_by, name, prefix, key_hash, permissions, last_used_at, revoked_at, created_at, updated_at
+FROM api_keys
+WHERE organization_id = $1 AND revoked_at IS NULL
+ORDER BY created_at
+`
+
+func (q *Queries) ListAPIKeysOfOrganization(ctx context.Context, organizationID uint64) ([]*models.APIKey, error) {
+	rows, err := q.db.QueryContext(ctx, listAPIKeysOfOrganization, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.APIKey{}
+	for rows.Next() {
+		var i models.APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.CreatedBy,
+			&i.Name,
+			&i.Prefix,
+			&i.KeyHash,
+			pq.Array(&i.Permissions),
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND organization_id = $2 AND revoked_at IS NULL
+RETURNING id, organization_id, created_by, name, prefix, key_hash, permissions, last_used_at, revoked_at, created_at, updated_at
+`
+
+type RevokeAPIKeyParams struct {
+	ID             uint64 `db:"id" json:"id"`
+	OrganizationID uint64 `db:"organization_id" json:"organization_id"`
+}
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (*models.APIKey, error) {
+	row := q.db.QueryRowContext(ctx, revokeAPIKey, arg.ID, arg.OrganizationID)
+	var i models.APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		pq.Array(&i.Permissions),
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TouchAPIKey(ctx context.Context, id uint64) error {
+	_, err := q.db.ExecContext(ctx, touchAPIKey, id)
+	return err
+}
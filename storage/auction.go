@@ -0,0 +1,391 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tschwaa.com/api/models"
+)
+
+const upsertAuctionMode = `-- name: UpsertAuctionMode :one
+INSERT INTO auction_modes(session_id, bidding, pot, currency, created_by)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (session_id) DO UPDATE
+SET bidding = EXCLUDED.bidding, pot = EXCLUDED.pot, currency = EXCLUDED.currency, updated_at = NOW()
+RETURNING id, session_id, bidding, pot, currency, created_by, created_at, updated_at
+`
+
+type UpsertAuctionModeParams struct {
+	SessionID uint64  `db:"session_id" json:"session_id"`
+	Bidding   string  `db:"bidding" json:"bidding"`
+	Pot       int64   `db:"pot" json:"pot"`
+	Currency  string  `db:"currency" json:"currency"`
+	CreatedBy *uint64 `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) UpsertAuctionMode(ctx context.Context, arg UpsertAuctionModeParams) (*models.AuctionMode, error) {
+	row := q.db.QueryRowContext(ctx, upsertAuctionMode,
+		arg.SessionID,
+		arg.Bidding,
+		arg.Pot,
+		arg.Currency,
+		arg.CreatedBy,
+	)
+	var i models.AuctionMode
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Bidding,
+		&i.Pot,
+		&i.Currency,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getAuctionMode = `-- name: GetAuctionMode :one
+SELECT id, session_id, bidding, pot, currency, created_by, created_at, updated_at
+FROM auction_modes
+WHERE session_id = $1
+`
+
+func (q *Queries) GetAuctionMode(ctx context.Context, sessionID uint64) (*models.AuctionMode, error) {
+	row := q.db.QueryRowContext(ctx, getAuctionMode, sessionID)
+	var i models.AuctionMode
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Bidding,
+		&i.Pot,
+		&i.Currency,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const createAuction = `-- name: CreateAuction :one
+INSERT INTO auctions(session_id, meeting_date, bidding, pot, currency, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, session_id, meeting_date, bidding, pot, currency, status, winner_membership_id, winning_discount, created_by, closed_at, created_at, updated_at
+`
+
+type CreateAuctionParams struct {
+	SessionID   uint64    `db:"session_id" json:"session_id"`
+	MeetingDate time.Time `db:"meeting_date" json:"meeting_date"`
+	Bidding     string    `db:"bidding" json:"bidding"`
+	Pot         int64     `db:"pot" json:"pot"`
+	Currency    string    `db:"currency" json:"currency"`
+	CreatedBy   *uint64   `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) CreateAuction(ctx context.Context, arg CreateAuctionParams) (*models.Auction, error) {
+	row := q.db.QueryRowContext(ctx, createAuction,
+		arg.SessionID,
+		arg.MeetingDate,
+		arg.Bidding,
+		arg.Pot,
+		arg.Currency,
+		arg.CreatedBy,
+	)
+	var i models.Auction
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.MeetingDate,
+		&i.Bidding,
+		&i.Pot,
+		&i.Currency,
+		&i.Status,
+		&i.WinnerMembershipID,
+		&i.WinningDiscount,
+		&i.CreatedBy,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getAuction = `-- name: GetAuction :one
+SELECT id, session_id, meeting_date, bidding, pot, currency, status, winner_membership_id, winning_discount, created_by, closed_at, created_at, updated_at
+FROM auctions
+WHERE id = $1 AND session_id = $2
+`
+
+type GetAuctionParams struct {
+	ID        uint64 `db:"id" json:"id"`
+	SessionID uint64 `db:"session_id" json:"session_id"`
+}
+
+func (q *Queries) GetAuction(ctx context.Context, arg GetAuctionParams) (*models.Auction, error) {
+	row := q.db.QueryRowContext(ctx, getAuction, arg.ID, arg.SessionID)
+	var i models.Auction
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.MeetingDate,
+		&i.Bidding,
+		&i.Pot,
+		&i.Currency,
+		&i.Status,
+		&i.WinnerMembershipID,
+		&i.WinningDiscount,
+		&i.CreatedBy,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const listAuctionsOfSession = `-- name: ListAuctionsOfSession :many
+SELECT id, session_id, meeting_date, bidding, pot, currency, status, winner_membership_id, winning_discount, created_by, closed_at, created_at, updated_at
+FROM auctions
+WHERE session_id = $1
+ORDER BY meeting_date
+`
+
+func (q *Queries) ListAuctionsOfSession(ctx context.Context, sessionID uint64) ([]*models.Auction, error) {
+	rows, err := q.db.QueryContext(ctx, listAuctionsOfSession, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.Auction{}
+	for rows.Next() {
+		var i models.Auction
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.MeetingDate,
+			&i.Bidding,
+			&i.Pot,
+			&i.Currency,
+			&i.Status,
+			&i.WinnerMembershipID,
+			&i.WinningDiscount,
+			&i.CreatedBy,
+			&i.ClosedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const closeAuction = `-- name: CloseAuction :one
+UPDATE auctions
+SET status = 'closed', closed_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND session_id = $2 AND status = 'open'
+RETURNING id, session_id, meeting_date, bidding, pot, currency, status, winner_membership_id, winning_discount, created_by, closed_at, created_at, updated_at
+`
+
+// CloseAuction stops the bidding, it returns nil when the auction is not open
+func (q *Queries) CloseAuction(ctx context.Context, arg GetAuctionParams) (*models.Auction, error) {
+	row := q.db.QueryRowContext(ctx, closeAuction, arg.ID, arg.SessionID)
+	var i models.Auction
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.MeetingDate,
+		&i.Bidding,
+		&i.Pot,
+		&i.Currency,
+		&i.Status,
+		&i.WinnerMembershipID,
+		&i.WinningDiscount,
+		&i.CreatedBy,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const setAuctionWinner = `-- name: SetAuctionWinner :exec
+UPDATE auctions
+SET winner_membership_id = $2, winning_discount = $3, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetAuctionWinnerParams struct {
+	ID                 uint64 `db:"id" json:"id"`
+	WinnerMembershipID uint64 `db:"winner_membership_id" json:"winner_membership_id"`
+	WinningDiscount    int64  `db:"winning_discount" json:"winning_discount"`
+}
+
+func (q *Queries) SetAuctionWinner(ctx context.Context, arg SetAuctionWinnerParams) error {
+	_, err := q.db.ExecContext(ctx, setAuctionWinner, arg.ID, arg.WinnerMembershipID, arg.WinningDiscount)
+	return err
+}
+
+const createAuctionBid = `-- name: CreateAuctionBid :one
+INSERT INTO auction_bids(auction_id, membership_id, discount)
+SELECT a.id, $2, $3
+FROM auctions a
+WHERE a.id = $1 AND a.status = 'open'
+AND (a.bidding = 'sealed' OR $3 > (
+  SELECT COALESCE(MAX(b.discount), -1)
+  FROM auction_bids b
+  WHERE b.auction_id = a.id
+))
+-- the bids wait for the auction to be closed, or the other way round
+FOR UPDATE OF a
+RETURNING id, auction_id, membership_id, discount, created_at
+`
+
+type CreateAuctionBidParams struct {
+	AuctionID    uint64 `db:"auction_id" json:"auction_id"`
+	MembershipID uint64 `db:"membership_id" json:"membership_id"`
+	Discount     int64  `db:"discount" json:"discount"`
+}
+
+// CreateAuctionBid places the bid while the auction is open. In an open bidding, the bid has
+// to beat the highest discount so far. It returns nil when the bid is refused.
+func (q *Queries) CreateAuctionBid(ctx context.Context, arg CreateAuctionBidParams) (*models.AuctionBid, error) {
+	row := q.db.QueryRowContext(ctx, createAuctionBid, arg.AuctionID, arg.MembershipID, arg.Discount)
+	var i models.AuctionBid
+	err := row.Scan(
+		&i.ID,
+		&i.AuctionID,
+		&i.MembershipID,
+		&i.Discount,
+		&i.CreatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const listAuctionBids = `-- name: ListAuctionBids :many
+SELECT id, auction_id, membership_id, discount, created_at
+FROM auction_bids
+WHERE auction_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListAuctionBids(ctx context.Context, auctionID uint64) ([]*models.AuctionBid, error) {
+	rows, err := q.db.QueryContext(ctx, listAuctionBids, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.AuctionBid{}
+	for rows.Next() {
+		var i models.AuctionBid
+		if err := rows.Scan(
+			&i.ID,
+			&i.AuctionID,
+			&i.MembershipID,
+			&i.Discount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countAuctionsWon = `-- name: CountAuctionsWon :one
+SELECT COUNT(*)
+FROM auctions
+WHERE session_id = $1 AND winner_membership_id = $2
+`
+
+type CountAuctionsWonParams struct {
+	SessionID    uint64 `db:"session_id" json:"session_id"`
+	MembershipID uint64 `db:"winner_membership_id" json:"membership_id"`
+}
+
+// CountAuctionsWon counts the pots of the session the member has already taken
+func (q *Queries) CountAuctionsWon(ctx context.Context, arg CountAuctionsWonParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAuctionsWon, arg.SessionID, arg.MembershipID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAuctionPayout = `-- name: CreateAuctionPayout :exec
+INSERT INTO auction_payouts(auction_id, membership_id, kind, amount)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateAuctionPayoutParams struct {
+	AuctionID    uint64 `db:"auction_id" json:"auction_id"`
+	MembershipID uint64 `db:"membership_id" json:"membership_id"`
+	Kind         string `db:"kind" json:"kind"`
+	Amount       int64  `db:"amount" json:"amount"`
+}
+
+func (q *Queries) CreateAuctionPayout(ctx context.Context, arg CreateAuctionPayoutParams) error {
+	_, err := q.db.ExecContext(ctx, createAuctionPayout, arg.AuctionID, arg.MembershipID, arg.Kind, arg.Amount)
+	return err
+}
+
+const listAuctionPayouts = `-- name: ListAuctionPayouts :many
+SELECT id, auction_id, membership_id, kind, amount, created_at
+FROM auction_payouts
+WHERE auction_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListAuctionPayouts(ctx context.Context, auctionID uint64) ([]*models.AuctionPayout, error) {
+	rows, err := q.db.QueryContext(ctx, listAuctionPayouts, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.AuctionPayout{}
+	for rows.Next() {
+		var i models.AuctionPayout
+		if err := rows.Scan(
+			&i.ID,
+			&i.AuctionID,
+			&i.MembershipID,
+			&i.Kind,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
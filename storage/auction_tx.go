@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/utils"
+)
+
+var ErrAuctionNotOpen = errors.New("the auction is not open")
+
+type CloseAuctionTxParams struct {
+	AuctionID uint64
+	SessionID uint64
+}
+
+// CloseAuctionTx stops the bidding and awards the pot to the highest bid. The winner is paid
+// the pot less their discount, which is shared among the other hands of the session, or
+// given back to the winner when they hold the only hand.
+func (store *SQLStorage) CloseAuctionTx(ctx context.Context, arg CloseAuctionTxParams) (*models.Auction, error) {
+	var auction *models.Auction
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		auction, err = q.CloseAuction(ctx, GetAuctionParams{
+			ID:        arg.AuctionID,
+			SessionID: arg.SessionID,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when closing auction[%d]", arg.AuctionID),
+				"ERR_CLS_AUC_01", err)
+		}
+		if auction == nil {
+			return ErrAuctionNotOpen
+		}
+
+		bids, err := q.ListAuctionBids(ctx, auction.ID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when listing the bids of auction[%d]", auction.ID),
+				"ERR_CLS_AUC_02", err)
+		}
+		winner := helpers.AuctionWinner(bids)
+		if winner == nil {
+			return nil
+		}
+
+		err = q.SetAuctionWinner(ctx, SetAuctionWinnerParams{
+			ID:                 auction.ID,
+			WinnerMembershipID: winner.MembershipID,
+			WinningDiscount:    winner.Discount,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when setting the winner of auction[%d]", auction.ID),
+				"ERR_CLS_AUC_03", err)
+		}
+		auction.WinnerMembershipID = &winner.MembershipID
+		auction.WinningDiscount = &winner.Discount
+
+		members, err := q.ListMembersInSession(ctx, arg.SessionID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when listing the members of session[%d]", arg.SessionID),
+				"ERR_CLS_AUC_04", err)
+		}
+		dividends := helpers.AuctionDividends(members, winner.MembershipID, winner.Discount)
+
+		payouts := []CreateAuctionPayoutParams{{
+			AuctionID:    auction.ID,
+			MembershipID: winner.MembershipID,
+			Kind:         common.AUCTION_PAYOUT_POT,
+			Amount:       auction.Pot - winner.Discount,
+		}}
+		for _, member := range members {
+			if dividends[member.MembershipID] == 0 {
+				continue
+			}
+			payouts = append(payouts, CreateAuctionPayoutParams{
+				AuctionID:    auction.ID,
+				MembershipID: member.MembershipID,
+				Kind:         common.AUCTION_PAYOUT_DIVIDEND,
+				Amount:       dividends[member.MembershipID],
+			})
+		}
+
+		for _, payout := range payouts {
+			err = q.CreateAuctionPayout(ctx, payout)
+			if err != nil {
+				return utils.Fail(
+					fmt.Sprintf("error when paying membership[%d] out of auction[%d]", payout.MembershipID, auction.ID),
+					"ERR_CLS_AUC_05", err)
+			}
+		}
+
+		return nil
+	})
+
+	return auction, err
+}
@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tschwaa.com/api/models"
+)
+
+const createContributionPlan = `-- name: CreateContributionPlan :one
+INSERT INTO contribution_plans(session_id, name, amount, currency, frequency, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, session_id, name, amount, currency, frequency, created_by, created_at, updated_at
+`
+
+type CreateContributionPlanParams struct {
+	SessionID uint64  `db:"session_id" json:"session_id"`
+	Name      string  `db:"name" json:"name"`
+	Amount    int64   `db:"amount" json:"amount"`
+	Currency  string  `db:"currency" json:"currency"`
+	Frequency string  `db:"frequency" json:"frequency"`
+	CreatedBy *uint64 `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) CreateContributionPlan(ctx context.Context, arg CreateContributionPlanParams) (*models.ContributionPlan, error) {
+	row := q.db.QueryRowContext(ctx, createContributionPlan,
+		arg.SessionID,
+		arg.Name,
+		arg.Amount,
+		arg.Currency,
+		arg.Frequency,
+		arg.CreatedBy,
+	)
+	var i models.ContributionPlan
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Name,
+		&i.Amount,
+		&i.Currency,
+		&i.Frequency,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getContributionPlan = `-- name: GetContributionPlan :one
+SELECT id, session_id, name, amount, currency, frequency, created_by, created_at, updated_at
+FROM contribution_plans
+WHERE id = $1 AND session_id = $2
+`
+
+type GetContributionPlanParams struct {
+	ID        uint64 `db:"id" json:"id"`
+	SessionID uint64 `db:"session_id" json:"session_id"`
+}
+
+func (q *Queries) GetContributionPlan(ctx context.Context, arg GetContributionPlanParams) (*models.ContributionPlan, error) {
+	row := q.db.QueryRowContext(ctx, getContributionPlan, arg.ID, arg.SessionID)
+	var i models.ContributionPlan
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Name,
+		&i.Amount,
+		&i.Currency,
+		&i.Frequency,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const listContributionPlansOfSession = `-- name: ListContributionPlansOfSession :many
+SELECT id, session_id, name, amount, currency, frequency, created_by, created_at, updated_at
+FROM contribution_plans
+WHERE session_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListContributionPlansOfSession(ctx context.Context, sessionID uint64) ([]*models.ContributionPlan, error) {
+	rows, err := q.db.QueryContext(ctx, listContributionPlansOfSession, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.ContributionPlan{}
+	for rows.Next() {
+		var i models.ContributionPlan
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Name,
+			&i.Amount,
+			&i.Currency,
+			&i.Frequency,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateContributionPlan = `-- name: UpdateContributionPlan :one
+UPDATE contribution_plans
+SET name = $3, amount = $4, currency = $5, frequency = $6, updated_at = NOW()
+WHERE id = $1 AND session_id = $2
+RETURNING id, session_id, name, amount, currency, frequency, created_by, created_at, updated_at
+`
+
+type UpdateContributionPlanParams struct {
+	ID        uint64 `db:"id" json:"id"`
+	SessionID uint64 `db:"session_id" json:"session_id"`
+	Name      string `db:"name" json:"name"`
+	Amount    int64  `db:"amount" json:"amount"`
+	Currency  string `db:"currency" json:"currency"`
+	Frequency string `db:"frequency" json:"frequency"`
+}
+
+func (q *Queries) UpdateContributionPlan(ctx context.Context, arg UpdateContributionPlanParams) (*models.ContributionPlan, error) {
+	row := q.db.QueryRowContext(ctx, updateContributionPlan,
+		arg.ID,
+		arg.SessionID,
+		arg.Name,
+		arg.Amount,
+		arg.Currency,
+		arg.Frequency,
+	)
+	var i models.ContributionPlan
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Name,
+		&i.Amount,
+		&i.Currency,
+		&i.Frequency,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const createContribution = `-- name: CreateContribution :one
+INSERT INTO contributions(plan_id, membership_id, meeting_date, amount, note, recorded_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, plan_id, membership_id, meeting_date, amount, note, recorded_by, created_at, updated_at
+`
+
+type CreateContributionParams struct {
+	PlanID       uint64    `db:"plan_id" json:"plan_id"`
+	MembershipID uint64    `db:"membership_id" json:"membership_id"`
+	MeetingDate  time.Time `db:"meeting_date" json:"meeting_date"`
+	Amount       int64     `db:"amount" json:"amount"`
+	Note         string    `db:"note" json:"note"`
+	RecordedBy   *uint64   `db:"recorded_by" json:"recorded_by"`
+}
+
+func (q *Queries) CreateContribution(ctx context.Context, arg CreateContributionParams) (*models.Contribution, error) {
+	row := q.db.QueryRowContext(ctx, createContribution,
+		arg.PlanID,
+		arg.MembershipID,
+		arg.MeetingDate,
+		arg.Amount,
+		arg.Note,
+		arg.RecordedBy,
+	)
+	var i models.Contribution
+	err := row.Scan(
+		&i.ID,
+		&i.PlanID,
+		&i.MembershipID,
+		&i.MeetingDate,
+		&i.Amount,
+		&i.Note,
+		&i.RecordedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const listContributionsOfSession = `-- name: ListContributionsOfSession :many
+SELECT c.id, c.plan_id, c.membership_id, c.meeting_date, c.amount, c.note, c.recorded_by, c.created_at, c.updated_at
+FROM contributions c
+INNER JOIN contribution_plans p ON p.id = c.plan_id
+WHERE p.session_id = $1
+AND ($2::INTEGER = 0 OR c.plan_id = $2)
+AND ($3::INTEGER = 0 OR c.membership_id = $3)
+ORDER BY c.meeting_date, c.created_at
+`
+
+// ListContributionsOfSessionParams filters the payments of a session by plan and by member, 0 meaning any
+type ListContributionsOfSessionParams struct {
+	SessionID    uint64 `db:"session_id" json:"session_id"`
+	PlanID       uint64 `db:"plan_id" json:"plan_id"`
+	MembershipID uint64 `db:"membership_id" json:"membership_id"`
+}
+
+func (q *Queries) ListContributionsOfSession(ctx context.Context, arg ListContributionsOfSessionParams) ([]*models.Contribution, error) {
+	rows, err := q.db.QueryContext(ctx, listContributionsOfSession, arg.SessionID, arg.PlanID, arg.MembershipID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.Contribution{}
+	for rows.Next() {
+		var i models.Contribution
+		if err := rows.Scan(
+			&i.ID,
+			&i.PlanID,
+			&i.MembershipID,
+			&i.MeetingDate,
+			&i.Amount,
+			&i.Note,
+			&i.RecordedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteContribution = `-- name: DeleteContribution :one
+DELETE
+FROM contributions c
+USING contribution_plans p
+WHERE c.plan_id = p.id
+AND c.id = $1 AND p.session_id = $2
+RETURNING c.id, c.plan_id, c.membership_id, c.meeting_date, c.amount, c.note, c.recorded_by, c.created_at, c.updated_at
+`
+
+type DeleteContributionParams struct {
+	ID        uint64 `db:"id" json:"id"`
+	SessionID uint64 `db:"session_id" json:"session_id"`
+}
+
+func (q *Queries) DeleteContribution(ctx context.Context, arg DeleteContributionParams) (*models.Contribution, error) {
+	row := q.db.QueryRowContext(ctx, deleteContribution, arg.ID, arg.SessionID)
+	var i models.Contribution
+	err := row.Scan(
+		&i.ID,
+		&i.PlanID,
+		&i.MembershipID,
+		&i.MeetingDate,
+		&i.Amount,
+		&i.Note,
+		&i.RecordedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const sumContributionsOfSession = `-- name: SumContributionsOfSession :many
+SELECT c.plan_id, c.membership_id, SUM(c.amount)::BIGINT AS paid
+FROM contributions c
+INNER JOIN contribution_plans p ON p.id = c.plan_id
+WHERE p.session_id = $1 AND c.meeting_date <= $2
+GROUP BY c.plan_id, c.membership_id
+`
+
+type SumContributionsOfSessionParams struct {
+	SessionID uint64    `db:"session_id" json:"session_id"`
+	AsOf      time.Time `db:"meeting_date" json:"as_of"`
+}
+
+func (q *Queries) SumContributionsOfSession(ctx context.Context, arg SumContributionsOfSessionParams) ([]*models.ContributionTotal, error) {
+	rows, err := q.db.QueryContext(ctx, sumContributionsOfSession, arg.SessionID, arg.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.ContributionTotal{}
+	for rows.Next() {
+		var i models.ContributionTotal
+		if err := rows.Scan(
+			&i.PlanID,
+			&i.MembershipID,
+			&i.Paid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tschwaa.com/api/models"
+)
+
+const createLogin = `-- name: CreateLogin :one
+INSERT INTO logins(member_id, family_id, channel, user_agent, ip, two_factor_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, member_id, family_id, channel, user_agent, ip, last_seen_at, revoked_at, two_factor_at, created_at, updated_at
+`
+
+type CreateLoginParams struct {
+	MemberID  uint64 `db:"member_id" json:"member_id"`
+	FamilyID  string `db:"family_id" json:"family_id"`
+	Channel   string `db:"channel" json:"channel"`
+	UserAgent string `db:"user_agent" json:"user_agent"`
+	IP        string `db:"ip" json:"ip"`
+	// TwoFactorAt is when the member passed the second step of the sign in, if any
+	TwoFactorAt *time.Time `db:"two_factor_at" json:"two_factor_at"`
+}
+
+func (q *Queries) CreateLogin(ctx context.Context, arg CreateLoginParams) (*models.Login, error) {
+	row := q.db.QueryRowContext(ctx, createLogin,
+		arg.MemberID,
+		arg.FamilyID,
+		arg.Channel,
+		arg.UserAgent,
+		arg.IP,
+		arg.TwoFactorAt,
+	)
+	var i models.Login
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.FamilyID,
+		&i.Channel,
+		&i.UserAgent,
+		&i.IP,
+		&i.LastSeenAt,
+		&i.RevokedAt,
+		&i.TwoFactorAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getLogin = `-- name: GetLogin :one
+SELECT id, member_id, family_id, channel, user_agent, ip, last_seen_at, revoked_at, two_factor_at, created_at, updated_at
+FROM logins
+WHERE id = $1 AND member_id = $2
+`
+
+type GetLoginParams struct {
+	ID       uint64 `db:"id" json:"id"`
+	MemberID uint64 `db:"member_id" json:"member_id"`
+}
+
+func (q *Queries) GetLogin(ctx context.Context, arg GetLoginParams) (*models.Login, error) {
+	row := q.db.QueryRowContext(ctx, getLogin, arg.ID, arg.MemberID)
+	var i models.Login
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.FamilyID,
+		&i.Channel,
+		&i.UserAgent,
+		&i.IP,
+		&i.LastSeenAt,
+		&i.RevokedAt,
+		&i.TwoFactorAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const touchLogin = `-- name: TouchLogin :one
+UPDATE logins
+SET last_seen_at = NOW(), updated_at = NOW()
+WHERE family_id = $1
+RETURNING id, member_id, family_id, channel, user_agent, ip, last_seen_at, revoked_at, two_factor_at, created_at, updated_at
+`
+
+// TouchLogin marks the login of the refresh token family as seen now,
+// it returns nil for the families opened before logins were recorded
+func (q *Queries) TouchLogin(ctx context.Context, familyID string) (*models.Login, error) {
+	row := q.db.QueryRowContext(ctx, touchLogin, familyID)
+	var i models.Login
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.FamilyID,
+		&i.Channel,
+		&i.UserAgent,
+		&i.IP,
+		&i.LastSeenAt,
+		&i.RevokedAt,
+		&i.TwoFactorAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const listActiveLoginsOfMember = `-- name: ListActiveLoginsOfMember :many
+SELECT id, member_id, family_id, channel, user_agent, ip, last_seen_at, revoked_at, two_factor_at, created_at, updated_at
+FROM logins
+WHERE member_id = $1 AND revoked_at IS NULL AND last_seen_at > $2
+ORDER BY last_seen_at DESC
+`
+
+type ListActiveLoginsOfMemberParams struct {
+	MemberID uint64    `db:"member_id" json:"member_id"`
+	Since    time.Time `db:"since" json:"since"`
+}
+
+func (q *Queries) ListActiveLoginsOfMember(ctx context.Context, arg ListActiveLoginsOfMemberParams) ([]*models.Login, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveLoginsOfMember, arg.MemberID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.Login{}
+	for rows.Next() {
+		var i models.Login
+		if err := rows.Scan(
+			&i.ID,
+			&i.MemberID,
+			&i.FamilyID,
+			&i.Channel,
+			&i.UserAgent,
+			&i.IP,
+			&i.LastSeenAt,
+			&i.RevokedAt,
+			&i.TwoFactorAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeLogin = `-- name: RevokeLogin :exec
+UPDATE logins
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeLogin(ctx context.Context, id uint64) error {
+	_, err := q.db.ExecContext(ctx, revokeLogin, id)
+	return err
+}
+
+const revokeMemberLogins = `-- name: RevokeMemberLogins :exec
+UPDATE logins
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE member_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeMemberLogins(ctx context.Context, memberID uint64) error {
+	_, err := q.db.ExecContext(ctx, revokeMemberLogins, memberID)
+	return err
+}
+
+const markLoginTwoFactor = `-- name: MarkLoginTwoFactor :exec
+UPDATE logins
+SET two_factor_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND member_id = $2 AND revoked_at IS NULL
+`
+
+type MarkLoginTwoFactorParams struct {
+	ID       uint64 `db:"id" json:"id"`
+	MemberID uint64 `db:"member_id" json:"member_id"`
+}
+
+func (q *Queries) MarkLoginTwoFactor(ctx context.Context, arg MarkLoginTwoFactorParams) error {
+	_, err := q.db.ExecContext(ctx, markLoginTwoFactor, arg.ID, arg.MemberID)
+	return err
+}
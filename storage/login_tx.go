@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/utils"
+)
+
+var ErrLoginNotFound = errors.New("login not found")
+
+type CreateLoginTxParams struct {
+	MemberID    uint64
+	FamilyID    string
+	TokenHash   string
+	ExpiresAt   time.Time
+	Channel     string
+	UserAgent   string
+	IP          string
+	TwoFactorAt *time.Time
+}
+
+// CreateLoginTx records a login along with the first refresh token of its family
+func (store *SQLStorage) CreateLoginTx(ctx context.Context, arg CreateLoginTxParams) (*models.Login, error) {
+	var result *models.Login
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+			MemberID:  arg.MemberID,
+			FamilyID:  arg.FamilyID,
+			TokenHash: arg.TokenHash,
+			ExpiresAt: arg.ExpiresAt,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when creating the refresh token of member[%d]", arg.MemberID),
+				"ERR_CRT_LGN_01", err)
+		}
+
+		result, err = q.CreateLogin(ctx, CreateLoginParams{
+			MemberID:    arg.MemberID,
+			FamilyID:    arg.FamilyID,
+			Channel:     arg.Channel,
+			UserAgent:   arg.UserAgent,
+			IP:          arg.IP,
+			TwoFactorAt: arg.TwoFactorAt,
+		})
+		return utils.Fail(
+			fmt.Sprintf("error when creating the login of member[%d]", arg.MemberID),
+			"ERR_CRT_LGN_02", err)
+	})
+
+	return result, err
+}
+
+type RevokeLoginParams struct {
+	ID       uint64
+	MemberID uint64
+}
+
+// RevokeLoginTx ends a login of the member: its access tokens are rejected
+// and its refresh token family can no longer be rotated
+func (store *SQLStorage) RevokeLoginTx(ctx context.Context, arg RevokeLoginParams) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+		login, err := q.GetLogin(ctx, GetLoginParams{
+			ID:       arg.ID,
+			MemberID: arg.MemberID,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when getting login[%d] of member[%d]", arg.ID, arg.MemberID),
+				"ERR_RVK_LGN_01", err)
+		}
+		if login == nil {
+			return ErrLoginNotFound
+		}
+
+		err = q.RevokeLogin(ctx, login.ID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when revoking login[%d]", login.ID),
+				"ERR_RVK_LGN_02", err)
+		}
+
+		err = q.RevokeRefreshTokenFamily(ctx, login.FamilyID)
+		return utils.Fail(
+			fmt.Sprintf("error when revoking refresh token family %s", login.FamilyID),
+			"ERR_RVK_LGN_03", err)
+	})
+
+	return err
+}
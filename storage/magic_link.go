@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tschwaa.com/api/models"
+)
+
+const createMagicLink = `-- name: CreateMagicLink :one
+INSERT INTO magic_links(member_id, phone, device_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, member_id, phone, device_hash, expires_at, used_at, created_at, updated_at
+`
+
+type CreateMagicLinkParams struct {
+	MemberID   uint64    `db:"member_id" json:"member_id"`
+	Phone      string    `db:"phone" json:"phone"`
+	DeviceHash string    `db:"device_hash" json:"device_hash"`
+	ExpiresAt  time.Time `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreateMagicLink(ctx context.Context, arg CreateMagicLinkParams) (*models.MagicLink, error) {
+	row := q.db.QueryRowContext(ctx, createMagicLink,
+		arg.MemberID,
+		arg.Phone,
+		arg.DeviceHash,
+		arg.ExpiresAt,
+	)
+	var i models.MagicLink
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.Phone,
+		&i.DeviceHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getLatestMagicLinkFromPhone = `-- name: GetLatestMagicLinkFromPhone :one
+SELECT id, member_id, phone, device_hash, expires_at, used_at, created_at, updated_at
+FROM magic_links
+WHERE phone = $1
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestMagicLinkFromPhone(ctx context.Context, phone string) (*models.MagicLink, error) {
+	row := q.db.QueryRowContext(ctx, getLatestMagicLinkFromPhone, phone)
+	var i models.MagicLink
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.Phone,
+		&i.DeviceHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const expireMagicLinksOfPhone = `-- name: ExpireMagicLinksOfPhone :exec
+UPDATE magic_links
+SET expires_at = NOW(), updated_at = NOW()
+WHERE phone = $1 AND used_at IS NULL AND expires_at > NOW()
+`
+
+func (q *Queries) ExpireMagicLinksOfPhone(ctx context.Context, phone string) error {
+	_, err := q.db.ExecContext(ctx, expireMagicLinksOfPhone, phone)
+	return err
+}
+
+const consumeMagicLink = `-- name: ConsumeMagicLink :one
+UPDATE magic_links
+SET used_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND phone = $2 AND device_hash = $3 AND used_at IS NULL AND expires_at > NOW()
+RETURNING id, member_id, phone, device_hash, expires_at, used_at, created_at, updated_at
+`
+
+type ConsumeMagicLinkParams struct {
+	ID         uint64 `db:"id" json:"id"`
+	Phone      string `db:"phone" json:"phone"`
+	DeviceHash string `db:"device_hash" json:"device_hash"`
+}
+
+func (q *Queries) ConsumeMagicLink(ctx context.Context, arg ConsumeMagicLinkParams) (*models.MagicLink, error) {
+	row := q.db.QueryRowContext(ctx, consumeMagicLink, arg.ID, arg.Phone, arg.DeviceHash)
+	var i models.MagicLink
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.Phone,
+		&i.DeviceHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const deleteMemberMagicLinks = `-- name: DeleteMemberMagicLinks :exec
+DELETE FROM magic_links
+WHERE member_id = $1
+`
+
+func (q *Queries) DeleteMemberMagicLinks(ctx context.Context, memberID uint64) error {
+	_, err := q.db.ExecContext(ctx, deleteMemberMagicLinks, memberID)
+	return err
+}
+
+const deleteMagicLink = `-- name: DeleteMagicLink :exec
+DELETE FROM magic_links
+WHERE id = $1
+`
+
+// DeleteMagicLink removes a magic link which could not be sent, so that it does not count as sent
+func (q *Queries) DeleteMagicLink(ctx context.Context, id uint64) error {
+	_, err := q.db.ExecContext(ctx, deleteMagicLink, id)
+	return err
+}
+
+const getMagicLinkSendStatsFromPhone = `-- name: GetMagicLinkSendStatsFromPhone :one
+SELECT COUNT(*), MIN(created_at), MAX(created_at)
+FROM magic_links
+WHERE phone = $1 AND created_at > $2
+`
+
+// GetMagicLinkSendStatsFromPhone counts the magic links sent to the phone since the time given,
+// the same way as the pin codes
+func (q *Queries) GetMagicLinkSendStatsFromPhone(ctx context.Context, arg GetOTPSendStatsFromPhoneParams) (*OTPSendStats, error) {
+	row := q.db.QueryRowContext(ctx, getMagicLinkSendStatsFromPhone, arg.Phone, arg.Since)
+	var i OTPSendStats
+	err := row.Scan(
+		&i.Count,
+		&i.Oldest,
+		&i.Latest,
+	)
+	return &i, err
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/utils"
+)
+
+// CreateMagicLinkTx expires the magic links still pending for the phone and creates a new one
+func (store *SQLStorage) CreateMagicLinkTx(ctx context.Context, arg CreateMagicLinkParams) (*models.MagicLink, error) {
+	var result *models.MagicLink
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.ExpireMagicLinksOfPhone(ctx, arg.Phone)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when expiring the magic links of member[%d]", arg.MemberID),
+				"ERR_CRT_MGC_LNK_01", err)
+		}
+
+		result, err = q.CreateMagicLink(ctx, arg)
+		return utils.Fail(
+			fmt.Sprintf("error when creating a magic link for member[%d]", arg.MemberID),
+			"ERR_CRT_MGC_LNK_02", err)
+	})
+
+	return result, err
+}
@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tschwaa.com/api/models"
+)
+
+const upsertMeetingRecurrence = `-- name: UpsertMeetingRecurrence :one
+INSERT INTO meeting_recurrences(session_id, frequency, weekday, week_of_month, start_time, place, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (session_id) DO UPDATE
+SET frequency = EXCLUDED.frequency, weekday = EXCLUDED.weekday, week_of_month = EXCLUDED.week_of_month,
+  start_time = EXCLUDED.start_time, place = EXCLUDED.place, updated_at = NOW()
+RETURNING id, session_id, frequency, weekday, week_of_month, start_time, place, created_by, created_at, updated_at
+`
+
+type UpsertMeetingRecurrenceParams struct {
+	SessionID   uint64  `db:"session_id" json:"session_id"`
+	Frequency   string  `db:"frequency" json:"frequency"`
+	Weekday     *int    `db:"weekday" json:"weekday"`
+	WeekOfMonth *int    `db:"week_of_month" json:"week_of_month"`
+	StartTime   string  `db:"start_time" json:"start_time"`
+	Place       string  `db:"place" json:"place"`
+	CreatedBy   *uint64 `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) UpsertMeetingRecurrence(ctx context.Context, arg UpsertMeetingRecurrenceParams) (*models.MeetingRecurrence, error) {
+	row := q.db.QueryRowContext(ctx, upsertMeetingRecurrence,
+		arg.SessionID,
+		arg.Frequency,
+		arg.Weekday,
+		arg.WeekOfMonth,
+		arg.StartTime,
+		arg.Place,
+		arg.CreatedBy,
+	)
+	var i models.MeetingRecurrence
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Frequency,
+		&i.Weekday,
+		&i.WeekOfMonth,
+		&i.StartTime,
+		&i.Place,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getMeetingRecurrence = `-- name: GetMeetingRecurrence :one
+SELECT id, session_id, frequency, weekday, week_of_month, start_time, place, created_by, created_at, updated_at
+FROM meeting_recurrences
+WHERE session_id = $1
+`
+
+func (q *Queries) GetMeetingRecurrence(ctx context.Context, sessionID uint64) (*models.MeetingRecurrence, error) {
+	row := q.db.QueryRowContext(ctx, getMeetingRecurrence, sessionID)
+	var i models.MeetingRecurrence
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Frequency,
+		&i.Weekday,
+		&i.WeekOfMonth,
+		&i.StartTime,
+		&i.Place,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const deleteUpcomingRecurringMeetings = `-- name: DeleteUpcomingRecurringMeetings :exec
+DELETE
+FROM meetings
+WHERE session_id = $1 AND original_date >= $2
+AND status = 'scheduled' AND rescheduled_at IS NULL
+`
+
+type DeleteUpcomingRecurringMeetingsParams struct {
+	SessionID uint64    `db:"session_id" json:"session_id"`
+	From      time.Time `db:"original_date" json:"from"`
+}
+
+// DeleteUpcomingRecurringMeetings drops the meetings to come as the recurrence set them,
+// the ones cancelled or rescheduled by hand are kept
+func (q *Queries) DeleteUpcomingRecurringMeetings(ctx context.Context, arg DeleteUpcomingRecurringMeetingsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUpcomingRecurringMeetings, arg.SessionID, arg.From)
+	return err
+}
+
+const createMeeting = `-- name: CreateMeeting :exec
+INSERT INTO meetings(session_id, original_date, date, start_time, place)
+VALUES ($1, $2, $2, $3, $4)
+ON CONFLICT (session_id, original_date) DO NOTHING
+`
+
+type CreateMeetingParams struct {
+	SessionID uint64    `db:"session_id" json:"session_id"`
+	Date      time.Time `db:"original_date" json:"date"`
+	StartTime string    `db:"start_time" json:"start_time"`
+	Place     string    `db:"place" json:"place"`
+}
+
+// CreateMeeting adds an occurrence of the recurrence, unless one already takes that date
+func (q *Queries) CreateMeeting(ctx context.Context, arg CreateMeetingParams) error {
+	_, err := q.db.ExecContext(ctx, createMeeting, arg.SessionID, arg.Date, arg.StartTime, arg.Place)
+	return err
+}
+
+const listMeetingsOfSession = `-- name: ListMeetingsOfSession :many
+SELECT id, session_id, original_date, date, start_time, place, status, note, rescheduled_at, cancelled_at, updated_by, created_at, updated_at
+FROM meetings
+WHERE session_id = $1
+ORDER BY date, start_time
+`
+
+func (q *Queries) ListMeetingsOfSession(ctx context.Context, sessionID uint64) ([]*models.Meeting, error) {
+	rows, err := q.db.QueryContext(ctx, listMeetingsOfSession, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.Meeting{}
+	for rows.Next() {
+		var i models.Meeting
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.OriginalDate,
+			&i.Date,
+			&i.StartTime,
+			&i.Place,
+			&i.Status,
+			&i.Note,
+			&i.RescheduledAt,
+			&i.CancelledAt,
+			&i.UpdatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMeeting = `-- name: GetMeeting :one
+SELECT id, session_id, original_date, date, start_time, place, status, note, rescheduled_at, cancelled_at, updated_by, created_at, updated_at
+FROM meetings
+WHERE id = $1 AND session_id = $2
+`
+
+type GetMeetingParams struct {
+	ID        uint64 `db:"id" json:"id"`
+	SessionID uint64 `db:"session_id" json:"session_id"`
+}
+
+func (q *Queries) GetMeeting(ctx context.Context, arg GetMeetingParams) (*models.Meeting, error) {
+	row := q.db.QueryRowContext(ctx, getMeeting, arg.ID, arg.SessionID)
+	var i models.Meeting
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.OriginalDate,
+		&i.Date,
+		&i.StartTime,
+		&i.Place,
+		&i.Status,
+		&i.Note,
+		&i.RescheduledAt,
+		&i.CancelledAt,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const cancelMeeting = `-- name: CancelMeeting :one
+UPDATE meetings
+SET status = 'cancelled', note = $3, updated_by = $4, cancelled_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND session_id = $2 AND status = 'scheduled'
+RETURNING id, session_id, original_date, date, start_time, place, status, note, rescheduled_at, cancelled_at, updated_by, created_at, updated_at
+`
+
+type CancelMeetingParams struct {
+	ID        uint64  `db:"id" json:"id"`
+	SessionID uint64  `db:"session_id" json:"session_id"`
+	Note      string  `db:"note" json:"note"`
+	UpdatedBy *uint64 `db:"updated_by" json:"updated_by"`
+}
+
+// CancelMeeting calls off a scheduled meeting, it returns nil when it is not scheduled anymore
+func (q *Queries) CancelMeeting(ctx context.Context, arg CancelMeetingParams) (*models.Meeting, error) {
+	row := q.db.QueryRowContext(ctx, cancelMeeting, arg.ID, arg.SessionID, arg.Note, arg.UpdatedBy)
+	var i models.Meeting
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.OriginalDate,
+		&i.Date,
+		&i.StartTime,
+		&i.Place,
+		&i.Status,
+		&i.Note,
+		&i.RescheduledAt,
+		&i.CancelledAt,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const rescheduleMeeting = `-- name: RescheduleMeeting :one
+UPDATE meetings
+SET date = $3, start_time = $4, place = $5, note = $6, updated_by = $7, rescheduled_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND session_id = $2 AND status = 'scheduled'
+RETURNING id, session_id, original_date, date, start_time, place, status, note, rescheduled_at, cancelled_at, updated_by, created_at, updated_at
+`
+
+type RescheduleMeetingParams struct {
+	ID        uint64    `db:"id" json:"id"`
+	SessionID uint64    `db:"session_id" json:"session_id"`
+	Date      time.Time `db:"date" json:"date"`
+	StartTime string    `db:"start_time" json:"start_time"`
+	Place     string    `db:"place" json:"place"`
+	Note      string    `db:"note" json:"note"`
+	UpdatedBy *uint64   `db:"updated_by" json:"updated_by"`
+}
+
+// RescheduleMeeting moves a scheduled meeting, it returns nil when it has been cancelled
+func (q *Queries) RescheduleMeeting(ctx context.Context, arg RescheduleMeetingParams) (*models.Meeting, error) {
+	row := q.db.QueryRowContext(ctx, rescheduleMeeting,
+		arg.ID,
+		arg.SessionID,
+		arg.Date,
+		arg.StartTime,
+		arg.Place,
+		arg.Note,
+		arg.UpdatedBy,
+	)
+	var i models.Meeting
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.OriginalDate,
+		&i.Date,
+		&i.StartTime,
+		&i.Place,
+		&i.Status,
+		&i.Note,
+		&i.RescheduledAt,
+		&i.CancelledAt,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
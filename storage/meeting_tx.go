@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/utils"
+)
+
+type SetMeetingRecurrenceParams struct {
+	SessionID   uint64
+	Frequency   string
+	Weekday     *int
+	WeekOfMonth *int
+	StartTime   string
+	Place       string
+	CreatedBy   *uint64
+	// From is the day the meetings are set again from, the past ones are left alone
+	From time.Time
+	// Dates are the meetings the recurrence sets from that day to the end of the session
+	Dates []time.Time
+}
+
+// SetMeetingRecurrenceTx saves the recurrence of the session and sets its meetings to come
+// again, keeping the ones cancelled or rescheduled by hand
+func (store *SQLStorage) SetMeetingRecurrenceTx(ctx context.Context, arg SetMeetingRecurrenceParams) (*models.MeetingRecurrence, error) {
+	var recurrence *models.MeetingRecurrence
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		recurrence, err = q.UpsertMeetingRecurrence(ctx, UpsertMeetingRecurrenceParams{
+			SessionID:   arg.SessionID,
+			Frequency:   arg.Frequency,
+			Weekday:     arg.Weekday,
+			WeekOfMonth: arg.WeekOfMonth,
+			StartTime:   arg.StartTime,
+			Place:       arg.Place,
+			CreatedBy:   arg.CreatedBy,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when saving the meeting recurrence of session[%d]", arg.SessionID),
+				"ERR_SET_MTG_REC_01", err)
+		}
+
+		err = q.DeleteUpcomingRecurringMeetings(ctx, DeleteUpcomingRecurringMeetingsParams{
+			SessionID: arg.SessionID,
+			From:      arg.From,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deleting the upcoming meetings of session[%d]", arg.SessionID),
+				"ERR_SET_MTG_REC_02", err)
+		}
+
+		for _, date := range arg.Dates {
+			err = q.CreateMeeting(ctx, CreateMeetingParams{
+				SessionID: arg.SessionID,
+				Date:      date,
+				StartTime: recurrence.StartTime,
+				Place:     recurrence.Place,
+			})
+			if err != nil {
+				return utils.Fail(
+					fmt.Sprintf("error when creating the meeting of %s in session[%d]", date.Format("2006-01-02"), arg.SessionID),
+					"ERR_SET_MTG_REC_03", err)
+			}
+		}
+
+		return nil
+	})
+
+	return recurrence, err
+}
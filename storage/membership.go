@@ -43,8 +43,8 @@ func (q *Queries) DoesMembershipExist(ctx context.Context, arg DoesMembershipExi
 }
 
 const createMembership = `-- name: CreateMembership :one
-INSERT INTO memberships(member_id, organization_id, joined, joined_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO memberships(member_id, organization_id, joined, joined_at, role)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING id, member_id, organization_id, created_at, updated_at, joined, joined_at, position, status, role
 `
 
@@ -53,6 +53,7 @@ type CreateMembershipParams struct {
 	OrganizationID uint64    `db:"organization_id" json:"organization_id"`
 	Joined         bool      `db:"joined" json:"joined"`
 	JoinedAt       time.Time `db:"joined_at" json:"joined_at"`
+	Role           string    `db:"role" json:"role"`
 }
 
 func (q *Queries) CreateMembership(ctx context.Context, arg CreateMembershipParams) (*models.Membership, error) {
@@ -61,6 +62,7 @@ func (q *Queries) CreateMembership(ctx context.Context, arg CreateMembershipPara
 		arg.OrganizationID,
 		arg.Joined,
 		arg.JoinedAt,
+		arg.Role,
 	)
 	var i models.Membership
 	err := row.Scan(
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"tschwaa.com/api/common"
 	"tschwaa.com/api/models"
 	"tschwaa.com/api/utils"
 )
@@ -25,6 +26,7 @@ func (store *SQLStorage) CreateInvitationTx(ctx context.Context, arg CreateMembe
 			OrganizationID: arg.OrganizationID,
 			Joined:         false,
 			JoinedAt:       time.Now(),
+			Role:           common.MEMBERSHIP_ROLE_MEMBER,
 		})
 		if err != nil {
 			return utils.Fail(
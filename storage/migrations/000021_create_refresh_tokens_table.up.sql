@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  member_id INTEGER NOT NULL,
+  family_id TEXT NOT NULL,
+  token_hash TEXT NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  replaced_by INTEGER DEFAULT NULL,
+  revoked_at TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT ak_refresh_tokens_token_hash
+    UNIQUE(token_hash),
+  CONSTRAINT fk_refresh_tokens_members_member_id
+    FOREIGN KEY (member_id) REFERENCES members(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
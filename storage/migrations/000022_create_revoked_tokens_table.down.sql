@@ -0,0 +1,5 @@
+ALTER TABLE members
+  DROP COLUMN tokens_revoked_at
+;
+
+DROP TABLE IF EXISTS revoked_tokens;
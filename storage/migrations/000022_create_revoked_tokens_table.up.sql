@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  jti TEXT NOT NULL,
+  member_id INTEGER NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT ak_revoked_tokens_jti
+    UNIQUE(jti),
+  CONSTRAINT fk_revoked_tokens_members_member_id
+    FOREIGN KEY (member_id) REFERENCES members(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+ALTER TABLE members
+  ADD COLUMN tokens_revoked_at TIMESTAMP DEFAULT NULL
+;
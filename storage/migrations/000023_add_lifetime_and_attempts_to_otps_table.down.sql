@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_otps_phone_created_at;
+
+ALTER TABLE otps
+  DROP COLUMN attempts,
+  DROP COLUMN expires_at,
+  DROP COLUMN created_at,
+  DROP COLUMN updated_at
+;
@@ -0,0 +1,9 @@
+ALTER TABLE otps
+  ALTER COLUMN pin_code TYPE VARCHAR(10),
+  ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN expires_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+;
+
+CREATE INDEX IF NOT EXISTS idx_otps_phone_created_at ON otps(phone, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE otps DROP COLUMN IF EXISTS channel;
+ALTER TABLE otps RENAME COLUMN message_id TO wa_message_id;
@@ -0,0 +1,2 @@
+ALTER TABLE otps RENAME COLUMN wa_message_id TO message_id;
+ALTER TABLE otps ADD COLUMN channel TEXT NOT NULL DEFAULT 'whatsapp';
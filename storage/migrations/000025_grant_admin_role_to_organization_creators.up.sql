@@ -0,0 +1,4 @@
+UPDATE memberships m
+SET role = 'admin'
+FROM organizations o
+WHERE m.organization_id = o.id AND m.member_id = o.created_by;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_logins_member_id;
+
+DROP TABLE IF EXISTS logins;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS logins (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  member_id INTEGER NOT NULL,
+  family_id TEXT NOT NULL,
+  channel TEXT NOT NULL,
+  user_agent TEXT NOT NULL DEFAULT '',
+  ip TEXT NOT NULL DEFAULT '',
+  last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  revoked_at TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT ak_logins_family_id
+    UNIQUE(family_id),
+  CONSTRAINT fk_logins_members_member_id
+    FOREIGN KEY (member_id) REFERENCES members(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_logins_member_id ON logins(member_id);
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP DEFAULT NULL;
+
+-- the accounts created so far keep their access
+UPDATE users SET email_verified_at = created_at;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_phone_changes_new_phone;
+DROP INDEX IF EXISTS idx_phone_changes_member_id;
+
+DROP TABLE IF EXISTS phone_changes;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS phone_changes (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  member_id INTEGER NOT NULL,
+  old_phone VARCHAR(15) NOT NULL,
+  new_phone VARCHAR(15) NOT NULL,
+  approved_by INTEGER DEFAULT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  expires_at TIMESTAMP NOT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_phone_changes_members_member_id
+    FOREIGN KEY (member_id) REFERENCES members(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_phone_changes_members_approved_by
+    FOREIGN KEY (approved_by) REFERENCES members(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_phone_changes_member_id ON phone_changes(member_id);
+CREATE INDEX IF NOT EXISTS idx_phone_changes_new_phone ON phone_changes(new_phone);
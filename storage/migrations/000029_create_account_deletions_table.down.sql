@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_account_deletions_scheduled_for;
+
+DROP TABLE IF EXISTS account_deletions;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS account_deletions (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  member_id INTEGER NOT NULL,
+  scheduled_for TIMESTAMP NOT NULL,
+  cancelled_at TIMESTAMP DEFAULT NULL,
+  completed_at TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_account_deletions_members_member_id
+    FOREIGN KEY (member_id) REFERENCES members(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_deletions_scheduled_for ON account_deletions(scheduled_for);
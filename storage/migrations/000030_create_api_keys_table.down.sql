@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_api_keys_organization_id;
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  organization_id INTEGER NOT NULL,
+  created_by INTEGER,
+  name TEXT NOT NULL,
+  prefix TEXT NOT NULL,
+  key_hash TEXT UNIQUE NOT NULL,
+  permissions TEXT[] NOT NULL DEFAULT '{}',
+  last_used_at TIMESTAMP DEFAULT NULL,
+  revoked_at TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_api_keys_organizations_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_api_keys_members_created_by
+    FOREIGN KEY (created_by) REFERENCES members(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_organization_id ON api_keys(organization_id);
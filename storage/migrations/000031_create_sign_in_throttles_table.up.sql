@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS sign_in_throttles (
+  key TEXT NOT NULL,
+  failures INTEGER NOT NULL DEFAULT 0,
+  last_failed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  locked_until TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (key)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_magic_links_phone;
+
+DROP TABLE IF EXISTS magic_links;
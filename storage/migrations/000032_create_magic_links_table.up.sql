@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS magic_links (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  member_id INTEGER NOT NULL,
+  phone VARCHAR(15) NOT NULL,
+  device_hash TEXT NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_magic_links_members_member_id
+    FOREIGN KEY (member_id) REFERENCES members(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_magic_links_phone ON magic_links(phone);
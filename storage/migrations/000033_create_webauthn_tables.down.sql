@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS webauthn_challenges;
+
+DROP INDEX IF EXISTS idx_webauthn_credentials_user_id;
+
+DROP TABLE IF EXISTS webauthn_credentials;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  user_id INTEGER NOT NULL,
+  credential_id TEXT UNIQUE NOT NULL,
+  public_key BYTEA NOT NULL,
+  algorithm INTEGER NOT NULL,
+  sign_count BIGINT NOT NULL DEFAULT 0,
+  name TEXT NOT NULL,
+  last_used_at TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_webauthn_credentials_users_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
+
+CREATE TABLE IF NOT EXISTS webauthn_challenges (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  challenge TEXT UNIQUE NOT NULL,
+  ceremony TEXT NOT NULL,
+  user_id INTEGER,
+  expires_at TIMESTAMP NOT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_webauthn_challenges_users_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
@@ -0,0 +1,9 @@
+ALTER TABLE organizations DROP COLUMN IF EXISTS require_two_factor;
+
+ALTER TABLE logins DROP COLUMN IF EXISTS two_factor_at;
+
+DROP INDEX IF EXISTS idx_recovery_codes_user_id;
+
+DROP TABLE IF EXISTS recovery_codes;
+
+DROP TABLE IF EXISTS two_factors;
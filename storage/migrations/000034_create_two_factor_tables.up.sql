@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS two_factors (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  user_id INTEGER UNIQUE NOT NULL,
+  secret TEXT NOT NULL,
+  confirmed_at TIMESTAMP DEFAULT NULL,
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_two_factors_users_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  user_id INTEGER NOT NULL,
+  code_hash TEXT NOT NULL,
+  used_at TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_recovery_codes_users_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes(user_id);
+
+ALTER TABLE logins ADD COLUMN two_factor_at TIMESTAMP DEFAULT NULL;
+
+ALTER TABLE organizations ADD COLUMN require_two_factor BOOLEAN NOT NULL DEFAULT FALSE;
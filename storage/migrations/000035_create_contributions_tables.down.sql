@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_contributions_plan_id_membership_id;
+
+DROP TABLE IF EXISTS contributions;
+
+DROP INDEX IF EXISTS idx_contribution_plans_session_id;
+
+DROP TABLE IF EXISTS contribution_plans;
@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS contribution_plans (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  session_id INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  -- in the smallest unit of the currency
+  amount BIGINT NOT NULL CHECK (amount > 0),
+  currency TEXT NOT NULL DEFAULT 'XAF',
+  frequency TEXT NOT NULL,
+  created_by INTEGER,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_contribution_plans_sessions_session_id
+    FOREIGN KEY (session_id) REFERENCES sessions(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_contribution_plans_members_created_by
+    FOREIGN KEY (created_by) REFERENCES members(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_contribution_plans_session_id ON contribution_plans(session_id);
+
+CREATE TABLE IF NOT EXISTS contributions (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  plan_id INTEGER NOT NULL,
+  membership_id INTEGER NOT NULL,
+  meeting_date DATE NOT NULL,
+  amount BIGINT NOT NULL CHECK (amount > 0),
+  note TEXT NOT NULL DEFAULT '',
+  recorded_by INTEGER,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_contributions_contribution_plans_plan_id
+    FOREIGN KEY (plan_id) REFERENCES contribution_plans(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_contributions_memberships_membership_id
+    FOREIGN KEY (membership_id) REFERENCES memberships(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_contributions_members_recorded_by
+    FOREIGN KEY (recorded_by) REFERENCES members(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_contributions_plan_id_membership_id ON contributions(plan_id, membership_id);
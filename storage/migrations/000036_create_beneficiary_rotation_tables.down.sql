@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_beneficiary_swaps_session_id;
+
+DROP TABLE IF EXISTS beneficiary_swaps;
+
+DROP TABLE IF EXISTS beneficiary_slots;
+
+DROP TABLE IF EXISTS beneficiary_rotations;
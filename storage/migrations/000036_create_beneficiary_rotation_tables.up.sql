@@ -0,0 +1,83 @@
+CREATE TABLE IF NOT EXISTS beneficiary_rotations (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  session_id INTEGER UNIQUE NOT NULL,
+  method TEXT NOT NULL,
+  -- the seed of a random draw, so that anyone can draw the same order again
+  seed BIGINT DEFAULT NULL,
+  created_by INTEGER,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_beneficiary_rotations_sessions_session_id
+    FOREIGN KEY (session_id) REFERENCES sessions(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_beneficiary_rotations_members_created_by
+    FOREIGN KEY (created_by) REFERENCES members(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS beneficiary_slots (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  session_id INTEGER NOT NULL,
+  position INTEGER NOT NULL,
+  membership_id INTEGER NOT NULL,
+  served_at TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  -- deferred, the positions get renumbered in a single statement
+  CONSTRAINT uq_beneficiary_slots_session_id_position
+    UNIQUE (session_id, position) DEFERRABLE INITIALLY DEFERRED,
+  CONSTRAINT fk_beneficiary_slots_sessions_session_id
+    FOREIGN KEY (session_id) REFERENCES sessions(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_beneficiary_slots_memberships_membership_id
+    FOREIGN KEY (membership_id) REFERENCES memberships(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS beneficiary_swaps (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  session_id INTEGER NOT NULL,
+  from_slot_id INTEGER NOT NULL,
+  to_slot_id INTEGER NOT NULL,
+  requested_by INTEGER NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  decided_by INTEGER DEFAULT NULL,
+  decided_at TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_beneficiary_swaps_sessions_session_id
+    FOREIGN KEY (session_id) REFERENCES sessions(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_beneficiary_swaps_beneficiary_slots_from_slot_id
+    FOREIGN KEY (from_slot_id) REFERENCES beneficiary_slots(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_beneficiary_swaps_beneficiary_slots_to_slot_id
+    FOREIGN KEY (to_slot_id) REFERENCES beneficiary_slots(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_beneficiary_swaps_memberships_requested_by
+    FOREIGN KEY (requested_by) REFERENCES memberships(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_beneficiary_swaps_members_decided_by
+    FOREIGN KEY (decided_by) REFERENCES members(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_beneficiary_swaps_session_id ON beneficiary_swaps(session_id);
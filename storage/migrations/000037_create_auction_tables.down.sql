@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_auction_payouts_auction_id;
+
+DROP TABLE IF EXISTS auction_payouts;
+
+DROP INDEX IF EXISTS idx_auction_bids_auction_id;
+
+DROP TABLE IF EXISTS auction_bids;
+
+DROP TABLE IF EXISTS auctions;
+
+DROP TABLE IF EXISTS auction_modes;
@@ -0,0 +1,101 @@
+CREATE TABLE IF NOT EXISTS auction_modes (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  session_id INTEGER UNIQUE NOT NULL,
+  -- the bidding of the meetings, unless one is opened otherwise
+  bidding TEXT NOT NULL,
+  -- in the smallest unit of the currency
+  pot BIGINT NOT NULL CHECK (pot > 0),
+  currency TEXT NOT NULL DEFAULT 'XAF',
+  created_by INTEGER,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_auction_modes_sessions_session_id
+    FOREIGN KEY (session_id) REFERENCES sessions(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_auction_modes_members_created_by
+    FOREIGN KEY (created_by) REFERENCES members(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS auctions (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  session_id INTEGER NOT NULL,
+  meeting_date DATE NOT NULL,
+  bidding TEXT NOT NULL,
+  pot BIGINT NOT NULL CHECK (pot > 0),
+  currency TEXT NOT NULL DEFAULT 'XAF',
+  status TEXT NOT NULL DEFAULT 'open',
+  winner_membership_id INTEGER DEFAULT NULL,
+  winning_discount BIGINT DEFAULT NULL,
+  created_by INTEGER,
+  closed_at TIMESTAMP DEFAULT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT uq_auctions_session_id_meeting_date
+    UNIQUE (session_id, meeting_date),
+  CONSTRAINT fk_auctions_sessions_session_id
+    FOREIGN KEY (session_id) REFERENCES sessions(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_auctions_memberships_winner_membership_id
+    FOREIGN KEY (winner_membership_id) REFERENCES memberships(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_auctions_members_created_by
+    FOREIGN KEY (created_by) REFERENCES members(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE
+);
+
+-- the bids are never updated nor deleted, they are the audit trail of the auction
+CREATE TABLE IF NOT EXISTS auction_bids (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  auction_id INTEGER NOT NULL,
+  membership_id INTEGER NOT NULL,
+  discount BIGINT NOT NULL CHECK (discount >= 0),
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_auction_bids_auctions_auction_id
+    FOREIGN KEY (auction_id) REFERENCES auctions(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_auction_bids_memberships_membership_id
+    FOREIGN KEY (membership_id) REFERENCES memberships(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_auction_bids_auction_id ON auction_bids(auction_id);
+
+CREATE TABLE IF NOT EXISTS auction_payouts (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  auction_id INTEGER NOT NULL,
+  membership_id INTEGER NOT NULL,
+  -- the pot less the discount for the winner, a share of the discount for the others
+  kind TEXT NOT NULL,
+  amount BIGINT NOT NULL CHECK (amount >= 0),
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_auction_payouts_auctions_auction_id
+    FOREIGN KEY (auction_id) REFERENCES auctions(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_auction_payouts_memberships_membership_id
+    FOREIGN KEY (membership_id) REFERENCES memberships(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_auction_payouts_auction_id ON auction_payouts(auction_id);
@@ -0,0 +1,3 @@
+ALTER TABLE members_of_session
+DROP COLUMN IF EXISTS shares
+;
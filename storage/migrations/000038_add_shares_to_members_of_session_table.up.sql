@@ -0,0 +1,4 @@
+-- the number of hands a member holds in the session, contributing and taking the pot once per hand
+ALTER TABLE members_of_session
+ADD COLUMN shares INTEGER NOT NULL DEFAULT 1 CHECK (shares > 0)
+;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_meetings_session_id_date;
+
+DROP TABLE IF EXISTS meetings;
+
+DROP TABLE IF EXISTS meeting_recurrences;
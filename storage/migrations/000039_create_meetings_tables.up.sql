@@ -0,0 +1,58 @@
+CREATE TABLE IF NOT EXISTS meeting_recurrences (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  session_id INTEGER UNIQUE NOT NULL,
+  frequency TEXT NOT NULL,
+  -- 0 for Sunday to 6 for Saturday, the day of the start of the session when NULL
+  weekday INTEGER DEFAULT NULL CHECK (weekday BETWEEN 0 AND 6),
+  -- the monthly meetings on the nth weekday of the month, -1 for the last one,
+  -- on the day of the month of the start of the session when NULL
+  week_of_month INTEGER DEFAULT NULL CHECK (week_of_month IN (-1, 1, 2, 3, 4)),
+  start_time TEXT NOT NULL CHECK (start_time ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+  place TEXT NOT NULL DEFAULT '',
+  created_by INTEGER,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT fk_meeting_recurrences_sessions_session_id
+    FOREIGN KEY (session_id) REFERENCES sessions(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_meeting_recurrences_members_created_by
+    FOREIGN KEY (created_by) REFERENCES members(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS meetings (
+  id INTEGER GENERATED ALWAYS AS IDENTITY,
+  session_id INTEGER NOT NULL,
+  -- the date the recurrence set, the meeting may have been moved to another one since
+  original_date DATE NOT NULL,
+  date DATE NOT NULL,
+  start_time TEXT NOT NULL CHECK (start_time ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+  place TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'scheduled',
+  note TEXT NOT NULL DEFAULT '',
+  rescheduled_at TIMESTAMP DEFAULT NULL,
+  cancelled_at TIMESTAMP DEFAULT NULL,
+  updated_by INTEGER,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (id),
+  CONSTRAINT uq_meetings_session_id_original_date
+    UNIQUE (session_id, original_date),
+  CONSTRAINT fk_meetings_sessions_session_id
+    FOREIGN KEY (session_id) REFERENCES sessions(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_meetings_members_updated_by
+    FOREIGN KEY (updated_by) REFERENCES members(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_meetings_session_id_date ON meetings(session_id, date);
@@ -0,0 +1,3 @@
+ALTER TABLE phone_changes
+DROP COLUMN IF EXISTS available_at
+;
@@ -0,0 +1,4 @@
+-- a phone recovery approved by an admin can only be completed once the old number has been warned for a while
+ALTER TABLE phone_changes
+ADD COLUMN available_at TIMESTAMP NOT NULL DEFAULT NOW()
+;
@@ -0,0 +1,3 @@
+ALTER TABLE otps
+DROP COLUMN IF EXISTS purpose
+;
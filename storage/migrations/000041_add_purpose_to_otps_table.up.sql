@@ -0,0 +1,4 @@
+-- a pin code can only be checked for what it was sent for
+ALTER TABLE otps
+ADD COLUMN purpose TEXT NOT NULL DEFAULT 'sign_in'
+;
@@ -0,0 +1,3 @@
+ALTER TABLE members
+ALTER COLUMN tokens_revoked_at TYPE TIMESTAMP
+;
@@ -0,0 +1,4 @@
+-- the revocation time is compared with the issue time of the tokens, which is in UTC
+ALTER TABLE members
+ALTER COLUMN tokens_revoked_at TYPE TIMESTAMPTZ
+;
@@ -0,0 +1,3 @@
+ALTER TABLE account_deletions
+DROP COLUMN IF EXISTS language
+;
@@ -0,0 +1,4 @@
+-- the language of the member when scheduling, used to tell them if the deletion is cancelled
+ALTER TABLE account_deletions
+ADD COLUMN language VARCHAR(8) NOT NULL DEFAULT 'fr'
+;
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 
 	"tschwaa.com/api/models"
 )
@@ -9,7 +10,7 @@ import (
 const listAllMembersOfSession = `-- name: ListAllMembersOfSession :many
 SELECT mos.id, mos.session_id, mos.created_at, mos.updated_at,
   m.id as member_id, m.first_name, m.last_name, m.sex, m.phone,
-  a.id as membership_id, a.position, a.role, a.status, a.joined, a.joined_at
+  a.id as membership_id, a.position, a.role, a.status, a.joined, a.joined_at, COALESCE(mos.shares, 0) as shares
 FROM members m
 INNER JOIN memberships a ON m.id = a.member_id
 LEFT JOIN members_of_session mos ON a.id = mos.membership_id AND a.organization_id = $1 AND mos.session_id = $2
@@ -45,6 +46,7 @@ func (q *Queries) ListAllMembersOfSession(ctx context.Context, arg ListAllMember
 			&i.Status,
 			&i.Joined,
 			&i.JoinedAt,
+			&i.Shares,
 		); err != nil {
 			return nil, err
 		}
@@ -60,23 +62,25 @@ func (q *Queries) ListAllMembersOfSession(ctx context.Context, arg ListAllMember
 }
 
 const addMemberToSession = `-- name: AddMemberToSession :one
-INSERT INTO members_of_session(membership_id, session_id)
-VALUES ($1, $2)
-RETURNING id, membership_id, session_id, created_at, updated_at
+INSERT INTO members_of_session(membership_id, session_id, shares)
+VALUES ($1, $2, $3)
+RETURNING id, membership_id, session_id, shares, created_at, updated_at
 `
 
 type AddMemberToSessionParams struct {
 	MembershipID uint64 `db:"membership_id" json:"membership_id"`
 	SessionID    uint64 `db:"session_id" json:"session_id"`
+	Shares       int    `db:"shares" json:"shares"`
 }
 
 func (q *Queries) AddMemberToSession(ctx context.Context, arg AddMemberToSessionParams) (*models.MembersOfSession, error) {
-	row := q.db.QueryRowContext(ctx, addMemberToSession, arg.MembershipID, arg.SessionID)
+	row := q.db.QueryRowContext(ctx, addMemberToSession, arg.MembershipID, arg.SessionID, arg.Shares)
 	var i models.MembersOfSession
 	err := row.Scan(
 		&i.ID,
 		&i.MembershipID,
 		&i.SessionID,
+		&i.Shares,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -119,3 +123,93 @@ func (q *Queries) RemoveMemberFromSession(ctx context.Context, arg RemoveMemberF
 	_, err := q.db.ExecContext(ctx, removeMemberFromSession, arg.ID, arg.OrganizationID, arg.SessionID)
 	return err
 }
+
+const listMembersInSession = `-- name: ListMembersInSession :many
+SELECT mos.id, mos.session_id, mos.created_at, mos.updated_at,
+  m.id as member_id, m.first_name, m.last_name, m.sex, m.phone,
+  a.id as membership_id, a.position, a.role, a.status, a.joined, a.joined_at, mos.shares
+FROM members_of_session mos
+INNER JOIN memberships a ON a.id = mos.membership_id
+INNER JOIN members m ON m.id = a.member_id
+WHERE mos.session_id = $1
+ORDER BY a.id
+`
+
+// ListMembersInSession only returns the memberships taking part in the session, by membership id
+// since the rows of the session are inserted again in no particular order on every update
+func (q *Queries) ListMembersInSession(ctx context.Context, sessionID uint64) ([]*models.MembersOfSession, error) {
+	rows, err := q.db.QueryContext(ctx, listMembersInSession, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.MembersOfSession{}
+	for rows.Next() {
+		var i models.MembersOfSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MemberID,
+			&i.FirstName,
+			&i.LastName,
+			&i.Sex,
+			&i.Phone,
+			&i.MembershipID,
+			&i.Position,
+			&i.Role,
+			&i.Status,
+			&i.Joined,
+			&i.JoinedAt,
+			&i.Shares,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isMembershipInSession = `-- name: IsMembershipInSession :one
+SELECT EXISTS(
+  SELECT 1
+  FROM members_of_session
+  WHERE session_id = $1 AND membership_id = $2
+)
+`
+
+type IsMembershipInSessionParams struct {
+	SessionID    uint64 `db:"session_id" json:"session_id"`
+	MembershipID uint64 `db:"membership_id" json:"membership_id"`
+}
+
+func (q *Queries) IsMembershipInSession(ctx context.Context, arg IsMembershipInSessionParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isMembershipInSession, arg.SessionID, arg.MembershipID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const getSharesInSession = `-- name: GetSharesInSession :one
+SELECT shares
+FROM members_of_session
+WHERE session_id = $1 AND membership_id = $2
+`
+
+// GetSharesInSession returns the hands the membership holds in the session, none when out of it
+func (q *Queries) GetSharesInSession(ctx context.Context, arg IsMembershipInSessionParams) (int, error) {
+	row := q.db.QueryRowContext(ctx, getSharesInSession, arg.SessionID, arg.MembershipID)
+	var shares int
+	err := row.Scan(&shares)
+	if err != nil && err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return shares, err
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"sync"
 
+	"tschwaa.com/api/helpers"
 	"tschwaa.com/api/models"
 	"tschwaa.com/api/utils"
 )
@@ -14,6 +15,8 @@ type UpdateSessionMembersParams struct {
 	OrganizationID uint64
 	SessionID      uint64
 	Memberships    []models.Membership
+	// Shares are the hands held by the memberships, one when left out
+	Shares map[uint64]int
 }
 
 type insertMOSResponse struct {
@@ -46,9 +49,14 @@ func (store *SQLStorage) UpdateSessionMembersTx(ctx context.Context, arg UpdateS
 		for _, membership := range arg.Memberships {
 			go func(membership models.Membership, channel chan insertMOSResponse, wg *sync.WaitGroup) {
 				defer wg.Done()
+				shares := arg.Shares[membership.ID]
+				if shares == 0 {
+					shares = 1
+				}
 				mos, err := store.AddMemberToSession(ctx, AddMemberToSessionParams{
 					MembershipID: membership.ID,
 					SessionID:    arg.SessionID,
+					Shares:       shares,
 				})
 				if err != nil {
 					channel <- insertMOSResponse{
@@ -78,8 +86,102 @@ func (store *SQLStorage) UpdateSessionMembersTx(ctx context.Context, arg UpdateS
 			responses = append(responses, val.MOS)
 		}
 
-		return nil
+		// 2. Bring the beneficiary order, if there is one, in line with the members and their hands
+		return resizeBeneficiarySlots(ctx, q, arg.SessionID)
 	})
 
 	return responses, err
 }
+
+// resizeBeneficiarySlots drops the turns to come of the members out of the session, trims or
+// extends the turns of the members whose hands changed, then closes the gaps in the order
+func resizeBeneficiarySlots(ctx context.Context, q *Queries, sessionID uint64) error {
+	rotation, err := q.GetBeneficiaryRotation(ctx, sessionID)
+	if err != nil {
+		return utils.Fail(
+			fmt.Sprintf("error when getting the rotation of session[%d]", sessionID),
+			"ERR_UPD_SESS_MBR_02", err)
+	}
+	if rotation == nil {
+		return nil
+	}
+
+	err = q.DeleteUnservedSlotsOutsideSession(ctx, sessionID)
+	if err != nil {
+		return utils.Fail(
+			fmt.Sprintf("error when deleting the slots of the members out of session[%d]", sessionID),
+			"ERR_UPD_SESS_MBR_03", err)
+	}
+
+	slots, err := q.ListBeneficiarySlots(ctx, sessionID)
+	if err != nil {
+		return utils.Fail(
+			fmt.Sprintf("error when listing the slots of session[%d]", sessionID),
+			"ERR_UPD_SESS_MBR_04", err)
+	}
+	members, err := q.ListMembersInSession(ctx, sessionID)
+	if err != nil {
+		return utils.Fail(
+			fmt.Sprintf("error when listing the members of session[%d]", sessionID),
+			"ERR_UPD_SESS_MBR_05", err)
+	}
+
+	removed, added := helpers.ResizeBeneficiarySlots(slots, members)
+	for _, slotID := range removed {
+		err = q.DeleteUnservedBeneficiarySlot(ctx, GetBeneficiarySlotParams{ID: slotID, SessionID: sessionID})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deleting slot[%d] of session[%d]", slotID, sessionID),
+				"ERR_UPD_SESS_MBR_06", err)
+		}
+	}
+
+	last := 0
+	if len(slots) > 0 {
+		last = slots[len(slots)-1].Position
+	}
+	for i, membershipID := range added {
+		err = q.CreateBeneficiarySlot(ctx, CreateBeneficiarySlotParams{
+			SessionID:    sessionID,
+			Position:     last + i + 1,
+			MembershipID: membershipID,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when creating the slot of membership[%d] in session[%d]", membershipID, sessionID),
+				"ERR_UPD_SESS_MBR_07", err)
+		}
+	}
+
+	return utils.Fail(
+		fmt.Sprintf("error when renumbering the slots of session[%d]", sessionID),
+		"ERR_UPD_SESS_MBR_08",
+		q.RenumberBeneficiarySlots(ctx, sessionID))
+}
+
+// RemoveMemberFromSessionTx takes the member out of the session, and out of the beneficiary
+// order, the members after them moving up one turn
+func (store *SQLStorage) RemoveMemberFromSessionTx(ctx context.Context, arg RemoveMemberFromSessionParams) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.RemoveMemberFromSession(ctx, arg)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when removing mos[%d] from session[%d]", arg.ID, arg.SessionID),
+				"ERR_RMV_SESS_MBR_01", err)
+		}
+
+		err = q.DeleteUnservedSlotsOutsideSession(ctx, arg.SessionID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deleting the slots of the members out of session[%d]", arg.SessionID),
+				"ERR_RMV_SESS_MBR_02", err)
+		}
+
+		return utils.Fail(
+			fmt.Sprintf("error when renumbering the slots of session[%d]", arg.SessionID),
+			"ERR_RMV_SESS_MBR_03",
+			q.RenumberBeneficiarySlots(ctx, arg.SessionID))
+	})
+
+	return err
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 
 	"tschwaa.com/api/models"
 )
@@ -158,3 +159,35 @@ func (q *Queries) ListOrganizationsCreatedBy(ctx context.Context, createdBy uint
 	}
 	return items, nil
 }
+
+const isTwoFactorRequired = `-- name: IsTwoFactorRequired :one
+SELECT require_two_factor
+FROM organizations
+WHERE id = $1
+`
+
+// IsTwoFactorRequired tells whether the officers and admins of the organization have to sign in with a second factor
+func (q *Queries) IsTwoFactorRequired(ctx context.Context, id uint64) (bool, error) {
+	var required bool
+	err := q.db.QueryRowContext(ctx, isTwoFactorRequired, id).Scan(&required)
+	if err != nil && err == sql.ErrNoRows {
+		return false, nil
+	}
+	return required, err
+}
+
+const setTwoFactorRequired = `-- name: SetTwoFactorRequired :exec
+UPDATE organizations
+SET require_two_factor = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetTwoFactorRequiredParams struct {
+	ID       uint64 `db:"id" json:"id"`
+	Required bool   `db:"require_two_factor" json:"require_two_factor"`
+}
+
+func (q *Queries) SetTwoFactorRequired(ctx context.Context, arg SetTwoFactorRequiredParams) error {
+	_, err := q.db.ExecContext(ctx, setTwoFactorRequired, arg.ID, arg.Required)
+	return err
+}
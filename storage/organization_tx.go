@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"tschwaa.com/api/common"
 	"tschwaa.com/api/models"
 	"tschwaa.com/api/utils"
 )
@@ -26,6 +27,7 @@ func (store *SQLStorage) CreateOrganizationWithMembershipTx(ctx context.Context,
 			OrganizationID: org.ID,
 			Joined:         true,
 			JoinedAt:       time.Now(),
+			Role:           common.MEMBERSHIP_ROLE_ADMIN,
 		})
 		return utils.Fail(
 			fmt.Sprintf("error when creating membership of member[%d] into organization[%d]", *arg.CreatedBy, org.ID),
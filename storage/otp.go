@@ -2,34 +2,45 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"tschwaa.com/api/models"
 )
 
 const createOTP = `-- name: CreateOTP :one
 INSERT INTO otps (
-  wa_message_id, phone, pin_code
+  message_id, channel, phone, pin_code, expires_at, purpose
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 )
-RETURNING id, wa_message_id, phone, pin_code, active
+RETURNING id, message_id, channel, phone, pin_code, active, attempts, expires_at, created_at, updated_at, purpose
 `
 
 type CreateOTPParams struct {
-	WaMessageID string `db:"wa_message_id" json:"wa_message_id"`
-	Phone       string `db:"phone" json:"phone"`
-	PinCode     string `db:"pin_code" json:"pin_code"`
+	MessageID string    `db:"message_id" json:"message_id"`
+	Channel   string    `db:"channel" json:"channel"`
+	Phone     string    `db:"phone" json:"phone"`
+	PinCode   string    `db:"pin_code" json:"pin_code"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	Purpose   string    `db:"purpose" json:"purpose"`
 }
 
 func (q *Queries) CreateOTP(ctx context.Context, arg CreateOTPParams) (*models.Otp, error) {
-	row := q.db.QueryRowContext(ctx, createOTP, arg.WaMessageID, arg.Phone, arg.PinCode)
+	row := q.db.QueryRowContext(ctx, createOTP, arg.MessageID, arg.Channel, arg.Phone, arg.PinCode, arg.ExpiresAt, arg.Purpose)
 	var i models.Otp
 	err := row.Scan(
 		&i.ID,
-		&i.WaMessageID,
+		&i.MessageID,
+		&i.Channel,
 		&i.Phone,
 		&i.PinCode,
 		&i.Active,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Purpose,
 	)
 	return &i, err
 }
@@ -46,7 +57,7 @@ func (q *Queries) DeactivateOTP(ctx context.Context, id uint64) error {
 }
 
 const getActiveOTPFromPhone = `-- name: GetActiveOTPFromPhone :one
-SELECT id, wa_message_id, phone, pin_code, active
+SELECT id, message_id, channel, phone, pin_code, active, attempts, expires_at, created_at, updated_at, purpose
 FROM otps
 WHERE phone = $1 AND active = TRUE
 `
@@ -56,16 +67,108 @@ func (q *Queries) GetActiveOTPFromPhone(ctx context.Context, phone string) (*mod
 	var i models.Otp
 	err := row.Scan(
 		&i.ID,
-		&i.WaMessageID,
+		&i.MessageID,
+		&i.Channel,
 		&i.Phone,
 		&i.PinCode,
 		&i.Active,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Purpose,
+	)
+	return &i, err
+}
+
+const getActiveOTPFromPhoneForPurpose = `-- name: GetActiveOTPFromPhoneForPurpose :one
+SELECT id, message_id, channel, phone, pin_code, active, attempts, expires_at, created_at, updated_at, purpose
+FROM otps
+WHERE phone = $1 AND purpose = $2 AND active = TRUE
+`
+
+type GetActiveOTPFromPhoneForPurposeParams struct {
+	Phone   string `db:"phone" json:"phone"`
+	Purpose string `db:"purpose" json:"purpose"`
+}
+
+func (q *Queries) GetActiveOTPFromPhoneForPurpose(ctx context.Context, arg GetActiveOTPFromPhoneForPurposeParams) (*models.Otp, error) {
+	row := q.db.QueryRowContext(ctx, getActiveOTPFromPhoneForPurpose, arg.Phone, arg.Purpose)
+	var i models.Otp
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.Channel,
+		&i.Phone,
+		&i.PinCode,
+		&i.Active,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Purpose,
+	)
+	return &i, err
+}
+
+const getLatestOTPFromPhone = `-- name: GetLatestOTPFromPhone :one
+SELECT id, message_id, channel, phone, pin_code, active, attempts, expires_at, created_at, updated_at, purpose
+FROM otps
+WHERE phone = $1
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestOTPFromPhone(ctx context.Context, phone string) (*models.Otp, error) {
+	row := q.db.QueryRowContext(ctx, getLatestOTPFromPhone, phone)
+	var i models.Otp
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.Channel,
+		&i.Phone,
+		&i.PinCode,
+		&i.Active,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Purpose,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const incrementOTPAttempts = `-- name: IncrementOTPAttempts :one
+UPDATE otps
+SET attempts = attempts + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING id, message_id, channel, phone, pin_code, active, attempts, expires_at, created_at, updated_at, purpose
+`
+
+func (q *Queries) IncrementOTPAttempts(ctx context.Context, id uint64) (*models.Otp, error) {
+	row := q.db.QueryRowContext(ctx, incrementOTPAttempts, id)
+	var i models.Otp
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.Channel,
+		&i.Phone,
+		&i.PinCode,
+		&i.Active,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Purpose,
 	)
 	return &i, err
 }
 
 const checkOTP = `-- name: CheckOTP :one
-SELECT id, wa_message_id, phone, pin_code, active
+SELECT id, message_id, channel, phone, pin_code, active, attempts, expires_at, created_at, updated_at, purpose
 FROM otps
 WHERE phone = $1 AND pin_code = $2 AND active = TRUE
 `
@@ -80,10 +183,44 @@ func (q *Queries) CheckOTP(ctx context.Context, arg CheckOTPParams) (*models.Otp
 	var i models.Otp
 	err := row.Scan(
 		&i.ID,
-		&i.WaMessageID,
+		&i.MessageID,
+		&i.Channel,
 		&i.Phone,
 		&i.PinCode,
 		&i.Active,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Purpose,
+	)
+	return &i, err
+}
+
+const getOTPSendStatsFromPhone = `-- name: GetOTPSendStatsFromPhone :one
+SELECT COUNT(*), MIN(created_at), MAX(created_at)
+FROM otps
+WHERE phone = $1 AND created_at > $2
+`
+
+type GetOTPSendStatsFromPhoneParams struct {
+	Phone string    `db:"phone" json:"phone"`
+	Since time.Time `db:"since" json:"since"`
+}
+
+type OTPSendStats struct {
+	Count  int
+	Oldest *time.Time
+	Latest *time.Time
+}
+
+func (q *Queries) GetOTPSendStatsFromPhone(ctx context.Context, arg GetOTPSendStatsFromPhoneParams) (*OTPSendStats, error) {
+	row := q.db.QueryRowContext(ctx, getOTPSendStatsFromPhone, arg.Phone, arg.Since)
+	var i OTPSendStats
+	err := row.Scan(
+		&i.Count,
+		&i.Oldest,
+		&i.Latest,
 	)
 	return &i, err
 }
@@ -2,8 +2,11 @@ package storage
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"tschwaa.com/api/models"
 	"tschwaa.com/api/utils"
@@ -42,3 +45,75 @@ func (store *SQLStorage) CreateOTPTx(ctx context.Context, arg CreateOTPParams) (
 
 	return res, err
 }
+
+var (
+	ErrOTPNotFound  = errors.New("no active otp")
+	ErrOTPExpired   = errors.New("otp expired")
+	ErrOTPLocked    = errors.New("otp locked after too many attempts")
+	ErrOTPWrongCode = errors.New("wrong otp")
+)
+
+type VerifyOTPParams struct {
+	Phone       string
+	PinCode     string
+	MaxAttempts int
+	// Purpose is what the pin code must have been sent for
+	Purpose string
+}
+
+// VerifyOTPTx checks the pin code against the active OTP of the phone number sent for the purpose.
+// Wrong codes are counted, and the OTP locks once MaxAttempts is reached.
+func (store *SQLStorage) VerifyOTPTx(ctx context.Context, arg VerifyOTPParams) (*models.Otp, error) {
+	var res *models.Otp
+	var verifyErr error
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		otp, err := q.GetActiveOTPFromPhoneForPurpose(ctx, GetActiveOTPFromPhoneForPurposeParams{
+			Phone:   arg.Phone,
+			Purpose: arg.Purpose,
+		})
+		if err == sql.ErrNoRows {
+			verifyErr = ErrOTPNotFound
+			return nil
+		}
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when getting active otp from %s", arg.Phone),
+				"ERR_VRF_OTP_01", err)
+		}
+
+		if otp.ExpiresAt.Before(time.Now().UTC()) {
+			verifyErr = ErrOTPExpired
+			return nil
+		}
+
+		if otp.Attempts >= arg.MaxAttempts {
+			verifyErr = ErrOTPLocked
+			return nil
+		}
+
+		if subtle.ConstantTimeCompare([]byte(otp.PinCode), []byte(arg.PinCode)) != 1 {
+			// The attempt is committed even though the verification fails
+			otp, err = q.IncrementOTPAttempts(ctx, otp.ID)
+			if err != nil {
+				return utils.Fail(
+					fmt.Sprintf("error when counting a wrong attempt on otp %d", otp.ID),
+					"ERR_VRF_OTP_02", err)
+			}
+
+			verifyErr = ErrOTPWrongCode
+			if otp.Attempts >= arg.MaxAttempts {
+				verifyErr = ErrOTPLocked
+			}
+			return nil
+		}
+
+		res = otp
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, verifyErr
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tschwaa.com/api/models"
+)
+
+const createPhoneChange = `-- name: CreatePhoneChange :one
+INSERT INTO phone_changes(member_id, old_phone, new_phone, approved_by, available_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, member_id, old_phone, new_phone, approved_by, status, available_at, expires_at, created_at, updated_at
+`
+
+type CreatePhoneChangeParams struct {
+	MemberID    uint64    `db:"member_id" json:"member_id"`
+	OldPhone    string    `db:"old_phone" json:"old_phone"`
+	NewPhone    string    `db:"new_phone" json:"new_phone"`
+	ApprovedBy  *uint64   `db:"approved_by" json:"approved_by"`
+	AvailableAt time.Time `db:"available_at" json:"available_at"`
+	ExpiresAt   time.Time `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreatePhoneChange(ctx context.Context, arg CreatePhoneChangeParams) (*models.PhoneChange, error) {
+	row := q.db.QueryRowContext(ctx, createPhoneChange,
+		arg.MemberID,
+		arg.OldPhone,
+		arg.NewPhone,
+		arg.ApprovedBy,
+		arg.AvailableAt,
+		arg.ExpiresAt,
+	)
+	var i models.PhoneChange
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.OldPhone,
+		&i.NewPhone,
+		&i.ApprovedBy,
+		&i.Status,
+		&i.AvailableAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const cancelPendingPhoneChanges = `-- name: CancelPendingPhoneChanges :exec
+UPDATE phone_changes
+SET status = 'cancelled', updated_at = NOW()
+WHERE member_id = $1 AND status = 'pending'
+`
+
+func (q *Queries) CancelPendingPhoneChanges(ctx context.Context, memberID uint64) error {
+	_, err := q.db.ExecContext(ctx, cancelPendingPhoneChanges, memberID)
+	return err
+}
+
+const getPendingPhoneChangeOfMember = `-- name: GetPendingPhoneChangeOfMember :one
+SELECT id, member_id, old_phone, new_phone, approved_by, status, available_at, expires_at, created_at, updated_at
+FROM phone_changes
+WHERE member_id = $1 AND status = 'pending' AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetPendingPhoneChangeOfMember(ctx context.Context, memberID uint64) (*models.PhoneChange, error) {
+	row := q.db.QueryRowContext(ctx, getPendingPhoneChangeOfMember, memberID)
+	var i models.PhoneChange
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.OldPhone,
+		&i.NewPhone,
+		&i.ApprovedBy,
+		&i.Status,
+		&i.AvailableAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const getApprovedPhoneChangeToPhone = `-- name: GetApprovedPhoneChangeToPhone :one
+SELECT id, member_id, old_phone, new_phone, approved_by, status, available_at, expires_at, created_at, updated_at
+FROM phone_changes
+WHERE new_phone = $1 AND status = 'pending' AND approved_by IS NOT NULL
+AND available_at <= NOW() AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetApprovedPhoneChangeToPhone(ctx context.Context, phone string) (*models.PhoneChange, error) {
+	row := q.db.QueryRowContext(ctx, getApprovedPhoneChangeToPhone, phone)
+	var i models.PhoneChange
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.OldPhone,
+		&i.NewPhone,
+		&i.ApprovedBy,
+		&i.Status,
+		&i.AvailableAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const completePhoneChange = `-- name: CompletePhoneChange :exec
+UPDATE phone_changes
+SET status = 'completed', updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) CompletePhoneChange(ctx context.Context, id uint64) error {
+	_, err := q.db.ExecContext(ctx, completePhoneChange, id)
+	return err
+}
+
+const updateMemberPhone = `-- name: UpdateMemberPhone :exec
+UPDATE members
+SET phone = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateMemberPhoneParams struct {
+	MemberID uint64 `db:"id" json:"member_id"`
+	Phone    string `db:"phone" json:"phone"`
+}
+
+func (q *Queries) UpdateMemberPhone(ctx context.Context, arg UpdateMemberPhoneParams) error {
+	_, err := q.db.ExecContext(ctx, updateMemberPhone, arg.MemberID, arg.Phone)
+	return err
+}
+
+const updateUserPhone = `-- name: UpdateUserPhone :exec
+UPDATE users
+SET phone = $2, updated_at = NOW()
+WHERE member_id = $1
+`
+
+type UpdateUserPhoneParams struct {
+	MemberID uint64 `db:"member_id" json:"member_id"`
+	Phone    string `db:"phone" json:"phone"`
+}
+
+func (q *Queries) UpdateUserPhone(ctx context.Context, arg UpdateUserPhoneParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPhone, arg.MemberID, arg.Phone)
+	return err
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/utils"
+)
+
+// CreatePhoneChangeTx replaces the pending phone change of the member with a new one
+func (store *SQLStorage) CreatePhoneChangeTx(ctx context.Context, arg CreatePhoneChangeParams) (*models.PhoneChange, error) {
+	var result *models.PhoneChange
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.CancelPendingPhoneChanges(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when cancelling the phone changes of member[%d]", arg.MemberID),
+				"ERR_CRT_PHN_CHG_01", err)
+		}
+
+		result, err = q.CreatePhoneChange(ctx, arg)
+		return utils.Fail(
+			fmt.Sprintf("error when creating the phone change of member[%d]", arg.MemberID),
+			"ERR_CRT_PHN_CHG_02", err)
+	})
+
+	return result, err
+}
+
+type ChangePhoneParams struct {
+	PhoneChangeID uint64
+	MemberID      uint64
+	NewPhone      string
+	OtpIDs        []uint64
+}
+
+// ChangePhoneTx moves the member and its user to the new phone number, consumes the OTPs
+// that confirmed it and signs the member out everywhere
+func (store *SQLStorage) ChangePhoneTx(ctx context.Context, arg ChangePhoneParams) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+		for _, otpID := range arg.OtpIDs {
+			err := q.DeactivateOTP(ctx, otpID)
+			if err != nil {
+				return utils.Fail(
+					fmt.Sprintf("error when desactivating otp %d", otpID),
+					"ERR_CHG_PHN_01", err)
+			}
+		}
+
+		err := q.UpdateMemberPhone(ctx, UpdateMemberPhoneParams{
+			MemberID: arg.MemberID,
+			Phone:    arg.NewPhone,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when updating the phone of member[%d]", arg.MemberID),
+				"ERR_CHG_PHN_02", err)
+		}
+
+		err = q.UpdateUserPhone(ctx, UpdateUserPhoneParams{
+			MemberID: arg.MemberID,
+			Phone:    arg.NewPhone,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when updating the phone of the user of member[%d]", arg.MemberID),
+				"ERR_CHG_PHN_03", err)
+		}
+
+		err = q.CompletePhoneChange(ctx, arg.PhoneChangeID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when completing phone change %d", arg.PhoneChangeID),
+				"ERR_CHG_PHN_04", err)
+		}
+
+		err = q.RevokeMemberTokens(ctx, RevokeMemberTokensParams{
+			MemberID:  arg.MemberID,
+			RevokedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when revoking tokens of member[%d]", arg.MemberID),
+				"ERR_CHG_PHN_05", err)
+		}
+
+		err = q.RevokeMemberRefreshTokens(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when revoking refresh tokens of member[%d]", arg.MemberID),
+				"ERR_CHG_PHN_06", err)
+		}
+
+		err = q.RevokeMemberLogins(ctx, arg.MemberID)
+		return utils.Fail(
+			fmt.Sprintf("error when revoking logins of member[%d]", arg.MemberID),
+			"ERR_CHG_PHN_07", err)
+	})
+
+	return err
+}
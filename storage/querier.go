@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"tschwaa.com/api/models"
 )
@@ -18,10 +19,18 @@ type Querier interface {
 	GetMemberByID(ctx context.Context, id uint64) (*models.Member, error)
 	CreateMember(ctx context.Context, arg CreateMemberParams) (*models.Member, error)
 	UpdateMember(ctx context.Context, arg UpdateMemberParams) error
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error)
+	GetUserByID(ctx context.Context, id uint64) (*models.User, error)
+	MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) error
 	// Otp
 	CreateOTP(ctx context.Context, arg CreateOTPParams) (*models.Otp, error)
 	DeactivateOTP(ctx context.Context, id uint64) error
 	GetActiveOTPFromPhone(ctx context.Context, phone string) (*models.Otp, error)
+	GetActiveOTPFromPhoneForPurpose(ctx context.Context, arg GetActiveOTPFromPhoneForPurposeParams) (*models.Otp, error)
+	GetLatestOTPFromPhone(ctx context.Context, phone string) (*models.Otp, error)
+	IncrementOTPAttempts(ctx context.Context, id uint64) (*models.Otp, error)
+	GetOTPSendStatsFromPhone(ctx context.Context, arg GetOTPSendStatsFromPhoneParams) (*OTPSendStats, error)
 	CheckOTP(ctx context.Context, arg CheckOTPParams) (*models.Otp, error)
 	// Organization
 	CreateOrganization(ctx context.Context, arg CreateOrganizationParams) (*models.Organization, error)
@@ -29,6 +38,8 @@ type Querier interface {
 	ListOrganizationOfMember(ctx context.Context, memberID uint64) ([]*models.Organization, error)
 	ListOrganizations(ctx context.Context) ([]*models.Organization, error)
 	ListOrganizationsCreatedBy(ctx context.Context, createdBy uint64) ([]*models.Organization, error)
+	IsTwoFactorRequired(ctx context.Context, id uint64) (bool, error)
+	SetTwoFactorRequired(ctx context.Context, arg SetTwoFactorRequiredParams) error
 	// Session
 	GetCurrentSession(ctx context.Context, organizationID uint64) (*models.Session, error)
 	GetSession(ctx context.Context, arg GetSessionParams) (*models.Session, error)
@@ -39,6 +50,57 @@ type Querier interface {
 	RemoveMemberFromSession(ctx context.Context, arg RemoveMemberFromSessionParams) error
 	RemoveAllMembersFromSession(ctx context.Context, arg RemoveAllMembersFromSessionParams) error
 	AddMemberToSession(ctx context.Context, arg AddMemberToSessionParams) (*models.MembersOfSession, error)
+	ListMembersInSession(ctx context.Context, sessionID uint64) ([]*models.MembersOfSession, error)
+	IsMembershipInSession(ctx context.Context, arg IsMembershipInSessionParams) (bool, error)
+	GetSharesInSession(ctx context.Context, arg IsMembershipInSessionParams) (int, error)
+	// Contribution
+	CreateContributionPlan(ctx context.Context, arg CreateContributionPlanParams) (*models.ContributionPlan, error)
+	GetContributionPlan(ctx context.Context, arg GetContributionPlanParams) (*models.ContributionPlan, error)
+	ListContributionPlansOfSession(ctx context.Context, sessionID uint64) ([]*models.ContributionPlan, error)
+	UpdateContributionPlan(ctx context.Context, arg UpdateContributionPlanParams) (*models.ContributionPlan, error)
+	CreateContribution(ctx context.Context, arg CreateContributionParams) (*models.Contribution, error)
+	ListContributionsOfSession(ctx context.Context, arg ListContributionsOfSessionParams) ([]*models.Contribution, error)
+	DeleteContribution(ctx context.Context, arg DeleteContributionParams) (*models.Contribution, error)
+	SumContributionsOfSession(ctx context.Context, arg SumContributionsOfSessionParams) ([]*models.ContributionTotal, error)
+	// Beneficiary Rotation
+	UpsertBeneficiaryRotation(ctx context.Context, arg UpsertBeneficiaryRotationParams) (*models.BeneficiaryRotation, error)
+	GetBeneficiaryRotation(ctx context.Context, sessionID uint64) (*models.BeneficiaryRotation, error)
+	DeleteBeneficiarySlots(ctx context.Context, sessionID uint64) error
+	CreateBeneficiarySlot(ctx context.Context, arg CreateBeneficiarySlotParams) error
+	ListBeneficiarySlots(ctx context.Context, sessionID uint64) ([]*models.BeneficiarySlot, error)
+	GetBeneficiarySlot(ctx context.Context, arg GetBeneficiarySlotParams) (*models.BeneficiarySlot, error)
+	CountServedBeneficiarySlots(ctx context.Context, sessionID uint64) (int64, error)
+	ServeBeneficiarySlot(ctx context.Context, arg GetBeneficiarySlotParams) (bool, error)
+	DeleteUnservedSlotsOutsideSession(ctx context.Context, sessionID uint64) error
+	DeleteUnservedBeneficiarySlot(ctx context.Context, arg GetBeneficiarySlotParams) error
+	RenumberBeneficiarySlots(ctx context.Context, sessionID uint64) error
+	SetBeneficiarySlotMembership(ctx context.Context, arg SetBeneficiarySlotMembershipParams) (bool, error)
+	CreateBeneficiarySwap(ctx context.Context, arg CreateBeneficiarySwapParams) (*models.BeneficiarySwap, error)
+	GetBeneficiarySwap(ctx context.Context, arg GetBeneficiarySwapParams) (*models.BeneficiarySwap, error)
+	ListBeneficiarySwaps(ctx context.Context, sessionID uint64) ([]*models.BeneficiarySwap, error)
+	DecideBeneficiarySwap(ctx context.Context, arg DecideBeneficiarySwapParams) (bool, error)
+	// Auction
+	UpsertAuctionMode(ctx context.Context, arg UpsertAuctionModeParams) (*models.AuctionMode, error)
+	GetAuctionMode(ctx context.Context, sessionID uint64) (*models.AuctionMode, error)
+	CreateAuction(ctx context.Context, arg CreateAuctionParams) (*models.Auction, error)
+	GetAuction(ctx context.Context, arg GetAuctionParams) (*models.Auction, error)
+	ListAuctionsOfSession(ctx context.Context, sessionID uint64) ([]*models.Auction, error)
+	CloseAuction(ctx context.Context, arg GetAuctionParams) (*models.Auction, error)
+	SetAuctionWinner(ctx context.Context, arg SetAuctionWinnerParams) error
+	CreateAuctionBid(ctx context.Context, arg CreateAuctionBidParams) (*models.AuctionBid, error)
+	ListAuctionBids(ctx context.Context, auctionID uint64) ([]*models.AuctionBid, error)
+	CountAuctionsWon(ctx context.Context, arg CountAuctionsWonParams) (int64, error)
+	CreateAuctionPayout(ctx context.Context, arg CreateAuctionPayoutParams) error
+	ListAuctionPayouts(ctx context.Context, auctionID uint64) ([]*models.AuctionPayout, error)
+	// Meeting
+	UpsertMeetingRecurrence(ctx context.Context, arg UpsertMeetingRecurrenceParams) (*models.MeetingRecurrence, error)
+	GetMeetingRecurrence(ctx context.Context, sessionID uint64) (*models.MeetingRecurrence, error)
+	DeleteUpcomingRecurringMeetings(ctx context.Context, arg DeleteUpcomingRecurringMeetingsParams) error
+	CreateMeeting(ctx context.Context, arg CreateMeetingParams) error
+	ListMeetingsOfSession(ctx context.Context, sessionID uint64) ([]*models.Meeting, error)
+	GetMeeting(ctx context.Context, arg GetMeetingParams) (*models.Meeting, error)
+	CancelMeeting(ctx context.Context, arg CancelMeetingParams) (*models.Meeting, error)
+	RescheduleMeeting(ctx context.Context, arg RescheduleMeetingParams) (*models.Meeting, error)
 	// Session Place
 	CreateSessionPlace(ctx context.Context, arg CreateSessionPlaceParams) (*models.SessionPlace, error)
 	CreateSessionPlaceGivenVenue(ctx context.Context, arg CreateSessionPlaceGivenVenueParams) (*models.SessionPlacesGivenVenue, error)
@@ -72,14 +134,99 @@ type Querier interface {
 	GetInvitationLinkFromMembership(ctx context.Context, membershipId uint64) (string, error)
 	DesactivateInvitation(ctx context.Context, membershipID uint64) error
 	DesactivateInvitationFromLink(ctx context.Context, link string) (*models.Invitation, error)
+	// Refresh Token
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (*models.RefreshToken, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	MarkRefreshTokenReplaced(ctx context.Context, arg MarkRefreshTokenReplacedParams) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeMemberRefreshTokens(ctx context.Context, memberID uint64) error
+	// Login
+	CreateLogin(ctx context.Context, arg CreateLoginParams) (*models.Login, error)
+	GetLogin(ctx context.Context, arg GetLoginParams) (*models.Login, error)
+	TouchLogin(ctx context.Context, familyID string) (*models.Login, error)
+	ListActiveLoginsOfMember(ctx context.Context, arg ListActiveLoginsOfMemberParams) ([]*models.Login, error)
+	RevokeLogin(ctx context.Context, id uint64) error
+	RevokeMemberLogins(ctx context.Context, memberID uint64) error
+	MarkLoginTwoFactor(ctx context.Context, arg MarkLoginTwoFactorParams) error
+	// Phone Change
+	CreatePhoneChange(ctx context.Context, arg CreatePhoneChangeParams) (*models.PhoneChange, error)
+	CancelPendingPhoneChanges(ctx context.Context, memberID uint64) error
+	GetPendingPhoneChangeOfMember(ctx context.Context, memberID uint64) (*models.PhoneChange, error)
+	GetApprovedPhoneChangeToPhone(ctx context.Context, phone string) (*models.PhoneChange, error)
+	CompletePhoneChange(ctx context.Context, id uint64) error
+	UpdateMemberPhone(ctx context.Context, arg UpdateMemberPhoneParams) error
+	UpdateUserPhone(ctx context.Context, arg UpdateUserPhoneParams) error
+	// Account
+	ListMembershipsOfMember(ctx context.Context, memberID uint64) ([]*models.Membership, error)
+	ListSessionsOfMember(ctx context.Context, memberID uint64) ([]*models.MembersOfSession, error)
+	ListInvitationsOfMember(ctx context.Context, memberID uint64) ([]*models.Invitation, error)
+	ListOTPsFromPhone(ctx context.Context, phone string) ([]*models.Otp, error)
+	CreateAccountDeletion(ctx context.Context, arg CreateAccountDeletionParams) (*models.AccountDeletion, error)
+	GetPendingAccountDeletion(ctx context.Context, memberID uint64) (*models.AccountDeletion, error)
+	CancelAccountDeletion(ctx context.Context, id uint64) error
+	ListDueAccountDeletions(ctx context.Context, now time.Time) ([]*models.AccountDeletion, error)
+	CompleteAccountDeletion(ctx context.Context, id uint64) error
+	AnonymizeMember(ctx context.Context, id uint64) error
+	DeleteUserOfMember(ctx context.Context, memberID uint64) error
+	DeleteOTPsFromPhone(ctx context.Context, phone string) error
+	DeleteMemberLogins(ctx context.Context, memberID uint64) error
+	DesactivateInvitationsOfMember(ctx context.Context, memberID uint64) error
+	ListOrganizationsLastAdministeredByMember(ctx context.Context, memberID uint64) ([]uint64, error)
+	DeleteMemberPhoneChanges(ctx context.Context, memberID uint64) error
+	// API Key
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	ListAPIKeysOfOrganization(ctx context.Context, organizationID uint64) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id uint64) error
+	// Magic Link
+	CreateMagicLink(ctx context.Context, arg CreateMagicLinkParams) (*models.MagicLink, error)
+	GetLatestMagicLinkFromPhone(ctx context.Context, phone string) (*models.MagicLink, error)
+	ExpireMagicLinksOfPhone(ctx context.Context, phone string) error
+	ConsumeMagicLink(ctx context.Context, arg ConsumeMagicLinkParams) (*models.MagicLink, error)
+	DeleteMemberMagicLinks(ctx context.Context, memberID uint64) error
+	DeleteMagicLink(ctx context.Context, id uint64) error
+	GetMagicLinkSendStatsFromPhone(ctx context.Context, arg GetOTPSendStatsFromPhoneParams) (*OTPSendStats, error)
+	// Sign In Throttle
+	GetSignInThrottle(ctx context.Context, key string) (*models.SignInThrottle, error)
+	RecordSignInFailure(ctx context.Context, arg RecordSignInFailureParams) (*models.SignInThrottle, error)
+	LockSignIn(ctx context.Context, arg LockSignInParams) error
+	ResetSignInThrottle(ctx context.Context, key string) error
+	// WebAuthn
+	CreateWebAuthnChallenge(ctx context.Context, arg CreateWebAuthnChallengeParams) (*models.WebAuthnChallenge, error)
+	DeleteExpiredWebAuthnChallenges(ctx context.Context) error
+	ConsumeWebAuthnChallenge(ctx context.Context, arg ConsumeWebAuthnChallengeParams) (*models.WebAuthnChallenge, error)
+	CreateWebAuthnCredential(ctx context.Context, arg CreateWebAuthnCredentialParams) (*models.WebAuthnCredential, error)
+	GetWebAuthnCredential(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error)
+	ListWebAuthnCredentialsOfUser(ctx context.Context, userID uint64) ([]*models.WebAuthnCredential, error)
+	UseWebAuthnCredential(ctx context.Context, arg UseWebAuthnCredentialParams) (bool, error)
+	RenameWebAuthnCredential(ctx context.Context, arg RenameWebAuthnCredentialParams) (*models.WebAuthnCredential, error)
+	DeleteWebAuthnCredential(ctx context.Context, arg DeleteWebAuthnCredentialParams) (*models.WebAuthnCredential, error)
+	// Two Factor
+	UpsertTwoFactor(ctx context.Context, arg UpsertTwoFactorParams) (*models.TwoFactor, error)
+	GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	ConfirmTwoFactor(ctx context.Context, arg TwoFactorStepParams) error
+	UseTwoFactorStep(ctx context.Context, arg TwoFactorStepParams) (bool, error)
+	DeleteTwoFactor(ctx context.Context, userID uint64) error
+	CreateRecoveryCode(ctx context.Context, arg RecoveryCodeParams) error
+	UseRecoveryCode(ctx context.Context, arg RecoveryCodeParams) (bool, error)
+	CountRecoveryCodesLeft(ctx context.Context, userID uint64) (int64, error)
+	DeleteRecoveryCodes(ctx context.Context, userID uint64) error
+	CountTwoFactorRequiredMemberships(ctx context.Context, memberID uint64) (int64, error)
+	// Revoked Token
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error)
+	RevokeMemberTokens(ctx context.Context, arg RevokeMemberTokensParams) error
 }
 
 type QuerierTx interface {
 	// User
 	CreateUserWithMemberTx(ctx context.Context, arg CreateUserWithMemberParams) (uint64, error)
-	CreateMemberWithAssociatedUserTx(ctx context.Context, arg CreateMemberWithAssociatedUserParams) error
+	CreateMemberWithAssociatedUserTx(ctx context.Context, arg CreateMemberWithAssociatedUserParams) (*models.User, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordParams) error
 	// Otp
 	CreateOTPTx(ctx context.Context, arg CreateOTPParams) (*models.Otp, error)
+	VerifyOTPTx(ctx context.Context, arg VerifyOTPParams) (*models.Otp, error)
 	// Organization
 	CreateOrganizationWithMembershipTx(ctx context.Context, arg CreateOrganizationParams) (*models.Organization, error)
 	// Session
@@ -91,10 +238,39 @@ type QuerierTx interface {
 	ChangeSessionPlaceTx(ctx context.Context, arg ChangeSessionPlaceParams) (models.ISessionPlace, error)
 	// Members of session
 	UpdateSessionMembersTx(ctx context.Context, arg UpdateSessionMembersParams) ([]*models.MembersOfSession, error)
+	RemoveMemberFromSessionTx(ctx context.Context, arg RemoveMemberFromSessionParams) error
+	// Beneficiary Rotation
+	GenerateBeneficiaryRotationTx(ctx context.Context, arg GenerateBeneficiaryRotationParams) (*models.BeneficiaryRotation, error)
+	DecideBeneficiarySwapTx(ctx context.Context, arg DecideBeneficiarySwapTxParams) (*models.BeneficiarySwap, error)
+	// Auction
+	CloseAuctionTx(ctx context.Context, arg CloseAuctionTxParams) (*models.Auction, error)
+	// Meeting
+	SetMeetingRecurrenceTx(ctx context.Context, arg SetMeetingRecurrenceParams) (*models.MeetingRecurrence, error)
 	// Membership
 	CreateInvitationTx(ctx context.Context, arg CreateMembershipInvitationParams) (*models.Organization, error)
 	// Invitation
 	ApprovedInvitationTx(ctx context.Context, link string) error
+	// Refresh Token
+	RotateRefreshTokenTx(ctx context.Context, arg RotateRefreshTokenParams) (*models.RefreshToken, error)
+	// Login
+	CreateLoginTx(ctx context.Context, arg CreateLoginTxParams) (*models.Login, error)
+	RevokeLoginTx(ctx context.Context, arg RevokeLoginParams) error
+	// Phone Change
+	CreatePhoneChangeTx(ctx context.Context, arg CreatePhoneChangeParams) (*models.PhoneChange, error)
+	ChangePhoneTx(ctx context.Context, arg ChangePhoneParams) error
+	// Magic Link
+	CreateMagicLinkTx(ctx context.Context, arg CreateMagicLinkParams) (*models.MagicLink, error)
+	// WebAuthn
+	CreateWebAuthnChallengeTx(ctx context.Context, arg CreateWebAuthnChallengeParams) (*models.WebAuthnChallenge, error)
+	// Two Factor
+	ConfirmTwoFactorTx(ctx context.Context, arg ConfirmTwoFactorParams) error
+	ReplaceRecoveryCodesTx(ctx context.Context, arg ReplaceRecoveryCodesParams) error
+	DisableTwoFactorTx(ctx context.Context, userID uint64) error
+	// Account
+	DeleteAccountTx(ctx context.Context, arg DeleteAccountParams) error
+	// Revoked Token
+	SignOutTx(ctx context.Context, arg SignOutParams) error
+	RevokeAllMemberTokensTx(ctx context.Context, memberID uint64) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tschwaa.com/api/models"
+)
+
+const createRefreshToken = `-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens(member_id, family_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, member_id, family_id, token_hash, expires_at, replaced_by, revoked_at, created_at, updated_at
+`
+
+type CreateRefreshTokenParams struct {
+	MemberID  uint64    `db:"member_id" json:"member_id"`
+	FamilyID  string    `db:"family_id" json:"family_id"`
+	TokenHash string    `db:"token_hash" json:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (*models.RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.MemberID,
+		arg.FamilyID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i models.RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.FamilyID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.ReplacedBy,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getRefreshTokenByHash = `-- name: GetRefreshTokenByHash :one
+SELECT id, member_id, family_id, token_hash, expires_at, replaced_by, revoked_at, created_at, updated_at
+FROM refresh_tokens
+WHERE token_hash = $1
+`
+
+func (q *Queries) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshTokenByHash, tokenHash)
+	var i models.RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.FamilyID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.ReplacedBy,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const markRefreshTokenReplaced = `-- name: MarkRefreshTokenReplaced :exec
+UPDATE refresh_tokens
+SET replaced_by = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type MarkRefreshTokenReplacedParams struct {
+	ID         uint64 `db:"id" json:"id"`
+	ReplacedBy uint64 `db:"replaced_by" json:"replaced_by"`
+}
+
+func (q *Queries) MarkRefreshTokenReplaced(ctx context.Context, arg MarkRefreshTokenReplacedParams) error {
+	_, err := q.db.ExecContext(ctx, markRefreshTokenReplaced, arg.ID, arg.ReplacedBy)
+	return err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const revokeMemberRefreshTokens = `-- name: RevokeMemberRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE member_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeMemberRefreshTokens(ctx context.Context, memberID uint64) error {
+	_, err := q.db.ExecContext(ctx, revokeMemberRefreshTokens, memberID)
+	return err
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/utils"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenExpired  = errors.New("refresh token expired")
+	ErrRefreshTokenReused   = errors.New("refresh token reused")
+)
+
+type RotateRefreshTokenParams struct {
+	TokenHash    string
+	NewTokenHash string
+	ExpiresAt    time.Time
+}
+
+// RotateRefreshTokenTx exchanges a refresh token for a new one of the same family.
+// Presenting a token that has already been rotated or revoked revokes the whole family.
+func (store *SQLStorage) RotateRefreshTokenTx(ctx context.Context, arg RotateRefreshTokenParams) (*models.RefreshToken, error) {
+	var result *models.RefreshToken
+	var reused bool
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetRefreshTokenByHash(ctx, arg.TokenHash)
+		if err != nil {
+			return utils.Fail(
+				"error when getting the refresh token",
+				"ERR_ROT_RFSH_TKN_01", err)
+		}
+		if current == nil {
+			return ErrRefreshTokenNotFound
+		}
+
+		if current.ReplacedBy != nil || current.RevokedAt != nil {
+			// The family is revoked and committed; the caller gets the reuse error afterwards
+			reused = true
+			return utils.Fail(
+				fmt.Sprintf("error when revoking refresh token family %s", current.FamilyID),
+				"ERR_ROT_RFSH_TKN_02",
+				q.RevokeRefreshTokenFamily(ctx, current.FamilyID),
+			)
+		}
+
+		if current.ExpiresAt.Before(time.Now().UTC()) {
+			return ErrRefreshTokenExpired
+		}
+
+		next, err := q.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+			MemberID:  current.MemberID,
+			FamilyID:  current.FamilyID,
+			TokenHash: arg.NewTokenHash,
+			ExpiresAt: arg.ExpiresAt,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when creating the next refresh token of family %s", current.FamilyID),
+				"ERR_ROT_RFSH_TKN_03", err)
+		}
+
+		err = q.MarkRefreshTokenReplaced(ctx, MarkRefreshTokenReplacedParams{
+			ID:         current.ID,
+			ReplacedBy: next.ID,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when marking refresh token %d as replaced", current.ID),
+				"ERR_ROT_RFSH_TKN_04", err)
+		}
+
+		result = next
+		return nil
+	})
+	if err == nil && reused {
+		return nil, ErrRefreshTokenReused
+	}
+
+	return result, err
+}
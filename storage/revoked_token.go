@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+const revokeToken = `-- name: RevokeToken :exec
+INSERT INTO revoked_tokens(jti, member_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (jti) DO NOTHING
+`
+
+type RevokeTokenParams struct {
+	JTI       string    `db:"jti" json:"jti"`
+	MemberID  uint64    `db:"member_id" json:"member_id"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) RevokeToken(ctx context.Context, arg RevokeTokenParams) error {
+	_, err := q.db.ExecContext(ctx, revokeToken, arg.JTI, arg.MemberID, arg.ExpiresAt)
+	return err
+}
+
+const isTokenRevoked = `-- name: IsTokenRevoked :one
+SELECT EXISTS (
+  SELECT 1 FROM revoked_tokens WHERE jti = $1
+) OR EXISTS (
+  SELECT 1 FROM members WHERE id = $2 AND tokens_revoked_at > $3
+) OR EXISTS (
+  SELECT 1 FROM logins WHERE id = $4 AND revoked_at IS NOT NULL
+)
+`
+
+type IsTokenRevokedParams struct {
+	JTI      string    `db:"jti" json:"jti"`
+	MemberID uint64    `db:"member_id" json:"member_id"`
+	IssuedAt time.Time `db:"issued_at" json:"issued_at"`
+	LoginID  uint64    `db:"login_id" json:"login_id"`
+}
+
+// IsTokenRevoked tells whether the token was revoked by its id, with its login, or along with
+// every token of the member issued before the revocation
+func (q *Queries) IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isTokenRevoked, arg.JTI, arg.MemberID, arg.IssuedAt, arg.LoginID)
+	var revoked bool
+	err := row.Scan(&revoked)
+	return revoked, err
+}
+
+const revokeMemberTokens = `-- name: RevokeMemberTokens :exec
+UPDATE members
+SET tokens_revoked_at = $2
+WHERE id = $1
+`
+
+type RevokeMemberTokensParams struct {
+	MemberID uint64 `db:"id" json:"member_id"`
+	// RevokedAt is taken from the clock which sets the issue time of the tokens
+	RevokedAt time.Time `db:"tokens_revoked_at" json:"revoked_at"`
+}
+
+// RevokeMemberTokens revokes every token of the member issued before RevokedAt
+func (q *Queries) RevokeMemberTokens(ctx context.Context, arg RevokeMemberTokensParams) error {
+	_, err := q.db.ExecContext(ctx, revokeMemberTokens, arg.MemberID, arg.RevokedAt)
+	return err
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tschwaa.com/api/utils"
+)
+
+type SignOutParams struct {
+	JTI              string
+	MemberID         uint64
+	ExpiresAt        time.Time
+	RefreshTokenHash string
+	LoginID          uint64
+}
+
+// SignOutTx revokes the access token, the refresh token family and the record of the current login
+func (store *SQLStorage) SignOutTx(ctx context.Context, arg SignOutParams) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+		if arg.JTI != "" {
+			err := q.RevokeToken(ctx, RevokeTokenParams{
+				JTI:       arg.JTI,
+				MemberID:  arg.MemberID,
+				ExpiresAt: arg.ExpiresAt,
+			})
+			if err != nil {
+				return utils.Fail(
+					fmt.Sprintf("error when revoking token %s of member[%d]", arg.JTI, arg.MemberID),
+					"ERR_SGN_OUT_01", err)
+			}
+		}
+
+		if arg.LoginID != 0 {
+			err := q.RevokeLogin(ctx, arg.LoginID)
+			if err != nil {
+				return utils.Fail(
+					fmt.Sprintf("error when revoking login[%d]", arg.LoginID),
+					"ERR_SGN_OUT_04", err)
+			}
+		}
+
+		if arg.RefreshTokenHash == "" {
+			return nil
+		}
+
+		refreshToken, err := q.GetRefreshTokenByHash(ctx, arg.RefreshTokenHash)
+		if err != nil {
+			return utils.Fail(
+				"error when getting the refresh token",
+				"ERR_SGN_OUT_02", err)
+		}
+		if refreshToken == nil {
+			return nil
+		}
+
+		err = q.RevokeRefreshTokenFamily(ctx, refreshToken.FamilyID)
+		return utils.Fail(
+			fmt.Sprintf("error when revoking refresh token family %s", refreshToken.FamilyID),
+			"ERR_SGN_OUT_03", err)
+	})
+
+	return err
+}
+
+// RevokeAllMemberTokensTx invalidates every access and refresh token issued to the member so far
+func (store *SQLStorage) RevokeAllMemberTokensTx(ctx context.Context, memberID uint64) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.RevokeMemberTokens(ctx, RevokeMemberTokensParams{
+			MemberID:  memberID,
+			RevokedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when revoking tokens of member[%d]", memberID),
+				"ERR_RVK_MBR_TKN_01", err)
+		}
+
+		err = q.RevokeMemberRefreshTokens(ctx, memberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when revoking refresh tokens of member[%d]", memberID),
+				"ERR_RVK_MBR_TKN_02", err)
+		}
+
+		err = q.RevokeMemberLogins(ctx, memberID)
+		return utils.Fail(
+			fmt.Sprintf("error when revoking logins of member[%d]", memberID),
+			"ERR_RVK_MBR_TKN_03", err)
+	})
+
+	return err
+}
@@ -0,0 +1,407 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"tschwaa.com/api/models"
+)
+
+const upsertBeneficiaryRotation = `-- name: UpsertBeneficiaryRotation :one
+INSERT INTO beneficiary_rotations(session_id, method, seed, created_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (session_id) DO UPDATE
+SET method = EXCLUDED.method, seed = EXCLUDED.seed, created_by = EXCLUDED.created_by, updated_at = NOW()
+RETURNING id, session_id, method, seed, created_by, created_at, updated_at
+`
+
+type UpsertBeneficiaryRotationParams struct {
+	SessionID uint64  `db:"session_id" json:"session_id"`
+	Method    string  `db:"method" json:"method"`
+	Seed      *int64  `db:"seed" json:"seed"`
+	CreatedBy *uint64 `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) UpsertBeneficiaryRotation(ctx context.Context, arg UpsertBeneficiaryRotationParams) (*models.BeneficiaryRotation, error) {
+	row := q.db.QueryRowContext(ctx, upsertBeneficiaryRotation,
+		arg.SessionID,
+		arg.Method,
+		arg.Seed,
+		arg.CreatedBy,
+	)
+	var i models.BeneficiaryRotation
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Method,
+		&i.Seed,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getBeneficiaryRotation = `-- name: GetBeneficiaryRotation :one
+SELECT id, session_id, method, seed, created_by, created_at, updated_at
+FROM beneficiary_rotations
+WHERE session_id = $1
+`
+
+func (q *Queries) GetBeneficiaryRotation(ctx context.Context, sessionID uint64) (*models.BeneficiaryRotation, error) {
+	row := q.db.QueryRowContext(ctx, getBeneficiaryRotation, sessionID)
+	var i models.BeneficiaryRotation
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Method,
+		&i.Seed,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const deleteBeneficiarySlots = `-- name: DeleteBeneficiarySlots :exec
+DELETE
+FROM beneficiary_slots
+WHERE session_id = $1
+`
+
+func (q *Queries) DeleteBeneficiarySlots(ctx context.Context, sessionID uint64) error {
+	_, err := q.db.ExecContext(ctx, deleteBeneficiarySlots, sessionID)
+	return err
+}
+
+const createBeneficiarySlot = `-- name: CreateBeneficiarySlot :exec
+INSERT INTO beneficiary_slots(session_id, position, membership_id)
+VALUES ($1, $2, $3)
+`
+
+type CreateBeneficiarySlotParams struct {
+	SessionID    uint64 `db:"session_id" json:"session_id"`
+	Position     int    `db:"position" json:"position"`
+	MembershipID uint64 `db:"membership_id" json:"membership_id"`
+}
+
+func (q *Queries) CreateBeneficiarySlot(ctx context.Context, arg CreateBeneficiarySlotParams) error {
+	_, err := q.db.ExecContext(ctx, createBeneficiarySlot, arg.SessionID, arg.Position, arg.MembershipID)
+	return err
+}
+
+const listBeneficiarySlots = `-- name: ListBeneficiarySlots :many
+SELECT s.id, s.session_id, s.position, s.membership_id, a.member_id, m.first_name, m.last_name, s.served_at, s.created_at, s.updated_at
+FROM beneficiary_slots s
+INNER JOIN memberships a ON a.id = s.membership_id
+INNER JOIN members m ON m.id = a.member_id
+WHERE s.session_id = $1
+ORDER BY s.position
+`
+
+func (q *Queries) ListBeneficiarySlots(ctx context.Context, sessionID uint64) ([]*models.BeneficiarySlot, error) {
+	rows, err := q.db.QueryContext(ctx, listBeneficiarySlots, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.BeneficiarySlot{}
+	for rows.Next() {
+		var i models.BeneficiarySlot
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Position,
+			&i.MembershipID,
+			&i.MemberID,
+			&i.FirstName,
+			&i.LastName,
+			&i.ServedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBeneficiarySlot = `-- name: GetBeneficiarySlot :one
+SELECT s.id, s.session_id, s.position, s.membership_id, a.member_id, m.first_name, m.last_name, s.served_at, s.created_at, s.updated_at
+FROM beneficiary_slots s
+INNER JOIN memberships a ON a.id = s.membership_id
+INNER JOIN members m ON m.id = a.member_id
+WHERE s.id = $1 AND s.session_id = $2
+`
+
+type GetBeneficiarySlotParams struct {
+	ID        uint64 `db:"id" json:"id"`
+	SessionID uint64 `db:"session_id" json:"session_id"`
+}
+
+func (q *Queries) GetBeneficiarySlot(ctx context.Context, arg GetBeneficiarySlotParams) (*models.BeneficiarySlot, error) {
+	row := q.db.QueryRowContext(ctx, getBeneficiarySlot, arg.ID, arg.SessionID)
+	var i models.BeneficiarySlot
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Position,
+		&i.MembershipID,
+		&i.MemberID,
+		&i.FirstName,
+		&i.LastName,
+		&i.ServedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const countServedBeneficiarySlots = `-- name: CountServedBeneficiarySlots :one
+SELECT COUNT(*)
+FROM beneficiary_slots
+WHERE session_id = $1 AND served_at IS NOT NULL
+`
+
+func (q *Queries) CountServedBeneficiarySlots(ctx context.Context, sessionID uint64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countServedBeneficiarySlots, sessionID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const serveBeneficiarySlot = `-- name: ServeBeneficiarySlot :one
+UPDATE beneficiary_slots
+SET served_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND session_id = $2 AND served_at IS NULL
+AND position = (
+  SELECT MIN(position)
+  FROM beneficiary_slots
+  WHERE session_id = $2 AND served_at IS NULL
+)
+RETURNING id
+`
+
+// ServeBeneficiarySlot marks the pot as handed over, only to the member whose turn it is
+func (q *Queries) ServeBeneficiarySlot(ctx context.Context, arg GetBeneficiarySlotParams) (bool, error) {
+	var id uint64
+	err := q.db.QueryRowContext(ctx, serveBeneficiarySlot, arg.ID, arg.SessionID).Scan(&id)
+	if err != nil && err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+const deleteUnservedSlotsOutsideSession = `-- name: DeleteUnservedSlotsOutsideSession :exec
+DELETE
+FROM beneficiary_slots
+WHERE session_id = $1 AND served_at IS NULL
+AND membership_id NOT IN (
+  SELECT membership_id
+  FROM members_of_session
+  WHERE session_id = $1
+)
+`
+
+// DeleteUnservedSlotsOutsideSession drops the turns still to come of the members no longer in the session
+func (q *Queries) DeleteUnservedSlotsOutsideSession(ctx context.Context, sessionID uint64) error {
+	_, err := q.db.ExecContext(ctx, deleteUnservedSlotsOutsideSession, sessionID)
+	return err
+}
+
+const deleteUnservedBeneficiarySlot = `-- name: DeleteUnservedBeneficiarySlot :exec
+DELETE FROM beneficiary_slots
+WHERE id = $1 AND session_id = $2 AND served_at IS NULL
+`
+
+// DeleteUnservedBeneficiarySlot drops a turn still to come, the served ones are kept
+func (q *Queries) DeleteUnservedBeneficiarySlot(ctx context.Context, arg GetBeneficiarySlotParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUnservedBeneficiarySlot, arg.ID, arg.SessionID)
+	return err
+}
+
+const renumberBeneficiarySlots = `-- name: RenumberBeneficiarySlots :exec
+UPDATE beneficiary_slots s
+SET position = r.rank, updated_at = NOW()
+FROM (
+  SELECT id, ROW_NUMBER() OVER (ORDER BY position) AS rank
+  FROM beneficiary_slots
+  WHERE session_id = $1
+) r
+WHERE s.id = r.id AND s.position <> r.rank
+`
+
+// RenumberBeneficiarySlots closes the gaps left in the order by the slots removed
+func (q *Queries) RenumberBeneficiarySlots(ctx context.Context, sessionID uint64) error {
+	_, err := q.db.ExecContext(ctx, renumberBeneficiarySlots, sessionID)
+	return err
+}
+
+const setBeneficiarySlotMembership = `-- name: SetBeneficiarySlotMembership :one
+UPDATE beneficiary_slots
+SET membership_id = $3, updated_at = NOW()
+WHERE id = $1 AND membership_id = $2 AND served_at IS NULL
+RETURNING id
+`
+
+type SetBeneficiarySlotMembershipParams struct {
+	ID                   uint64 `db:"id" json:"id"`
+	PreviousMembershipID uint64 `db:"membership_id" json:"previous_membership_id"`
+	MembershipID         uint64 `json:"membership_id"`
+}
+
+// SetBeneficiarySlotMembership hands the slot over to another member, as long as
+// it still belongs to the previous one and has not been served
+func (q *Queries) SetBeneficiarySlotMembership(ctx context.Context, arg SetBeneficiarySlotMembershipParams) (bool, error) {
+	var id uint64
+	err := q.db.QueryRowContext(ctx, setBeneficiarySlotMembership, arg.ID, arg.PreviousMembershipID, arg.MembershipID).Scan(&id)
+	if err != nil && err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+const createBeneficiarySwap = `-- name: CreateBeneficiarySwap :one
+INSERT INTO beneficiary_swaps(session_id, from_slot_id, to_slot_id, requested_by)
+VALUES ($1, $2, $3, $4)
+RETURNING id, session_id, from_slot_id, to_slot_id, requested_by, status, decided_by, decided_at, created_at, updated_at
+`
+
+type CreateBeneficiarySwapParams struct {
+	SessionID   uint64 `db:"session_id" json:"session_id"`
+	FromSlotID  uint64 `db:"from_slot_id" json:"from_slot_id"`
+	ToSlotID    uint64 `db:"to_slot_id" json:"to_slot_id"`
+	RequestedBy uint64 `db:"requested_by" json:"requested_by"`
+}
+
+func (q *Queries) CreateBeneficiarySwap(ctx context.Context, arg CreateBeneficiarySwapParams) (*models.BeneficiarySwap, error) {
+	row := q.db.QueryRowContext(ctx, createBeneficiarySwap,
+		arg.SessionID,
+		arg.FromSlotID,
+		arg.ToSlotID,
+		arg.RequestedBy,
+	)
+	var i models.BeneficiarySwap
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.FromSlotID,
+		&i.ToSlotID,
+		&i.RequestedBy,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getBeneficiarySwap = `-- name: GetBeneficiarySwap :one
+SELECT id, session_id, from_slot_id, to_slot_id, requested_by, status, decided_by, decided_at, created_at, updated_at
+FROM beneficiary_swaps
+WHERE id = $1 AND session_id = $2
+`
+
+type GetBeneficiarySwapParams struct {
+	ID        uint64 `db:"id" json:"id"`
+	SessionID uint64 `db:"session_id" json:"session_id"`
+}
+
+func (q *Queries) GetBeneficiarySwap(ctx context.Context, arg GetBeneficiarySwapParams) (*models.BeneficiarySwap, error) {
+	row := q.db.QueryRowContext(ctx, getBeneficiarySwap, arg.ID, arg.SessionID)
+	var i models.BeneficiarySwap
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.FromSlotID,
+		&i.ToSlotID,
+		&i.RequestedBy,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const listBeneficiarySwaps = `-- name: ListBeneficiarySwaps :many
+SELECT id, session_id, from_slot_id, to_slot_id, requested_by, status, decided_by, decided_at, created_at, updated_at
+FROM beneficiary_swaps
+WHERE session_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListBeneficiarySwaps(ctx context.Context, sessionID uint64) ([]*models.BeneficiarySwap, error) {
+	rows, err := q.db.QueryContext(ctx, listBeneficiarySwaps, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.BeneficiarySwap{}
+	for rows.Next() {
+		var i models.BeneficiarySwap
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.FromSlotID,
+			&i.ToSlotID,
+			&i.RequestedBy,
+			&i.Status,
+			&i.DecidedBy,
+			&i.DecidedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const decideBeneficiarySwap = `-- name: DecideBeneficiarySwap :one
+UPDATE beneficiary_swaps
+SET status = $2, decided_by = $3, decided_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING id
+`
+
+type DecideBeneficiarySwapParams struct {
+	ID        uint64  `db:"id" json:"id"`
+	Status    string  `db:"status" json:"status"`
+	DecidedBy *uint64 `db:"decided_by" json:"decided_by"`
+}
+
+// DecideBeneficiarySwap approves or rejects a swap, only once
+func (q *Queries) DecideBeneficiarySwap(ctx context.Context, arg DecideBeneficiarySwapParams) (bool, error) {
+	var id uint64
+	err := q.db.QueryRowContext(ctx, decideBeneficiarySwap, arg.ID, arg.Status, arg.DecidedBy).Scan(&id)
+	if err != nil && err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tschwaa.com/api/common"
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/utils"
+)
+
+var (
+	ErrRotationStarted    = errors.New("the pot has already been handed over in this session")
+	ErrSwapNotFound       = errors.New("swap not found")
+	ErrSwapAlreadyDecided = errors.New("swap already decided")
+	ErrSwapSlotsChanged   = errors.New("the slots of the swap have changed since it was requested")
+)
+
+type GenerateBeneficiaryRotationParams struct {
+	SessionID uint64
+	Method    string
+	Seed      *int64
+	CreatedBy *uint64
+	// MembershipIDs are the beneficiaries, in the order they take the pot
+	MembershipIDs []uint64
+}
+
+// GenerateBeneficiaryRotationTx replaces the beneficiary order of the session, as long as
+// nobody has taken the pot yet
+func (store *SQLStorage) GenerateBeneficiaryRotationTx(ctx context.Context, arg GenerateBeneficiaryRotationParams) (*models.BeneficiaryRotation, error) {
+	var rotation *models.BeneficiaryRotation
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		served, err := q.CountServedBeneficiarySlots(ctx, arg.SessionID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when counting the served slots of session[%d]", arg.SessionID),
+				"ERR_GEN_BNF_ROT_01", err)
+		}
+		if served > 0 {
+			return ErrRotationStarted
+		}
+
+		err = q.DeleteBeneficiarySlots(ctx, arg.SessionID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deleting the slots of session[%d]", arg.SessionID),
+				"ERR_GEN_BNF_ROT_02", err)
+		}
+
+		for i, membershipID := range arg.MembershipIDs {
+			err = q.CreateBeneficiarySlot(ctx, CreateBeneficiarySlotParams{
+				SessionID:    arg.SessionID,
+				Position:     i + 1,
+				MembershipID: membershipID,
+			})
+			if err != nil {
+				return utils.Fail(
+					fmt.Sprintf("error when creating the slot of membership[%d] in session[%d]", membershipID, arg.SessionID),
+					"ERR_GEN_BNF_ROT_03", err)
+			}
+		}
+
+		rotation, err = q.UpsertBeneficiaryRotation(ctx, UpsertBeneficiaryRotationParams{
+			SessionID: arg.SessionID,
+			Method:    arg.Method,
+			Seed:      arg.Seed,
+			CreatedBy: arg.CreatedBy,
+		})
+		return utils.Fail(
+			fmt.Sprintf("error when saving the rotation of session[%d]", arg.SessionID),
+			"ERR_GEN_BNF_ROT_04", err)
+	})
+
+	return rotation, err
+}
+
+type DecideBeneficiarySwapTxParams struct {
+	SwapID    uint64
+	SessionID uint64
+	Approved  bool
+	DecidedBy *uint64
+}
+
+// DecideBeneficiarySwapTx approves or rejects a pending swap. On approval the members
+// of both slots exchange their turns, which must both still be to come.
+func (store *SQLStorage) DecideBeneficiarySwapTx(ctx context.Context, arg DecideBeneficiarySwapTxParams) (*models.BeneficiarySwap, error) {
+	var swap *models.BeneficiarySwap
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		swap, err = q.GetBeneficiarySwap(ctx, GetBeneficiarySwapParams{
+			ID:        arg.SwapID,
+			SessionID: arg.SessionID,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when getting swap[%d]", arg.SwapID),
+				"ERR_DCD_BNF_SWP_01", err)
+		}
+		if swap == nil {
+			return ErrSwapNotFound
+		}
+
+		status := common.SWAP_STATUS_REJECTED
+		if arg.Approved {
+			status = common.SWAP_STATUS_APPROVED
+		}
+		decided, err := q.DecideBeneficiarySwap(ctx, DecideBeneficiarySwapParams{
+			ID:        swap.ID,
+			Status:    status,
+			DecidedBy: arg.DecidedBy,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deciding swap[%d]", swap.ID),
+				"ERR_DCD_BNF_SWP_02", err)
+		}
+		if !decided {
+			return ErrSwapAlreadyDecided
+		}
+		swap.Status = status
+		swap.DecidedBy = arg.DecidedBy
+
+		if !arg.Approved {
+			return nil
+		}
+
+		from, err := q.GetBeneficiarySlot(ctx, GetBeneficiarySlotParams{ID: swap.FromSlotID, SessionID: arg.SessionID})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when getting slot[%d]", swap.FromSlotID),
+				"ERR_DCD_BNF_SWP_03", err)
+		}
+		to, err := q.GetBeneficiarySlot(ctx, GetBeneficiarySlotParams{ID: swap.ToSlotID, SessionID: arg.SessionID})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when getting slot[%d]", swap.ToSlotID),
+				"ERR_DCD_BNF_SWP_04", err)
+		}
+		// The requester must still hold the slot they offered
+		if from == nil || to == nil || from.MembershipID != swap.RequestedBy {
+			return ErrSwapSlotsChanged
+		}
+
+		for _, exchange := range []SetBeneficiarySlotMembershipParams{
+			{ID: from.ID, PreviousMembershipID: from.MembershipID, MembershipID: to.MembershipID},
+			{ID: to.ID, PreviousMembershipID: to.MembershipID, MembershipID: from.MembershipID},
+		} {
+			changed, err := q.SetBeneficiarySlotMembership(ctx, exchange)
+			if err != nil {
+				return utils.Fail(
+					fmt.Sprintf("error when exchanging slot[%d]", exchange.ID),
+					"ERR_DCD_BNF_SWP_05", err)
+			}
+			if !changed {
+				return ErrSwapSlotsChanged
+			}
+		}
+
+		return nil
+	})
+
+	return swap, err
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tschwaa.com/api/models"
+)
+
+const getSignInThrottle = `-- name: GetSignInThrottle :one
+SELECT key, failures, last_failed_at, locked_until, created_at, updated_at
+FROM sign_in_throttles
+WHERE key = $1
+`
+
+func (q *Queries) GetSignInThrottle(ctx context.Context, key string) (*models.SignInThrottle, error) {
+	row := q.db.QueryRowContext(ctx, getSignInThrottle, key)
+	var i models.SignInThrottle
+	err := row.Scan(
+		&i.Key,
+		&i.Failures,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const recordSignInFailure = `-- name: RecordSignInFailure :one
+INSERT INTO sign_in_throttles(key, failures, last_failed_at)
+VALUES ($1, 1, NOW())
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE WHEN sign_in_throttles.last_failed_at < $2 THEN 1 ELSE sign_in_throttles.failures + 1 END,
+  locked_until = CASE WHEN sign_in_throttles.last_failed_at < $2 THEN NULL ELSE sign_in_throttles.locked_until END,
+  last_failed_at = NOW(),
+  updated_at = NOW()
+RETURNING key, failures, last_failed_at, locked_until, created_at, updated_at
+`
+
+type RecordSignInFailureParams struct {
+	Key string `db:"key" json:"key"`
+	// Since is the start of the window the failures are counted over
+	Since time.Time `db:"since" json:"since"`
+}
+
+func (q *Queries) RecordSignInFailure(ctx context.Context, arg RecordSignInFailureParams) (*models.SignInThrottle, error) {
+	row := q.db.QueryRowContext(ctx, recordSignInFailure, arg.Key, arg.Since)
+	var i models.SignInThrottle
+	err := row.Scan(
+		&i.Key,
+		&i.Failures,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const lockSignIn = `-- name: LockSignIn :exec
+UPDATE sign_in_throttles
+SET locked_until = $2, updated_at = NOW()
+WHERE key = $1
+`
+
+type LockSignInParams struct {
+	Key         string    `db:"key" json:"key"`
+	LockedUntil time.Time `db:"locked_until" json:"locked_until"`
+}
+
+func (q *Queries) LockSignIn(ctx context.Context, arg LockSignInParams) error {
+	_, err := q.db.ExecContext(ctx, lockSignIn, arg.Key, arg.LockedUntil)
+	return err
+}
+
+const resetSignInThrottle = `-- name: ResetSignInThrottle :exec
+DELETE FROM sign_in_throttles
+WHERE key = $1
+`
+
+func (q *Queries) ResetSignInThrottle(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, resetSignInThrottle, key)
+	return err
+}
@@ -0,0 +1,96 @@
+-- name: ListMembershipsOfMember :many
+SELECT *
+FROM memberships
+WHERE member_id = $1
+ORDER BY created_at;
+
+-- name: ListSessionsOfMember :many
+SELECT mos.id, mos.session_id, mos.created_at, mos.updated_at,
+  m.id as member_id, m.first_name, m.last_name, m.sex, m.phone,
+  a.id as membership_id, a.position, a.role, a.status, a.joined, a.joined_at
+FROM members_of_session mos
+INNER JOIN memberships a ON a.id = mos.membership_id
+INNER JOIN members m ON m.id = a.member_id
+WHERE a.member_id = $1
+ORDER BY mos.created_at;
+
+-- name: ListInvitationsOfMember :many
+SELECT i.id, i.link, i.active, i.created_at, i.updated_at, i.membership_id
+FROM invitations i
+INNER JOIN memberships a ON a.id = i.membership_id
+WHERE a.member_id = $1
+ORDER BY i.created_at;
+
+-- name: ListOTPsFromPhone :many
+SELECT *
+FROM otps
+WHERE phone = $1
+ORDER BY created_at;
+
+-- name: CreateAccountDeletion :one
+INSERT INTO account_deletions(member_id, scheduled_for, language)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPendingAccountDeletion :one
+SELECT *
+FROM account_deletions
+WHERE member_id = $1 AND cancelled_at IS NULL AND completed_at IS NULL;
+
+-- name: CancelAccountDeletion :exec
+UPDATE account_deletions
+SET cancelled_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListDueAccountDeletions :many
+SELECT *
+FROM account_deletions
+WHERE cancelled_at IS NULL AND completed_at IS NULL AND scheduled_for <= $1;
+
+-- name: CompleteAccountDeletion :exec
+UPDATE account_deletions
+SET completed_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: AnonymizeMember :exec
+UPDATE members
+SET first_name = 'Deleted', last_name = 'Member', sex = '',
+  email = 'deleted-' || id || '@tschwaa.invalid', phone = 'x' || id,
+  user_id = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteUserOfMember :exec
+DELETE FROM users
+WHERE member_id = $1;
+
+-- name: DeleteOTPsFromPhone :exec
+DELETE FROM otps
+WHERE phone = $1;
+
+-- name: DeleteMemberLogins :exec
+DELETE FROM logins
+WHERE member_id = $1;
+
+-- name: DesactivateInvitationsOfMember :exec
+UPDATE invitations
+SET active = FALSE
+WHERE active = TRUE AND membership_id IN (
+  SELECT id FROM memberships WHERE member_id = $1
+);
+
+-- name: DeleteMemberPhoneChanges :exec
+DELETE FROM phone_changes
+WHERE member_id = $1;
+
+-- name: ListOrganizationsLastAdministeredByMember :many
+SELECT a.organization_id
+FROM memberships a
+WHERE a.member_id = $1 AND a.role = 'admin'
+AND NOT EXISTS (
+  SELECT 1 FROM memberships o
+  WHERE o.organization_id = a.organization_id AND o.id <> a.id AND o.role = 'admin'
+)
+AND EXISTS (
+  SELECT 1 FROM memberships o
+  WHERE o.organization_id = a.organization_id AND o.id <> a.id
+);
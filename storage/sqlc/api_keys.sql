@@ -0,0 +1,26 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys(organization_id, created_by, name, prefix, key_hash, permissions)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetAPIKeyByHash :one
+SELECT *
+FROM api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL;
+
+-- name: ListAPIKeysOfOrganization :many
+SELECT *
+FROM api_keys
+WHERE organization_id = $1 AND revoked_at IS NULL
+ORDER BY created_at;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND organization_id = $2 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1;
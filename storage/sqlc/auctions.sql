@@ -0,0 +1,68 @@
+-- name: UpsertAuctionMode :one
+INSERT INTO auction_modes(session_id, bidding, pot, currency, created_by)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (session_id) DO UPDATE
+SET bidding = EXCLUDED.bidding, pot = EXCLUDED.pot, currency = EXCLUDED.currency, updated_at = NOW()
+RETURNING *;
+
+-- name: GetAuctionMode :one
+SELECT * FROM auction_modes
+WHERE session_id = $1;
+
+-- name: CreateAuction :one
+INSERT INTO auctions(session_id, meeting_date, bidding, pot, currency, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetAuction :one
+SELECT * FROM auctions
+WHERE id = $1 AND session_id = $2;
+
+-- name: ListAuctionsOfSession :many
+SELECT * FROM auctions
+WHERE session_id = $1
+ORDER BY meeting_date;
+
+-- name: CloseAuction :one
+UPDATE auctions
+SET status = 'closed', closed_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND session_id = $2 AND status = 'open'
+RETURNING *;
+
+-- name: SetAuctionWinner :exec
+UPDATE auctions
+SET winner_membership_id = $2, winning_discount = $3, updated_at = NOW()
+WHERE id = $1;
+
+-- name: CreateAuctionBid :one
+INSERT INTO auction_bids(auction_id, membership_id, discount)
+SELECT a.id, $2, $3
+FROM auctions a
+WHERE a.id = $1 AND a.status = 'open'
+AND (a.bidding = 'sealed' OR $3 > (
+  SELECT COALESCE(MAX(b.discount), -1)
+  FROM auction_bids b
+  WHERE b.auction_id = a.id
+))
+-- the bids wait for the auction to be closed, or the other way round
+FOR UPDATE OF a
+RETURNING *;
+
+-- name: ListAuctionBids :many
+SELECT * FROM auction_bids
+WHERE auction_id = $1
+ORDER BY id;
+
+-- name: CountAuctionsWon :one
+SELECT COUNT(*)
+FROM auctions
+WHERE session_id = $1 AND winner_membership_id = $2;
+
+-- name: CreateAuctionPayout :exec
+INSERT INTO auction_payouts(auction_id, membership_id, kind, amount)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListAuctionPayouts :many
+SELECT * FROM auction_payouts
+WHERE auction_id = $1
+ORDER BY id;
@@ -0,0 +1,50 @@
+-- name: CreateContributionPlan :one
+INSERT INTO contribution_plans(session_id, name, amount, currency, frequency, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetContributionPlan :one
+SELECT *
+FROM contribution_plans
+WHERE id = $1 AND session_id = $2;
+
+-- name: ListContributionPlansOfSession :many
+SELECT *
+FROM contribution_plans
+WHERE session_id = $1
+ORDER BY created_at;
+
+-- name: UpdateContributionPlan :one
+UPDATE contribution_plans
+SET name = $3, amount = $4, currency = $5, frequency = $6, updated_at = NOW()
+WHERE id = $1 AND session_id = $2
+RETURNING *;
+
+-- name: CreateContribution :one
+INSERT INTO contributions(plan_id, membership_id, meeting_date, amount, note, recorded_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListContributionsOfSession :many
+SELECT c.*
+FROM contributions c
+INNER JOIN contribution_plans p ON p.id = c.plan_id
+WHERE p.session_id = $1
+AND ($2::INTEGER = 0 OR c.plan_id = $2)
+AND ($3::INTEGER = 0 OR c.membership_id = $3)
+ORDER BY c.meeting_date, c.created_at;
+
+-- name: DeleteContribution :one
+DELETE
+FROM contributions c
+USING contribution_plans p
+WHERE c.plan_id = p.id
+AND c.id = $1 AND p.session_id = $2
+RETURNING c.*;
+
+-- name: SumContributionsOfSession :many
+SELECT c.plan_id, c.membership_id, SUM(c.amount)::BIGINT AS paid
+FROM contributions c
+INNER JOIN contribution_plans p ON p.id = c.plan_id
+WHERE p.session_id = $1 AND c.meeting_date <= $2
+GROUP BY c.plan_id, c.membership_id;
@@ -0,0 +1,36 @@
+-- name: CreateLogin :one
+INSERT INTO logins(member_id, family_id, channel, user_agent, ip, two_factor_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetLogin :one
+SELECT *
+FROM logins
+WHERE id = $1 AND member_id = $2;
+
+-- name: TouchLogin :one
+UPDATE logins
+SET last_seen_at = NOW(), updated_at = NOW()
+WHERE family_id = $1
+RETURNING *;
+
+-- name: ListActiveLoginsOfMember :many
+SELECT *
+FROM logins
+WHERE member_id = $1 AND revoked_at IS NULL AND last_seen_at > $2
+ORDER BY last_seen_at DESC;
+
+-- name: RevokeLogin :exec
+UPDATE logins
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeMemberLogins :exec
+UPDATE logins
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE member_id = $1 AND revoked_at IS NULL;
+
+-- name: MarkLoginTwoFactor :exec
+UPDATE logins
+SET two_factor_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND member_id = $2 AND revoked_at IS NULL;
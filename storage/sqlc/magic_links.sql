@@ -0,0 +1,35 @@
+-- name: CreateMagicLink :one
+INSERT INTO magic_links(member_id, phone, device_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetLatestMagicLinkFromPhone :one
+SELECT *
+FROM magic_links
+WHERE phone = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ExpireMagicLinksOfPhone :exec
+UPDATE magic_links
+SET expires_at = NOW(), updated_at = NOW()
+WHERE phone = $1 AND used_at IS NULL AND expires_at > NOW();
+
+-- name: ConsumeMagicLink :one
+UPDATE magic_links
+SET used_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND phone = $2 AND device_hash = $3 AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteMemberMagicLinks :exec
+DELETE FROM magic_links
+WHERE member_id = $1;
+
+-- name: DeleteMagicLink :exec
+DELETE FROM magic_links
+WHERE id = $1;
+
+-- name: GetMagicLinkSendStatsFromPhone :one
+SELECT COUNT(*), MIN(created_at), MAX(created_at)
+FROM magic_links
+WHERE phone = $1 AND created_at > $2;
@@ -0,0 +1,42 @@
+-- name: UpsertMeetingRecurrence :one
+INSERT INTO meeting_recurrences(session_id, frequency, weekday, week_of_month, start_time, place, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (session_id) DO UPDATE
+SET frequency = EXCLUDED.frequency, weekday = EXCLUDED.weekday, week_of_month = EXCLUDED.week_of_month,
+  start_time = EXCLUDED.start_time, place = EXCLUDED.place, updated_at = NOW()
+RETURNING *;
+
+-- name: GetMeetingRecurrence :one
+SELECT * FROM meeting_recurrences
+WHERE session_id = $1;
+
+-- name: DeleteUpcomingRecurringMeetings :exec
+DELETE FROM meetings
+WHERE session_id = $1 AND original_date >= $2
+AND status = 'scheduled' AND rescheduled_at IS NULL;
+
+-- name: CreateMeeting :exec
+INSERT INTO meetings(session_id, original_date, date, start_time, place)
+VALUES ($1, $2, $2, $3, $4)
+ON CONFLICT (session_id, original_date) DO NOTHING;
+
+-- name: ListMeetingsOfSession :many
+SELECT * FROM meetings
+WHERE session_id = $1
+ORDER BY date, start_time;
+
+-- name: GetMeeting :one
+SELECT * FROM meetings
+WHERE id = $1 AND session_id = $2;
+
+-- name: CancelMeeting :one
+UPDATE meetings
+SET status = 'cancelled', note = $3, updated_by = $4, cancelled_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND session_id = $2 AND status = 'scheduled'
+RETURNING *;
+
+-- name: RescheduleMeeting :one
+UPDATE meetings
+SET date = $3, start_time = $4, place = $5, note = $6, updated_by = $7, rescheduled_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND session_id = $2 AND status = 'scheduled'
+RETURNING *;
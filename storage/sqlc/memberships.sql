@@ -4,8 +4,8 @@ FROM memberships
 WHERE member_id = $1 AND organization_id = $2;
 
 -- name: CreateMembership :one
-INSERT INTO memberships(member_id, organization_id, joined, joined_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO memberships(member_id, organization_id, joined, joined_at, role)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetMembersFromOrganization :many
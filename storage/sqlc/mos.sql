@@ -13,14 +13,36 @@ WHERE mos.membership_id = m.id
 AND mos.id = $1 AND m.organization_id = $2 AND mos.session_id = $3;
 
 -- name: AddMemberToSession :one
-INSERT INTO members_of_session(membership_id, session_id)
-VALUES ($1, $2)
+INSERT INTO members_of_session(membership_id, session_id, shares)
+VALUES ($1, $2, $3)
 RETURNING *;
 
 -- name: ListAllMembersOfSession :many
 SELECT mos.id, mos.session_id, mos.created_at, mos.updated_at,
   m.id as member_id, m.first_name, m.last_name, m.sex, m.phone,
-  a.id as membership_id, a.position, a.role, a.status, a.joined, a.joined_at
+  a.id as membership_id, a.position, a.role, a.status, a.joined, a.joined_at, COALESCE(mos.shares, 0) as shares
 FROM members m
 INNER JOIN memberships a ON m.id = a.member_id
 LEFT JOIN members_of_session mos ON a.id = mos.membership_id AND a.organization_id = $1 AND mos.session_id = $2;
+
+-- name: ListMembersInSession :many
+SELECT mos.id, mos.session_id, mos.created_at, mos.updated_at,
+  m.id as member_id, m.first_name, m.last_name, m.sex, m.phone,
+  a.id as membership_id, a.position, a.role, a.status, a.joined, a.joined_at, mos.shares
+FROM members_of_session mos
+INNER JOIN memberships a ON a.id = mos.membership_id
+INNER JOIN members m ON m.id = a.member_id
+WHERE mos.session_id = $1
+ORDER BY a.id;
+
+-- name: IsMembershipInSession :one
+SELECT EXISTS(
+  SELECT 1
+  FROM members_of_session
+  WHERE session_id = $1 AND membership_id = $2
+);
+
+-- name: GetSharesInSession :one
+SELECT shares
+FROM members_of_session
+WHERE session_id = $1 AND membership_id = $2;
@@ -21,3 +21,13 @@ WHERE A.member_id = $1;
 SELECT *
 FROM organizations
 WHERE id = $1;
+
+-- name: IsTwoFactorRequired :one
+SELECT require_two_factor
+FROM organizations
+WHERE id = $1;
+
+-- name: SetTwoFactorRequired :exec
+UPDATE organizations
+SET require_two_factor = $2, updated_at = NOW()
+WHERE id = $1;
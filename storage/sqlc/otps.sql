@@ -3,16 +3,34 @@ SELECT *
 FROM otps
 WHERE phone = $1 AND active = TRUE;
 
+-- name: GetActiveOTPFromPhoneForPurpose :one
+SELECT *
+FROM otps
+WHERE phone = $1 AND purpose = $2 AND active = TRUE;
+
+-- name: GetLatestOTPFromPhone :one
+SELECT *
+FROM otps
+WHERE phone = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
 -- name: DeactivateOTP :exec
 UPDATE otps
 SET active = FALSE
 WHERE id = $1;
 
+-- name: IncrementOTPAttempts :one
+UPDATE otps
+SET attempts = attempts + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
 -- name: CreateOTP :one
 INSERT INTO otps (
-  wa_message_id, phone, pin_code
+  message_id, channel, phone, pin_code, expires_at, purpose
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 )
 RETURNING *;
 
@@ -20,3 +38,8 @@ RETURNING *;
 SELECT *
 FROM otps
 WHERE phone = $1 AND pin_code = $2 AND active = TRUE;
+
+-- name: GetOTPSendStatsFromPhone :one
+SELECT COUNT(*), MIN(created_at), MAX(created_at)
+FROM otps
+WHERE phone = $1 AND created_at > $2;
@@ -0,0 +1,39 @@
+-- name: CreatePhoneChange :one
+INSERT INTO phone_changes(member_id, old_phone, new_phone, approved_by, available_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: CancelPendingPhoneChanges :exec
+UPDATE phone_changes
+SET status = 'cancelled', updated_at = NOW()
+WHERE member_id = $1 AND status = 'pending';
+
+-- name: GetPendingPhoneChangeOfMember :one
+SELECT *
+FROM phone_changes
+WHERE member_id = $1 AND status = 'pending' AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetApprovedPhoneChangeToPhone :one
+SELECT *
+FROM phone_changes
+WHERE new_phone = $1 AND status = 'pending' AND approved_by IS NOT NULL
+AND available_at <= NOW() AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: CompletePhoneChange :exec
+UPDATE phone_changes
+SET status = 'completed', updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateMemberPhone :exec
+UPDATE members
+SET phone = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateUserPhone :exec
+UPDATE users
+SET phone = $2, updated_at = NOW()
+WHERE member_id = $1;
@@ -0,0 +1,24 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens(member_id, family_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetRefreshTokenByHash :one
+SELECT *
+FROM refresh_tokens
+WHERE token_hash = $1;
+
+-- name: MarkRefreshTokenReplaced :exec
+UPDATE refresh_tokens
+SET replaced_by = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeMemberRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE member_id = $1 AND revoked_at IS NULL;
@@ -0,0 +1,18 @@
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens(jti, member_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: IsTokenRevoked :one
+SELECT EXISTS (
+  SELECT 1 FROM revoked_tokens WHERE jti = $1
+) OR EXISTS (
+  SELECT 1 FROM members WHERE id = $2 AND tokens_revoked_at > $3
+) OR EXISTS (
+  SELECT 1 FROM logins WHERE id = $4 AND revoked_at IS NOT NULL
+);
+
+-- name: RevokeMemberTokens :exec
+UPDATE members
+SET tokens_revoked_at = $2
+WHERE id = $1;
@@ -0,0 +1,97 @@
+-- name: UpsertBeneficiaryRotation :one
+INSERT INTO beneficiary_rotations(session_id, method, seed, created_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (session_id) DO UPDATE
+SET method = EXCLUDED.method, seed = EXCLUDED.seed, created_by = EXCLUDED.created_by, updated_at = NOW()
+RETURNING *;
+
+-- name: GetBeneficiaryRotation :one
+SELECT * FROM beneficiary_rotations
+WHERE session_id = $1;
+
+-- name: DeleteBeneficiarySlots :exec
+DELETE FROM beneficiary_slots
+WHERE session_id = $1;
+
+-- name: CreateBeneficiarySlot :exec
+INSERT INTO beneficiary_slots(session_id, position, membership_id)
+VALUES ($1, $2, $3);
+
+-- name: ListBeneficiarySlots :many
+SELECT s.id, s.session_id, s.position, s.membership_id, a.member_id, m.first_name, m.last_name, s.served_at, s.created_at, s.updated_at
+FROM beneficiary_slots s
+INNER JOIN memberships a ON a.id = s.membership_id
+INNER JOIN members m ON m.id = a.member_id
+WHERE s.session_id = $1
+ORDER BY s.position;
+
+-- name: GetBeneficiarySlot :one
+SELECT s.id, s.session_id, s.position, s.membership_id, a.member_id, m.first_name, m.last_name, s.served_at, s.created_at, s.updated_at
+FROM beneficiary_slots s
+INNER JOIN memberships a ON a.id = s.membership_id
+INNER JOIN members m ON m.id = a.member_id
+WHERE s.id = $1 AND s.session_id = $2;
+
+-- name: CountServedBeneficiarySlots :one
+SELECT COUNT(*) FROM beneficiary_slots
+WHERE session_id = $1 AND served_at IS NOT NULL;
+
+-- name: ServeBeneficiarySlot :one
+UPDATE beneficiary_slots
+SET served_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND session_id = $2 AND served_at IS NULL
+AND position = (
+  SELECT MIN(position)
+  FROM beneficiary_slots
+  WHERE session_id = $2 AND served_at IS NULL
+)
+RETURNING id;
+
+-- name: DeleteUnservedSlotsOutsideSession :exec
+DELETE FROM beneficiary_slots
+WHERE session_id = $1 AND served_at IS NULL
+AND membership_id NOT IN (
+  SELECT membership_id
+  FROM members_of_session
+  WHERE session_id = $1
+);
+
+-- name: DeleteUnservedBeneficiarySlot :exec
+DELETE FROM beneficiary_slots
+WHERE id = $1 AND session_id = $2 AND served_at IS NULL;
+
+-- name: RenumberBeneficiarySlots :exec
+UPDATE beneficiary_slots s
+SET position = r.rank, updated_at = NOW()
+FROM (
+  SELECT id, ROW_NUMBER() OVER (ORDER BY position) AS rank
+  FROM beneficiary_slots
+  WHERE session_id = $1
+) r
+WHERE s.id = r.id AND s.position <> r.rank;
+
+-- name: SetBeneficiarySlotMembership :one
+UPDATE beneficiary_slots
+SET membership_id = $3, updated_at = NOW()
+WHERE id = $1 AND membership_id = $2 AND served_at IS NULL
+RETURNING id;
+
+-- name: CreateBeneficiarySwap :one
+INSERT INTO beneficiary_swaps(session_id, from_slot_id, to_slot_id, requested_by)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetBeneficiarySwap :one
+SELECT * FROM beneficiary_swaps
+WHERE id = $1 AND session_id = $2;
+
+-- name: ListBeneficiarySwaps :many
+SELECT * FROM beneficiary_swaps
+WHERE session_id = $1
+ORDER BY created_at DESC;
+
+-- name: DecideBeneficiarySwap :one
+UPDATE beneficiary_swaps
+SET status = $2, decided_by = $3, decided_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING id;
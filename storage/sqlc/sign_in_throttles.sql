@@ -0,0 +1,23 @@
+-- name: GetSignInThrottle :one
+SELECT *
+FROM sign_in_throttles
+WHERE key = $1;
+
+-- name: RecordSignInFailure :one
+INSERT INTO sign_in_throttles(key, failures, last_failed_at)
+VALUES ($1, 1, NOW())
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE WHEN sign_in_throttles.last_failed_at < $2 THEN 1 ELSE sign_in_throttles.failures + 1 END,
+  locked_until = CASE WHEN sign_in_throttles.last_failed_at < $2 THEN NULL ELSE sign_in_throttles.locked_until END,
+  last_failed_at = NOW(),
+  updated_at = NOW()
+RETURNING *;
+
+-- name: LockSignIn :exec
+UPDATE sign_in_throttles
+SET locked_until = $2, updated_at = NOW()
+WHERE key = $1;
+
+-- name: ResetSignInThrottle :exec
+DELETE FROM sign_in_throttles
+WHERE key = $1;
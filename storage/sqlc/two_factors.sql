@@ -0,0 +1,51 @@
+-- name: UpsertTwoFactor :one
+INSERT INTO two_factors(user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = NOW()
+WHERE two_factors.confirmed_at IS NULL
+RETURNING *;
+
+-- name: GetTwoFactor :one
+SELECT *
+FROM two_factors
+WHERE user_id = $1;
+
+-- name: ConfirmTwoFactor :exec
+UPDATE two_factors
+SET confirmed_at = NOW(), last_used_step = $2, updated_at = NOW()
+WHERE user_id = $1 AND confirmed_at IS NULL;
+
+-- name: UseTwoFactorStep :one
+UPDATE two_factors
+SET last_used_step = $2, updated_at = NOW()
+WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2
+RETURNING id;
+
+-- name: DeleteTwoFactor :exec
+DELETE FROM two_factors
+WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes(user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+RETURNING id;
+
+-- name: CountRecoveryCodesLeft :one
+SELECT COUNT(*)
+FROM recovery_codes
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: CountTwoFactorRequiredMemberships :one
+SELECT COUNT(*)
+FROM memberships m INNER JOIN organizations o ON o.id = m.organization_id
+WHERE m.member_id = $1 AND m.joined = TRUE AND m.role <> 'member' AND o.require_two_factor = TRUE;
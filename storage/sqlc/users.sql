@@ -3,4 +3,24 @@ SELECT *
 FROM members
 WHERE phone = $1;
 
--- name: 
\ No newline at end of file
+-- name: 
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetUserByMemberID :one
+SELECT id, phone, email, password, member_id, email_verified_at
+FROM users
+WHERE member_id = $1;
+
+-- name: GetUserByID :one
+SELECT id, phone, email, password, member_id, email_verified_at
+FROM users
+WHERE id = $1;
+
+-- name: MarkUserEmailVerified :exec
+UPDATE users
+SET email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND email = $2 AND email_verified_at IS NULL;
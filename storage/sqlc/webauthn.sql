@@ -0,0 +1,46 @@
+-- name: CreateWebAuthnChallenge :one
+INSERT INTO webauthn_challenges(challenge, ceremony, user_id, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: DeleteExpiredWebAuthnChallenges :exec
+DELETE FROM webauthn_challenges
+WHERE expires_at <= NOW();
+
+-- name: ConsumeWebAuthnChallenge :one
+DELETE FROM webauthn_challenges
+WHERE challenge = $1 AND ceremony = $2 AND expires_at > NOW()
+RETURNING *;
+
+-- name: CreateWebAuthnCredential :one
+INSERT INTO webauthn_credentials(user_id, credential_id, public_key, algorithm, sign_count, name)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetWebAuthnCredential :one
+SELECT *
+FROM webauthn_credentials
+WHERE credential_id = $1;
+
+-- name: ListWebAuthnCredentialsOfUser :many
+SELECT *
+FROM webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: UseWebAuthnCredential :one
+UPDATE webauthn_credentials
+SET sign_count = $3, last_used_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND sign_count = $2
+RETURNING id;
+
+-- name: RenameWebAuthnCredential :one
+UPDATE webauthn_credentials
+SET name = $3, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteWebAuthnCredential :one
+DELETE FROM webauthn_credentials
+WHERE id = $1 AND user_id = $2
+RETURNING *;
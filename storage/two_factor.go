@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"tschwaa.com/api/models"
+)
+
+const upsertTwoFactor = `-- name: UpsertTwoFactor :one
+INSERT INTO two_factors(user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = NOW()
+WHERE two_factors.confirmed_at IS NULL
+RETURNING id, user_id, secret, confirmed_at, last_used_step, created_at, updated_at
+`
+
+type UpsertTwoFactorParams struct {
+	UserID uint64 `db:"user_id" json:"user_id"`
+	Secret string `db:"secret" json:"secret"`
+}
+
+// UpsertTwoFactor starts the enrollment of the user, or starts it over while it is not confirmed.
+// It returns nil when the user already has a confirmed authenticator app.
+func (q *Queries) UpsertTwoFactor(ctx context.Context, arg UpsertTwoFactorParams) (*models.TwoFactor, error) {
+	row := q.db.QueryRowContext(ctx, upsertTwoFactor, arg.UserID, arg.Secret)
+	var i models.TwoFactor
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Secret,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const getTwoFactor = `-- name: GetTwoFactor :one
+SELECT id, user_id, secret, confirmed_at, last_used_step, created_at, updated_at
+FROM two_factors
+WHERE user_id = $1
+`
+
+func (q *Queries) GetTwoFactor(ctx context.Context, userID uint64) (*models.TwoFactor, error) {
+	row := q.db.QueryRowContext(ctx, getTwoFactor, userID)
+	var i models.TwoFactor
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Secret,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const confirmTwoFactor = `-- name: ConfirmTwoFactor :exec
+UPDATE two_factors
+SET confirmed_at = NOW(), last_used_step = $2, updated_at = NOW()
+WHERE user_id = $1 AND confirmed_at IS NULL
+`
+
+type TwoFactorStepParams struct {
+	UserID uint64 `db:"user_id" json:"user_id"`
+	Step   int64  `db:"last_used_step" json:"last_used_step"`
+}
+
+func (q *Queries) ConfirmTwoFactor(ctx context.Context, arg TwoFactorStepParams) error {
+	_, err := q.db.ExecContext(ctx, confirmTwoFactor, arg.UserID, arg.Step)
+	return err
+}
+
+const useTwoFactorStep = `-- name: UseTwoFactorStep :one
+UPDATE two_factors
+SET last_used_step = $2, updated_at = NOW()
+WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2
+RETURNING id
+`
+
+// UseTwoFactorStep records the time step of a code, it returns false when a code
+// of this step or a later one has already been used
+func (q *Queries) UseTwoFactorStep(ctx context.Context, arg TwoFactorStepParams) (bool, error) {
+	var id uint64
+	err := q.db.QueryRowContext(ctx, useTwoFactorStep, arg.UserID, arg.Step).Scan(&id)
+	if err != nil && err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+const deleteTwoFactor = `-- name: DeleteTwoFactor :exec
+DELETE FROM two_factors
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteTwoFactor(ctx context.Context, userID uint64) error {
+	_, err := q.db.ExecContext(ctx, deleteTwoFactor, userID)
+	return err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes(user_id, code_hash)
+VALUES ($1, $2)
+`
+
+type RecoveryCodeParams struct {
+	UserID   uint64 `db:"user_id" json:"user_id"`
+	CodeHash string `db:"code_hash" json:"code_hash"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg RecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+RETURNING id
+`
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg RecoveryCodeParams) (bool, error) {
+	var id uint64
+	err := q.db.QueryRowContext(ctx, useRecoveryCode, arg.UserID, arg.CodeHash).Scan(&id)
+	if err != nil && err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+const countRecoveryCodesLeft = `-- name: CountRecoveryCodesLeft :one
+SELECT COUNT(*)
+FROM recovery_codes
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountRecoveryCodesLeft(ctx context.Context, userID uint64) (int64, error) {
+	var count int64
+	err := q.db.QueryRowContext(ctx, countRecoveryCodesLeft, userID).Scan(&count)
+	return count, err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID uint64) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
+const countTwoFactorRequiredMemberships = `-- name: CountTwoFactorRequiredMemberships :one
+SELECT COUNT(*)
+FROM memberships m INNER JOIN organizations o ON o.id = m.organization_id
+WHERE m.member_id = $1 AND m.joined = TRUE AND m.role <> 'member' AND o.require_two_factor = TRUE
+`
+
+// CountTwoFactorRequiredMemberships counts the organizations requiring the member to sign in with a second factor
+func (q *Queries) CountTwoFactorRequiredMemberships(ctx context.Context, memberID uint64) (int64, error) {
+	var count int64
+	err := q.db.QueryRowContext(ctx, countTwoFactorRequiredMemberships, memberID).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"tschwaa.com/api/utils"
+)
+
+type ConfirmTwoFactorParams struct {
+	UserID uint64
+	Step   int64
+	// CodeHashes are the hashes of the recovery codes handed over with the confirmation
+	CodeHashes []string
+}
+
+// ConfirmTwoFactorTx turns the two-factor authentication on along with a new set of recovery codes
+func (store *SQLStorage) ConfirmTwoFactorTx(ctx context.Context, arg ConfirmTwoFactorParams) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.ConfirmTwoFactor(ctx, TwoFactorStepParams{
+			UserID: arg.UserID,
+			Step:   arg.Step,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when confirming the two-factor authentication of user[%d]", arg.UserID),
+				"ERR_CNF_TWO_FCT_01", err)
+		}
+
+		return replaceRecoveryCodes(ctx, q, arg.UserID, arg.CodeHashes)
+	})
+
+	return err
+}
+
+type ReplaceRecoveryCodesParams struct {
+	UserID     uint64
+	CodeHashes []string
+}
+
+// ReplaceRecoveryCodesTx drops the recovery codes of the user for new ones
+func (store *SQLStorage) ReplaceRecoveryCodesTx(ctx context.Context, arg ReplaceRecoveryCodesParams) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+		return replaceRecoveryCodes(ctx, q, arg.UserID, arg.CodeHashes)
+	})
+
+	return err
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *Queries, userID uint64, codeHashes []string) error {
+	err := q.DeleteRecoveryCodes(ctx, userID)
+	if err != nil {
+		return utils.Fail(
+			fmt.Sprintf("error when deleting the recovery codes of user[%d]", userID),
+			"ERR_RPL_RCV_CDS_01", err)
+	}
+
+	for _, codeHash := range codeHashes {
+		err = q.CreateRecoveryCode(ctx, RecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: codeHash,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when creating a recovery code for user[%d]", userID),
+				"ERR_RPL_RCV_CDS_02", err)
+		}
+	}
+
+	return nil
+}
+
+// DisableTwoFactorTx removes the authenticator app and the recovery codes of the user
+func (store *SQLStorage) DisableTwoFactorTx(ctx context.Context, userID uint64) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.DeleteRecoveryCodes(ctx, userID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when deleting the recovery codes of user[%d]", userID),
+				"ERR_DSB_TWO_FCT_01", err)
+		}
+
+		err = q.DeleteTwoFactor(ctx, userID)
+		return utils.Fail(
+			fmt.Sprintf("error when deleting the two-factor authentication of user[%d]", userID),
+			"ERR_DSB_TWO_FCT_02", err)
+	})
+
+	return err
+}
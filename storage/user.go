@@ -7,10 +7,11 @@ import (
 	"tschwaa.com/api/models"
 )
 
+// An email address only identifies a user once it has been verified
 const getUserByUsername = `
-SELECT id, phone, email, password, member_id
+SELECT id, phone, email, password, member_id, email_verified_at
 FROM users
-WHERE (phone = $1) OR (email = $2)
+WHERE (phone = $1) OR (email = $2 AND email_verified_at IS NOT NULL)
 `
 
 type GetUserByUsernameParams struct {
@@ -21,7 +22,7 @@ type GetUserByUsernameParams struct {
 func (q *Queries) GetUserByUsername(ctx context.Context, arg GetUserByUsernameParams) (*models.User, error) {
 	var user models.User
 
-	if err := q.db.QueryRowContext(ctx, getUserByUsername, arg.Phone, arg.Email).Scan(&user.ID, &user.Phone, &user.Email, &user.Password, &user.MemberID); err == nil {
+	if err := q.db.QueryRowContext(ctx, getUserByUsername, arg.Phone, arg.Email).Scan(&user.ID, &user.Phone, &user.Email, &user.Password, &user.MemberID, &user.EmailVerifiedAt); err == nil {
 		return &user, nil
 	} else if err == sql.ErrNoRows {
 		return nil, nil
@@ -30,6 +31,68 @@ func (q *Queries) GetUserByUsername(ctx context.Context, arg GetUserByUsernamePa
 	}
 }
 
+const getUserByMemberID = `-- name: GetUserByMemberID :one
+SELECT id, phone, email, password, member_id, email_verified_at
+FROM users
+WHERE member_id = $1
+`
+
+func (q *Queries) GetUserByMemberID(ctx context.Context, memberID uint64) (*models.User, error) {
+	var user models.User
+
+	err := q.db.QueryRowContext(ctx, getUserByMemberID, memberID).Scan(
+		&user.ID,
+		&user.Phone,
+		&user.Email,
+		&user.Password,
+		&user.MemberID,
+		&user.EmailVerifiedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &user, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, phone, email, password, member_id, email_verified_at
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uint64) (*models.User, error) {
+	var user models.User
+
+	err := q.db.QueryRowContext(ctx, getUserByID, id).Scan(
+		&user.ID,
+		&user.Phone,
+		&user.Email,
+		&user.Password,
+		&user.MemberID,
+		&user.EmailVerifiedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &user, err
+}
+
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :exec
+UPDATE users
+SET email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND email = $2 AND email_verified_at IS NULL
+`
+
+type MarkUserEmailVerifiedParams struct {
+	ID    uint64 `db:"id" json:"id"`
+	Email string `db:"email" json:"email"`
+}
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) error {
+	_, err := q.db.ExecContext(ctx, markUserEmailVerified, arg.ID, arg.Email)
+	return err
+}
+
 func (q *Queries) DoesUserExist(ctx context.Context, phone string) (bool, error) {
 	user, err := q.GetUserByUsername(ctx, GetUserByUsernameParams{Phone: phone, Email: phone})
 	return (user != nil), err
@@ -192,3 +255,19 @@ func (q *Queries) UpdateMember(ctx context.Context, arg UpdateMemberParams) erro
 
 	return nil
 }
+
+const updateUserPassword = `
+UPDATE users
+SET password = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID       uint64
+	Password string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.Password)
+	return err
+}
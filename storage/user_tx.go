@@ -3,8 +3,10 @@ package storage
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"tschwaa.com/api/helpers"
+	"tschwaa.com/api/models"
 	"tschwaa.com/api/utils"
 )
 
@@ -18,10 +20,17 @@ type CreateUserWithMemberParams struct {
 
 func (store *SQLStorage) CreateUserWithMemberTx(ctx context.Context, arg CreateUserWithMemberParams) (uint64, error) {
 	err := store.execTx(ctx, func(q *Queries) error {
+		hashedPassword, err := helpers.HashPassword(arg.Password)
+		if hashedPassword == "" || err != nil {
+			return utils.Fail(
+				"error when hashing the password",
+				"ERR_CRT_USR_MBR_02", err)
+		}
+
 		user, err := q.CreateUser(ctx, CreateUserParams{
 			Phone:    arg.Phone,
 			Email:    arg.Email,
-			Password: arg.Password,
+			Password: hashedPassword,
 			Token:    arg.Token,
 			MemberID: arg.MemberID,
 		})
@@ -50,7 +59,9 @@ type CreateMemberWithAssociatedUserParams struct {
 	Password  string
 }
 
-func (store *SQLStorage) CreateMemberWithAssociatedUserTx(ctx context.Context, arg CreateMemberWithAssociatedUserParams) error {
+func (store *SQLStorage) CreateMemberWithAssociatedUserTx(ctx context.Context, arg CreateMemberWithAssociatedUserParams) (*models.User, error) {
+	var result *models.User
+
 	err := store.execTx(ctx, func(q *Queries) error {
 		newMember, err := q.CreateMember(ctx, CreateMemberParams{
 			FirstName: arg.FirstName,
@@ -95,6 +106,7 @@ func (store *SQLStorage) CreateMemberWithAssociatedUserTx(ctx context.Context, a
 				"error creating the user",
 				"ERR_CRT_MBR_USR_04", err)
 		}
+		result = user
 
 		err = q.UpdateMemberUserID(ctx, UpdateMemberUserIDParams{UserID: user.ID, MemberID: user.MemberID})
 		return utils.Fail(
@@ -102,5 +114,65 @@ func (store *SQLStorage) CreateMemberWithAssociatedUserTx(ctx context.Context, a
 			"ERR_CRT_MBR_USR_05", err)
 	})
 
+	return result, err
+}
+
+type ResetPasswordParams struct {
+	UserID   uint64
+	MemberID uint64
+	OtpID    uint64
+	Password string
+}
+
+// ResetPasswordTx consumes the OTP, stores the new password and invalidates every token and login of the user
+func (store *SQLStorage) ResetPasswordTx(ctx context.Context, arg ResetPasswordParams) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.DeactivateOTP(ctx, arg.OtpID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when desactivating otp %d", arg.OtpID),
+				"ERR_RST_PWD_01", err)
+		}
+
+		hashedPassword, err := helpers.HashPassword(arg.Password)
+		if hashedPassword == "" || err != nil {
+			return utils.Fail(
+				"error when hashing the password",
+				"ERR_RST_PWD_02", err)
+		}
+
+		err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			ID:       arg.UserID,
+			Password: hashedPassword,
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when updating the password of user[%d]", arg.UserID),
+				"ERR_RST_PWD_03", err)
+		}
+
+		err = q.RevokeMemberTokens(ctx, RevokeMemberTokensParams{
+			MemberID:  arg.MemberID,
+			RevokedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when revoking tokens of member[%d]", arg.MemberID),
+				"ERR_RST_PWD_04", err)
+		}
+
+		err = q.RevokeMemberRefreshTokens(ctx, arg.MemberID)
+		if err != nil {
+			return utils.Fail(
+				fmt.Sprintf("error when revoking refresh tokens of member[%d]", arg.MemberID),
+				"ERR_RST_PWD_05", err)
+		}
+
+		err = q.RevokeMemberLogins(ctx, arg.MemberID)
+		return utils.Fail(
+			fmt.Sprintf("error when revoking logins of member[%d]", arg.MemberID),
+			"ERR_RST_PWD_06", err)
+	})
+
 	return err
 }
@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tschwaa.com/api/models"
+)
+
+const createWebAuthnChallenge = `-- name: CreateWebAuthnChallenge :one
+INSERT INTO webauthn_challenges(challenge, ceremony, user_id, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, challenge, ceremony, user_id, expires_at, created_at
+`
+
+type CreateWebAuthnChallengeParams struct {
+	Challenge string    `db:"challenge" json:"challenge"`
+	Ceremony  string    `db:"ceremony" json:"ceremony"`
+	UserID    *uint64   `db:"user_id" json:"user_id"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreateWebAuthnChallenge(ctx context.Context, arg CreateWebAuthnChallengeParams) (*models.WebAuthnChallenge, error) {
+	row := q.db.QueryRowContext(ctx, createWebAuthnChallenge,
+		arg.Challenge,
+		arg.Ceremony,
+		arg.UserID,
+		arg.ExpiresAt,
+	)
+	var i models.WebAuthnChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Challenge,
+		&i.Ceremony,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return &i, err
+}
+
+const deleteExpiredWebAuthnChallenges = `-- name: DeleteExpiredWebAuthnChallenges :exec
+DELETE FROM webauthn_challenges
+WHERE expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredWebAuthnChallenges(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredWebAuthnChallenges)
+	return err
+}
+
+const consumeWebAuthnChallenge = `-- name: ConsumeWebAuthnChallenge :one
+DELETE FROM webauthn_challenges
+WHERE challenge = $1 AND ceremony = $2 AND expires_at > NOW()
+RETURNING id, challenge, ceremony, user_id, expires_at, created_at
+`
+
+type ConsumeWebAuthnChallengeParams struct {
+	Challenge string `db:"challenge" json:"challenge"`
+	Ceremony  string `db:"ceremony" json:"ceremony"`
+}
+
+func (q *Queries) ConsumeWebAuthnChallenge(ctx context.Context, arg ConsumeWebAuthnChallengeParams) (*models.WebAuthnChallenge, error) {
+	row := q.db.QueryRowContext(ctx, consumeWebAuthnChallenge, arg.Challenge, arg.Ceremony)
+	var i models.WebAuthnChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Challenge,
+		&i.Ceremony,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const createWebAuthnCredential = `-- name: CreateWebAuthnCredential :one
+INSERT INTO webauthn_credentials(user_id, credential_id, public_key, algorithm, sign_count, name)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, credential_id, public_key, algorithm, sign_count, name, last_used_at, created_at, updated_at
+`
+
+type CreateWebAuthnCredentialParams struct {
+	UserID       uint64 `db:"user_id" json:"user_id"`
+	CredentialID string `db:"credential_id" json:"credential_id"`
+	PublicKey    []byte `db:"public_key" json:"public_key"`
+	Algorithm    int    `db:"algorithm" json:"algorithm"`
+	SignCount    uint32 `db:"sign_count" json:"sign_count"`
+	Name         string `db:"name" json:"name"`
+}
+
+func (q *Queries) CreateWebAuthnCredential(ctx context.Context, arg CreateWebAuthnCredentialParams) (*models.WebAuthnCredential, error) {
+	row := q.db.QueryRowContext(ctx, createWebAuthnCredential,
+		arg.UserID,
+		arg.CredentialID,
+		arg.PublicKey,
+		arg.Algorithm,
+		arg.SignCount,
+		arg.Name,
+	)
+	var i models.WebAuthnCredential
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.Algorithm,
+		&i.SignCount,
+		&i.Name,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getWebAuthnCredential = `-- name: GetWebAuthnCredential :one
+SELECT id, user_id, credential_id, public_key, algorithm, sign_count, name, last_used_at, created_at, updated_at
+FROM webauthn_credentials
+WHERE credential_id = $1
+`
+
+func (q *Queries) GetWebAuthnCredential(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+	row := q.db.QueryRowContext(ctx, getWebAuthnCredential, credentialID)
+	var i models.WebAuthnCredential
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.Algorithm,
+		&i.SignCount,
+		&i.Name,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const listWebAuthnCredentialsOfUser = `-- name: ListWebAuthnCredentialsOfUser :many
+SELECT id, user_id, credential_id, public_key, algorithm, sign_count, name, last_used_at, created_at, updated_at
+FROM webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListWebAuthnCredentialsOfUser(ctx context.Context, userID uint64) ([]*models.WebAuthnCredential, error) {
+	rows, err := q.db.QueryContext(ctx, listWebAuthnCredentialsOfUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*models.WebAuthnCredential{}
+	for rows.Next() {
+		var i models.WebAuthnCredential
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CredentialID,
+			&i.PublicKey,
+			&i.Algorithm,
+			&i.SignCount,
+			&i.Name,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const useWebAuthnCredential = `-- name: UseWebAuthnCredential :one
+UPDATE webauthn_credentials
+SET sign_count = $3, last_used_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND sign_count = $2
+RETURNING id
+`
+
+type UseWebAuthnCredentialParams struct {
+	ID uint64 `db:"id" json:"id"`
+	// PreviousSignCount makes sure a concurrent login did not use the counter in the meantime
+	PreviousSignCount uint32 `db:"previous_sign_count" json:"previous_sign_count"`
+	SignCount         uint32 `db:"sign_count" json:"sign_count"`
+}
+
+func (q *Queries) UseWebAuthnCredential(ctx context.Context, arg UseWebAuthnCredentialParams) (bool, error) {
+	var id uint64
+	err := q.db.QueryRowContext(ctx, useWebAuthnCredential, arg.ID, arg.PreviousSignCount, arg.SignCount).Scan(&id)
+	if err != nil && err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+const renameWebAuthnCredential = `-- name: RenameWebAuthnCredential :one
+UPDATE webauthn_credentials
+SET name = $3, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, credential_id, public_key, algorithm, sign_count, name, last_used_at, created_at, updated_at
+`
+
+type RenameWebAuthnCredentialParams struct {
+	ID     uint64 `db:"id" json:"id"`
+	UserID uint64 `db:"user_id" json:"user_id"`
+	Name   string `db:"name" json:"name"`
+}
+
+func (q *Queries) RenameWebAuthnCredential(ctx context.Context, arg RenameWebAuthnCredentialParams) (*models.WebAuthnCredential, error) {
+	row := q.db.QueryRowContext(ctx, renameWebAuthnCredential, arg.ID, arg.UserID, arg.Name)
+	var i models.WebAuthnCredential
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.Algorithm,
+		&i.SignCount,
+		&i.Name,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
+
+const deleteWebAuthnCredential = `-- name: DeleteWebAuthnCredential :one
+DELETE FROM webauthn_credentials
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, credential_id, public_key, algorithm, sign_count, name, last_used_at, created_at, updated_at
+`
+
+type DeleteWebAuthnCredentialParams struct {
+	ID     uint64 `db:"id" json:"id"`
+	UserID uint64 `db:"user_id" json:"user_id"`
+}
+
+func (q *Queries) DeleteWebAuthnCredential(ctx context.Context, arg DeleteWebAuthnCredentialParams) (*models.WebAuthnCredential, error) {
+	row := q.db.QueryRowContext(ctx, deleteWebAuthnCredential, arg.ID, arg.UserID)
+	var i models.WebAuthnCredential
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.Algorithm,
+		&i.SignCount,
+		&i.Name,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil && err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &i, err
+}
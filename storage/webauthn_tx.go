@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+
+	"tschwaa.com/api/models"
+	"tschwaa.com/api/utils"
+)
+
+// CreateWebAuthnChallengeTx clears the challenges nobody answered in time and creates a new one
+func (store *SQLStorage) CreateWebAuthnChallengeTx(ctx context.Context, arg CreateWebAuthnChallengeParams) (*models.WebAuthnChallenge, error) {
+	var result *models.WebAuthnChallenge
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.DeleteExpiredWebAuthnChallenges(ctx)
+		if err != nil {
+			return utils.Fail(
+				"error when deleting the expired webauthn challenges",
+				"ERR_CRT_WAN_CHL_01", err)
+		}
+
+		result, err = q.CreateWebAuthnChallenge(ctx, arg)
+		return utils.Fail(
+			"error when creating a webauthn challenge for the "+arg.Ceremony,
+			"ERR_CRT_WAN_CHL_02", err)
+	})
+
+	return result, err
+}